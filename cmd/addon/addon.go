@@ -0,0 +1,39 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package addon
+
+import (
+	"github.com/spf13/cobra"
+	paddon "github.com/utkarsh-pro/kindli/pkg/addon"
+)
+
+// AddonCmd represents the addon command
+var AddonCmd = &cobra.Command{
+	Use:   "addon",
+	Short: "Commands for managing the add-ons of the kind clusters",
+}
+
+func init() {
+	AddonCmd.AddCommand(
+		ListCmd,
+		EnableCmd,
+		DisableCmd,
+	)
+}
+
+func completeAddons(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return paddon.Names(), cobra.ShellCompDirectiveNoFileComp
+}
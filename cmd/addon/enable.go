@@ -0,0 +1,52 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package addon
+
+import (
+	"github.com/spf13/cobra"
+	paddon "github.com/utkarsh-pro/kindli/pkg/addon"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// EnableCmd represents the enable command
+var EnableCmd = &cobra.Command{
+	Use:               "enable <addon>...",
+	Short:             "Enable add-ons in the kind cluster",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeAddons,
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		cname, _ := cmd.Flags().GetString("cluster-name")
+
+		utils.ExitIfNotNil(RunEnable(utils.CreateClusterName(cname, vmName), args))
+	},
+}
+
+func RunEnable(clusterName string, names []string) error {
+	for _, name := range names {
+		if _, err := paddon.Get(name); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		if err := paddon.Enable(clusterName, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
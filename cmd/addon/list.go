@@ -0,0 +1,54 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package addon
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	paddon "github.com/utkarsh-pro/kindli/pkg/addon"
+	"github.com/utkarsh-pro/kindli/pkg/output"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// ListCmd represents the list command
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the available add-ons and the ones enabled in the cluster",
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		cname, _ := cmd.Flags().GetString("cluster-name")
+		out, _ := cmd.Flags().GetString("output")
+
+		format, err := output.ParseFormat(out)
+		utils.ExitIfNotNil(err)
+
+		utils.ExitIfNotNil(RunList(utils.CreateClusterName(cname, vmName), format))
+	},
+}
+
+func init() {
+	ListCmd.Flags().StringP("output", "o", "", output.Usage)
+}
+
+func RunList(clusterName string, format output.Format) error {
+	infos, err := paddon.Infos(clusterName)
+	if err != nil {
+		return err
+	}
+
+	return output.Print(os.Stdout, format, infos)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/output"
+	"github.com/utkarsh-pro/kindli/pkg/topology"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// ApplyCmd represents the apply command
+var ApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Create the VMs and the kind clusters described by a topology file",
+	Long: `Create the VMs and the kind clusters described by a topology file.
+
+The topology is compared with the kindli store and only the missing VMs,
+clusters, MetalLB installations and add-ons are created, applying the same
+topology again is a no-op. The resources and the kind configs are only used
+for new VMs and clusters.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, _ := cmd.Flags().GetString("file")
+		prune, _ := cmd.Flags().GetBool("prune")
+		plan, _ := cmd.Flags().GetBool("plan")
+		out, _ := cmd.Flags().GetString("output")
+
+		format, err := output.ParseFormat(out)
+		utils.ExitIfNotNil(err)
+
+		utils.ExitIfNotNil(RunApply(file, prune, plan, format))
+	},
+}
+
+func init() {
+	ApplyCmd.Flags().StringP("file", "f", "", "topology file")
+	ApplyCmd.MarkFlagRequired("file")
+	ApplyCmd.Flags().Bool("prune", false, "delete the clusters of the VMs of the topology which are not part of the topology")
+	ApplyCmd.Flags().Bool("plan", false, "print the actions needed to converge to the topology without executing them")
+	ApplyCmd.Flags().StringP("output", "o", "", output.Usage+" (used with --plan)")
+}
+
+func RunApply(file string, prune, plan bool, format output.Format) error {
+	t, err := topology.Load(file)
+	if err != nil {
+		return err
+	}
+
+	if plan {
+		p, err := topology.Compute(t, prune)
+		if err != nil {
+			return err
+		}
+
+		return output.Print(os.Stdout, format, p)
+	}
+
+	return topology.Apply(t, prune)
+}
@@ -0,0 +1,38 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/registry"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// DisableCmd represents the disable command
+var DisableCmd = &cobra.Command{
+	Use:   "disable [upstream]...",
+	Short: "Remove the pull-through caches of the upstream registries, or all of them, from the VM",
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		purge, _ := cmd.Flags().GetBool("purge")
+
+		utils.ExitIfNotNil(registry.DisableCache(vmName, args, purge))
+	},
+}
+
+func init() {
+	DisableCmd.Flags().Bool("purge", false, "Remove the cached images as well")
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/registry"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// EnableCmd represents the enable command
+var EnableCmd = &cobra.Command{
+	Use:   "enable [upstream]...",
+	Short: "Run pull-through caches of the upstream registries in the VM",
+	Long: `Run pull-through caches of the upstream registries in the VM
+
+The clusters created in the VM afterwards pull the images of the upstream
+registries through the caches, the cached images are kept on the disk of the
+VM. The caches of ` + strings.Join(registry.DefaultUpstreams, ", ") + ` are enabled if no upstreams are given.`,
+	Example: `kindli cache enable
+kindli cache enable registry.k8s.io`,
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		image, _ := cmd.Flags().GetString("image")
+
+		utils.ExitIfNotNil(registry.EnableCache(vmName, args, image))
+	},
+}
+
+func init() {
+	EnableCmd.Flags().String("image", registry.DefaultImage, "Image of the cache registries")
+}
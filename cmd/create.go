@@ -17,18 +17,24 @@ package cmd
 
 import (
 	"fmt"
-	"path/filepath"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/utkarsh-pro/kindli/pkg/config"
-	"github.com/utkarsh-pro/kindli/pkg/docker"
+	"github.com/utkarsh-pro/kindli/pkg/addon"
 	"github.com/utkarsh-pro/kindli/pkg/kind"
+	"github.com/utkarsh-pro/kindli/pkg/metallb"
 	"github.com/utkarsh-pro/kindli/pkg/utils"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
 )
 
 var (
 	cfg         string
 	skipMetallb bool
+	printConfig bool
+
+	metallbVersion string
+	addons         []string
 )
 
 // CreateCmd represents create command
@@ -49,37 +55,53 @@ var CreateCmd = &cobra.Command{
 func init() {
 	CreateCmd.Flags().StringVarP(&cfg, "config", "c", "", "kind configuration")
 	CreateCmd.Flags().BoolVarP(&skipMetallb, "skip-metallb", "s", false, "skip metallb setup")
+	CreateCmd.Flags().StringVar(&metallbVersion, "metallb-version", "", fmt.Sprintf("metallb version to install, one of: %s (default %s)", strings.Join(metallb.Versions(), ", "), metallb.DefaultVersion()))
+	CreateCmd.RegisterFlagCompletionFunc(
+		"metallb-version",
+		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return metallb.Versions(), cobra.ShellCompDirectiveNoFileComp
+		},
+	)
+	CreateCmd.Flags().StringSliceVar(&addons, "addon", nil, fmt.Sprintf("addons to enable in the cluster, any of: %s", strings.Join(addon.Names(), ", ")))
+	CreateCmd.RegisterFlagCompletionFunc(
+		"addon",
+		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return addon.Names(), cobra.ShellCompDirectiveNoFileComp
+		},
+	)
+	CreateCmd.Flags().BoolVar(&printConfig, "print-config", false, "print the kind config of the cluster instead of creating it")
 }
 
 func RunCreate(name string, vmName string) error {
-	// Create docker context if it doesn't already exists
-	ctxExists, err := docker.ExistsContext(vmName)
-	if err != nil {
-		return err
+	if metallbVersion != "" {
+		if err := metallb.ValidateVersion(metallbVersion); err != nil {
+			return err
+		}
 	}
 
-	if !ctxExists {
-		err := docker.CreateContext(vmName, fmt.Sprintf("host=unix://%s", filepath.Join(config.Dir(), vmName+".sock")))
-		if err != nil {
+	for _, name := range addons {
+		if _, err := addon.Get(name); err != nil {
 			return err
 		}
 	}
 
-	// Switch default docker context to newly created docker context
-	err = docker.UseContext(vmName)
-	if err != nil {
-		return err
+	createCfg := kind.CreateConfig{
+		Name:           utils.CreateClusterName(name, vmName),
+		VMName:         vmName,
+		SkipMetalLB:    skipMetallb,
+		MetalLBVersion: metallbVersion,
+		Addons:         addons,
 	}
 
-	// Create the kind cluster
-	err = kind.Create(cfg, kind.CreateConfig{
-		Name:        utils.CreateClusterName(name, vmName),
-		VMName:      vmName,
-		SkipMetalLB: skipMetallb,
-	})
-	if err != nil {
+	if printConfig {
+		return kind.PrintConfig(cfg, createCfg, os.Stdout)
+	}
+
+	// Create docker context if it doesn't already exists and switch to it
+	if err := vm.UseDockerContext(vmName); err != nil {
 		return err
 	}
 
-	return nil
+	// Create the kind cluster
+	return kind.Create(cfg, createCfg)
 }
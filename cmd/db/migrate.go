@@ -0,0 +1,45 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package db
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	pdb "github.com/utkarsh-pro/kindli/pkg/db"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// MigrateCmd represents the migrate command
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending migrations to the kindli state store",
+	Long: `Apply pending migrations to the kindli state store.
+
+Migrations are also applied automatically whenever kindli is used, the
+database is backed up to ~/.kindli/backups before applying migrations.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.ExitIfNotNil(RunMigrate())
+	},
+}
+
+func RunMigrate() error {
+	if err := pdb.Migrate(); err != nil {
+		return err
+	}
+
+	logrus.Info("✅ Database is up to date")
+	return nil
+}
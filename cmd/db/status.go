@@ -0,0 +1,58 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package db
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	pdb "github.com/utkarsh-pro/kindli/pkg/db"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// StatusCmd represents the status command
+var StatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the migration status of the kindli state store",
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.ExitIfNotNil(RunStatus())
+	},
+}
+
+func RunStatus() error {
+	statuses, err := pdb.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Database:", pdb.Path())
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 8, 4, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tDESCRIPTION\tSTATUS\tAPPLIED AT")
+
+	for _, status := range statuses {
+		state := "PENDING"
+		if status.Applied {
+			state = "APPLIED"
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Description, state, status.AppliedAt)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,49 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/topology"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// DestroyCmd represents the destroy command
+var DestroyCmd = &cobra.Command{
+	Use:   "destroy",
+	Short: "Delete the VMs and the kind clusters described by a topology file",
+	Run: func(cmd *cobra.Command, args []string) {
+		file, _ := cmd.Flags().GetString("file")
+		keepVMs, _ := cmd.Flags().GetBool("keep-vms")
+
+		utils.ExitIfNotNil(RunDestroy(file, keepVMs))
+	},
+}
+
+func init() {
+	DestroyCmd.Flags().StringP("file", "f", "", "topology file")
+	DestroyCmd.MarkFlagRequired("file")
+	DestroyCmd.Flags().Bool("keep-vms", false, "only delete the clusters of the topology and keep the VMs")
+}
+
+func RunDestroy(file string, keepVMs bool) error {
+	t, err := topology.Load(file)
+	if err != nil {
+		return err
+	}
+
+	return topology.Destroy(t, keepVMs)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/doctor"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// DoctorCmd represents doctor command
+var DoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "doctor finds inconsistencies between kindli's state and reality",
+	Long: `doctor finds inconsistencies between kindli's state and reality
+
+Doctor compares the following:
+1. VMs in the kindli store with the VMs of their providers
+2. Docker contexts with the VMs in the kindli store
+3. Clusters in the kindli store with the kind clusters running in the VMs
+4. Clusters in the kindli store with the kind and metallb configs in ~/.kindli
+
+Pass --fix to repair the inconsistencies and to adopt orphaned VMs and clusters.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fix, err := cmd.Flags().GetBool("fix")
+		utils.ExitIfNotNil(err)
+
+		utils.ExitIfNotNil(RunDoctor(fix))
+	},
+}
+
+func init() {
+	DoctorCmd.Flags().Bool("fix", false, "If true, doctor will fix the inconsistencies which can be fixed automatically")
+}
+
+func RunDoctor(fix bool) error {
+	issues, err := doctor.Diagnose()
+	if err != nil {
+		return err
+	}
+
+	if len(issues) == 0 {
+		logrus.Info("✅ No inconsistencies found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 8, 4, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tISSUE\tREMEDY")
+	for _, issue := range issues {
+		remedy := issue.Remedy
+		if !issue.Fixable() {
+			remedy = "(manual) " + remedy
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", issue.Kind, issue.Name, issue.Description, remedy)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !fix {
+		logrus.Info("Run `kindli doctor --fix` to fix the issues")
+		return nil
+	}
+
+	failure := false
+	for _, issue := range issues {
+		if !issue.Fixable() {
+			continue
+		}
+
+		if err := issue.Fix(); err != nil {
+			logrus.Errorf("❌ Failed to fix %s %s: %s", issue.Kind, issue.Name, err)
+			failure = true
+			continue
+		}
+
+		logrus.Infof("✅ Fixed %s %s: %s", issue.Kind, issue.Name, issue.Remedy)
+	}
+
+	if failure {
+		return fmt.Errorf("failed to fix some of the issues")
+	}
+
+	return nil
+}
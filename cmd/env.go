@@ -0,0 +1,87 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/kind"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// EnvCmd represents env command
+var EnvCmd = &cobra.Command{
+	Use:   "env [cluster]",
+	Short: "Print the environment variables which point the current shell at the given kind cluster",
+	Long: `Print the environment variables which point the current shell at the given kind cluster
+
+DOCKER_CONTEXT and DOCKER_HOST point docker at the VM of the cluster and
+KUBECONFIG points kubectl at the standalone kubeconfig of the cluster, the
+default docker context and kubeconfig are left untouched.`,
+	Example: `eval $(kindli env)
+eval $(kindli env dev --vm-name work)
+eval $(kindli env --unset)
+kindli env --shell fish | source`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		shell, err := cmd.Flags().GetString("shell")
+		utils.ExitIfNotNil(err)
+		unset, err := cmd.Flags().GetBool("unset")
+		utils.ExitIfNotNil(err)
+
+		if shell != "posix" && shell != "fish" {
+			utils.ExitIfNotNil(fmt.Errorf("unsupported shell \"%s\", must be one of posix, fish", shell))
+		}
+
+		if unset {
+			printUnset(shell)
+			return
+		}
+
+		c, err := resolveCluster(cmd, args)
+		utils.ExitIfNotNil(err)
+
+		env, err := kind.Env(c)
+		utils.ExitIfNotNil(err)
+
+		for _, e := range env {
+			if shell == "fish" {
+				fmt.Printf("set -gx %s %s;\n", e.Name, sh.Quote(e.Value))
+				continue
+			}
+
+			fmt.Printf("export %s=%s\n", e.Name, sh.Quote(e.Value))
+		}
+	},
+}
+
+func init() {
+	EnvCmd.Flags().String("shell", "posix", "Syntax of the output, one of posix, fish")
+	EnvCmd.Flags().Bool("unset", false, "Print the commands which unset the environment variables")
+}
+
+func printUnset(shell string) {
+	for _, name := range []string{"DOCKER_CONTEXT", "DOCKER_HOST", "KUBECONFIG"} {
+		if shell == "fish" {
+			fmt.Printf("set -e %s;\n", name)
+			continue
+		}
+
+		fmt.Printf("unset %s\n", name)
+	}
+}
@@ -17,34 +17,97 @@ limitations under the License.
 package image
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/spf13/cobra"
 	"github.com/utkarsh-pro/kindli/pkg/image"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/output"
 	"github.com/utkarsh-pro/kindli/pkg/utils"
 )
 
 var LoadCmd = &cobra.Command{
-	Use:     "load",
-	Short:   "Load OCI images in the VM",
-	Example: `kindli image <image-name>`,
-	Run: func(cmd *cobra.Command, args []string) {
-		image := args[0]
+	Use:   "load <image|archive>...",
+	Short: "Load OCI images into the kind clusters",
+	Long: `Load OCI images into the kind clusters
 
+Arguments which are files on the host are loaded as docker or OCI image
+archives, the other arguments are image references. Images are taken from
+the docker daemon of the VM and pulled from their registry if they are
+missing there, --from-host streams them from the docker daemon of the host
+instead.`,
+	Example: `kindli image load nginx:1.23 ./app.tar
+kindli image load --from-host --all-clusters my-app:dev`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
 		vm, err := cmd.Flags().GetString("vm-name")
 		utils.ExitIfNotNil(err)
 		cluster, err := cmd.Flags().GetString("cluster-name")
 		utils.ExitIfNotNil(err)
+		allClusters, err := cmd.Flags().GetBool("all-clusters")
+		utils.ExitIfNotNil(err)
+		fromHost, err := cmd.Flags().GetBool("from-host")
+		utils.ExitIfNotNil(err)
+		hostContext, err := cmd.Flags().GetString("host-context")
+		utils.ExitIfNotNil(err)
+		out, err := cmd.Flags().GetString("output")
+		utils.ExitIfNotNil(err)
+
+		format, err := output.ParseFormat(out)
+		utils.ExitIfNotNil(err)
+
+		opts := image.LoadOptions{FromHost: fromHost, HostContext: hostContext}
+		for _, arg := range args {
+			if image.IsArchive(arg) {
+				opts.Archives = append(opts.Archives, arg)
+				continue
+			}
 
-		cluster = utils.CreateClusterName(cluster, vm)
+			opts.Images = append(opts.Images, arg)
+		}
 
-		utils.ExitIfNotNil(RunLoad(image, cluster))
+		clusterName := utils.CreateClusterName(cluster, vm)
+		if allClusters {
+			clusterName = ""
+		}
+
+		utils.ExitIfNotNil(RunLoad(clusterName, opts, format))
 	},
 }
 
 func init() {
-	ImageCmd.AddCommand()
+	LoadCmd.Flags().Bool("all-clusters", false, "Load the images into all the running clusters of all the VMs")
+	LoadCmd.Flags().Bool("from-host", false, "Stream the images from the docker daemon of the host into the VM")
+	LoadCmd.Flags().String("host-context", image.DefaultHostContext, "Docker context of the docker daemon of the host")
+	LoadCmd.Flags().StringP("output", "o", "", output.Usage)
 }
 
-func RunLoad(imageName, clusterName string) error {
-	image.Load(imageName, clusterName)
-	return nil
+// RunLoad loads the images into the cluster, or into all the clusters if
+// clusterName is empty, and prints the result of every load
+func RunLoad(clusterName string, opts image.LoadOptions, format output.Format) error {
+	clusters, err := models.ListCluster()
+	if err != nil {
+		return fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	if clusterName != "" {
+		c := models.NewCluster(clusterName, "", "")
+		if err := c.GetByName(); err != nil {
+			return fmt.Errorf("failed to find cluster with name \"%s\": %w", clusterName, err)
+		}
+
+		clusters = []models.Cluster{*c}
+	}
+
+	if len(clusters) == 0 {
+		return fmt.Errorf("no clusters to load the images into")
+	}
+
+	results, loadErr := image.Load(clusters, opts)
+	if err := output.Print(os.Stdout, format, results); err != nil {
+		return err
+	}
+
+	return loadErr
 }
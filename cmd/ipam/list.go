@@ -0,0 +1,52 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ipam
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	pipam "github.com/utkarsh-pro/kindli/pkg/ipam"
+	"github.com/utkarsh-pro/kindli/pkg/output"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// ListCmd represents the list command
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the allocated subnets",
+	Run: func(cmd *cobra.Command, args []string) {
+		out, _ := cmd.Flags().GetString("output")
+
+		format, err := output.ParseFormat(out)
+		utils.ExitIfNotNil(err)
+
+		utils.ExitIfNotNil(RunList(format))
+	},
+}
+
+func init() {
+	ListCmd.Flags().StringP("output", "o", "", output.Usage)
+}
+
+func RunList(format output.Format) error {
+	allocs, err := pipam.Allocations()
+	if err != nil {
+		return err
+	}
+
+	return output.Print(os.Stdout, format, allocs)
+}
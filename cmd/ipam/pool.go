@@ -0,0 +1,106 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ipam
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	pipam "github.com/utkarsh-pro/kindli/pkg/ipam"
+	"github.com/utkarsh-pro/kindli/pkg/output"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// PoolsCmd represents the pools command
+var PoolsCmd = &cobra.Command{
+	Use:   "pools",
+	Short: "List the pools from which the subnets are allocated",
+	Run: func(cmd *cobra.Command, args []string) {
+		out, _ := cmd.Flags().GetString("output")
+
+		format, err := output.ParseFormat(out)
+		utils.ExitIfNotNil(err)
+
+		utils.ExitIfNotNil(RunPools(format))
+	},
+}
+
+// SetPoolCmd represents the set-pool command
+var SetPoolCmd = &cobra.Command{
+	Use:   "set-pool <pool> <cidr>",
+	Short: "Change the CIDR and the subnet size of a pool",
+	Long: `Change the CIDR and the subnet size of a pool.
+
+Only new allocations are affected, the subnets of the existing clusters are
+left untouched. Pass an empty CIDR to a LoadBalancer pool to allocate from
+the subnet of the "kind" docker network of the VM.`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		pools, err := pipam.Pools()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		names := []string{}
+		for _, pool := range pools {
+			names = append(names, pool.Name)
+		}
+
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		prefixLen, _ := cmd.Flags().GetInt("prefix-len")
+
+		utils.ExitIfNotNil(RunSetPool(args[0], args[1], prefixLen))
+	},
+}
+
+func init() {
+	PoolsCmd.Flags().StringP("output", "o", "", output.Usage)
+	SetPoolCmd.Flags().Int("prefix-len", 0, "Prefix length of the subnets allocated from the pool (default: unchanged)")
+}
+
+func RunPools(format output.Format) error {
+	pools, err := pipam.Pools()
+	if err != nil {
+		return err
+	}
+
+	return output.Print(os.Stdout, format, pools)
+}
+
+func RunSetPool(name, cidr string, prefixLen int) error {
+	if prefixLen == 0 {
+		pool, err := pipam.GetPool(name)
+		if err != nil {
+			return err
+		}
+
+		prefixLen = pool.PrefixLen
+	}
+
+	if err := pipam.SetPool(name, cidr, prefixLen); err != nil {
+		return err
+	}
+
+	logrus.Infof("✅ Updated pool %s", name)
+	return nil
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package kubeconfig
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	pkubeconfig "github.com/utkarsh-pro/kindli/pkg/kubeconfig"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// MergeCmd represents the merge command
+var MergeCmd = &cobra.Command{
+	Use:   "merge",
+	Short: "Merge the kubeconfig of the kind cluster into KUBECONFIG or ~/.kube/config",
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		cname, _ := cmd.Flags().GetString("cluster-name")
+		setCurrent, _ := cmd.Flags().GetBool("set-current")
+
+		clusterName := utils.CreateClusterName(cname, vmName)
+
+		_, err := pkubeconfig.Export(clusterName, vmName)
+		utils.ExitIfNotNil(err)
+
+		utils.ExitIfNotNil(pkubeconfig.Merge(clusterName, setCurrent))
+
+		logrus.Infof("✅ Merged context %s into %s", pkubeconfig.ContextName(clusterName), pkubeconfig.KubeconfigPath())
+	},
+}
+
+func init() {
+	MergeCmd.Flags().Bool("set-current", false, "Make the context of the cluster the current context")
+}
@@ -0,0 +1,38 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package kubeconfig
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	pkubeconfig "github.com/utkarsh-pro/kindli/pkg/kubeconfig"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// RemoveCmd represents the remove command
+var RemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove the context of the kind cluster from the kubeconfig and delete its standalone kubeconfig",
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		cname, _ := cmd.Flags().GetString("cluster-name")
+
+		clusterName := utils.CreateClusterName(cname, vmName)
+		utils.ExitIfNotNil(pkubeconfig.Remove(clusterName))
+
+		logrus.Infof("✅ Removed context %s", pkubeconfig.ContextName(clusterName))
+	},
+}
@@ -16,8 +16,12 @@ limitations under the License.
 package cmd
 
 import (
+	"os"
+
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/utkarsh-pro/kindli/pkg/kind"
+	"github.com/utkarsh-pro/kindli/pkg/output"
 	"github.com/utkarsh-pro/kindli/pkg/utils"
 )
 
@@ -28,20 +32,35 @@ var ListCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		name, _ := cmd.Flags().GetString("vm-name")
 		all, _ := cmd.Flags().GetBool("all")
+		out, _ := cmd.Flags().GetString("output")
+
+		format, err := output.ParseFormat(out)
+		utils.ExitIfNotNil(err)
 
 		if all {
-			utils.ExitIfNotNil(RunList(""))
+			utils.ExitIfNotNil(RunList("", format))
 			return
 		}
 
-		utils.ExitIfNotNil(RunList(name))
+		utils.ExitIfNotNil(RunList(name, format))
 	},
 }
 
-func RunList(vmName string) error {
-	return kind.List(vmName)
+func RunList(vmName string, format output.Format) error {
+	clusters, err := kind.List(vmName)
+	if err != nil {
+		return err
+	}
+
+	if len(clusters) == 0 && (format.Name == output.Table || format.Name == output.Wide) {
+		logrus.Warn("⚠️ No clusters found")
+		return nil
+	}
+
+	return output.Print(os.Stdout, format, clusters)
 }
 
 func init() {
 	ListCmd.Flags().BoolP("all", "A", false, "Set to list clusters of all the Kindli VMs")
+	ListCmd.Flags().StringP("output", "o", "", output.Usage)
 }
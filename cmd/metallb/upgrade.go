@@ -0,0 +1,90 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metallb
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	pmetallb "github.com/utkarsh-pro/kindli/pkg/metallb"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// UpgradeCmd represents the upgrade command
+var UpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade MetalLB in the kind cluster to a newer embedded version",
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		cname, _ := cmd.Flags().GetString("cluster-name")
+		version, _ := cmd.Flags().GetString("version")
+		all, _ := cmd.Flags().GetBool("all")
+
+		if all {
+			utils.ExitIfNotNil(RunUpgradeAll(version))
+			return
+		}
+
+		utils.ExitIfNotNil(RunUpgrade(utils.CreateClusterName(cname, vmName), version))
+	},
+}
+
+func init() {
+	UpgradeCmd.Flags().String("version", "", fmt.Sprintf("metallb version to upgrade to (default %s)", pmetallb.DefaultVersion()))
+	UpgradeCmd.RegisterFlagCompletionFunc(
+		"version",
+		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return pmetallb.Versions(), cobra.ShellCompDirectiveNoFileComp
+		},
+	)
+	UpgradeCmd.Flags().BoolP("all", "A", false, "Upgrade metallb in all the clusters which have metallb installed")
+}
+
+func RunUpgrade(clusterName, version string) error {
+	if version != "" {
+		if err := pmetallb.ValidateVersion(version); err != nil {
+			return err
+		}
+	}
+
+	if err := pmetallb.Upgrade(clusterName, version); err != nil {
+		return err
+	}
+
+	logrus.Infof("✅ MetalLB of cluster \"%s\" is up to date", clusterName)
+	return nil
+}
+
+func RunUpgradeAll(version string) error {
+	clusters, err := models.ListCluster()
+	if err != nil {
+		return fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	for _, c := range clusters {
+		if c.MetalLBVersion == "" {
+			continue
+		}
+
+		if err := RunUpgrade(c.Name, version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
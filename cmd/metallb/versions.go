@@ -0,0 +1,56 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metallb
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	pmetallb "github.com/utkarsh-pro/kindli/pkg/metallb"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// VersionsCmd represents the versions command
+var VersionsCmd = &cobra.Command{
+	Use:   "versions",
+	Short: "List the MetalLB versions supported by kindli",
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.ExitIfNotNil(RunVersions())
+	},
+}
+
+func RunVersions() error {
+	w := tabwriter.NewWriter(os.Stdout, 4, 8, 4, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tDEFAULT\tEMBEDDED")
+
+	for _, version := range pmetallb.Versions() {
+		def := ""
+		if version == pmetallb.DefaultVersion() {
+			def = "*"
+		}
+
+		embedded := "no (downloaded on first use)"
+		if pmetallb.Embedded(version) {
+			embedded = "yes"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", version, def, embedded)
+	}
+
+	return w.Flush()
+}
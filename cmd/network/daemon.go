@@ -0,0 +1,127 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/dns"
+	"github.com/utkarsh-pro/kindli/pkg/networking"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// DaemonCmd represents the daemon command
+var DaemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep the routes to the kind networks of all the running VMs in sync",
+	Long: `Keep the routes to the kind networks of all the running VMs in sync
+
+The daemon also serves the DNS names <service>.<namespace>.<cluster>.kindli.local
+of the LoadBalancer services of the running clusters on --dns, an empty
+address disables it. Use "kindli network dns install" to make the host
+resolve the names with it.
+
+The daemon runs until it is terminated (SIGINT or SIGTERM) and removes the
+routes it installed on exit. Use "kindli network daemon install" to run it
+as a systemd user unit (Linux) or a launchd agent (MacOS).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		interval, err := cmd.Flags().GetDuration("interval")
+		utils.ExitIfNotNil(err)
+
+		dnsAddress, _ := cmd.Flags().GetString("dns")
+
+		utils.ExitIfNotNil(RunDaemon(interval, dnsAddress))
+	},
+}
+
+// DaemonInstallCmd represents the daemon install command
+var DaemonInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Run the daemon as a user service",
+	Long: `Run the daemon as a user service
+
+The service manages the routes with sudo, which must be configured to not
+prompt for a password for the route commands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		interval, err := cmd.Flags().GetDuration("interval")
+		utils.ExitIfNotNil(err)
+
+		dnsAddress, _ := cmd.Flags().GetString("dns")
+
+		utils.ExitIfNotNil(RunDaemonInstall(interval, dnsAddress))
+	},
+}
+
+// DaemonUninstallCmd represents the daemon uninstall command
+var DaemonUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop and remove the user service of the daemon",
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.ExitIfNotNil(RunDaemonUninstall())
+	},
+}
+
+func init() {
+	DaemonCmd.PersistentFlags().Duration("interval", networking.DefaultSyncInterval, "Interval between the syncs of the routes")
+	DaemonCmd.PersistentFlags().String("dns", dns.DefaultAddress, "Address of the DNS server of the LoadBalancer services, empty disables it")
+
+	DaemonCmd.AddCommand(
+		DaemonInstallCmd,
+		DaemonUninstallCmd,
+	)
+}
+
+func RunDaemon(interval time.Duration, dnsAddress string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if dnsAddress != "" {
+		go func() {
+			// The routes are still useful without the DNS server
+			if err := dns.Serve(ctx, dnsAddress, interval); err != nil {
+				logrus.Warnf("DNS server stopped: %s", err)
+			}
+		}()
+	}
+
+	return networking.Daemon(ctx, interval)
+}
+
+func RunDaemonInstall(interval time.Duration, dnsAddress string) error {
+	path, err := networking.InstallService(interval, dnsAddress)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("✅ Installed network daemon service at %s", path)
+	return nil
+}
+
+func RunDaemonUninstall() error {
+	if err := networking.UninstallService(); err != nil {
+		return err
+	}
+
+	logrus.Info("✅ Uninstalled network daemon service")
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/dns"
+	"github.com/utkarsh-pro/kindli/pkg/output"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// DNSCmd represents the dns command
+var DNSCmd = &cobra.Command{
+	Use:   "dns",
+	Short: "Show the DNS names of the LoadBalancer services of the clusters of the VM",
+	Long: `Show the DNS names of the LoadBalancer services of the clusters of the VM
+
+The LoadBalancer services are resolved as
+<service>.<namespace>.<cluster>.kindli.local by the DNS server of
+"kindli network daemon". Use "kindli network dns install" to make the host
+resolve the names with it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		all, _ := cmd.Flags().GetBool("all")
+		out, _ := cmd.Flags().GetString("output")
+
+		format, err := output.ParseFormat(out)
+		utils.ExitIfNotNil(err)
+
+		if all {
+			vmName = ""
+		}
+
+		utils.ExitIfNotNil(RunDNS(vmName, format))
+	},
+}
+
+var DNSInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Resolve the DNS names of the LoadBalancer services on the host",
+	Long: `Resolve the DNS names of the LoadBalancer services on the host
+
+The queries of kindli.local are sent to the DNS server of the network daemon,
+with a resolver file (/etc/resolver/kindli.local) on MacOS and with a
+systemd-resolved drop-in (/etc/systemd/resolved.conf.d/kindli.conf) on Linux.
+Requires root access.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		address, _ := cmd.Flags().GetString("address")
+
+		utils.ExitIfNotNil(RunDNSInstall(address))
+	},
+}
+
+var DNSUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop resolving the DNS names of the LoadBalancer services on the host",
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.ExitIfNotNil(RunDNSUninstall())
+	},
+}
+
+func init() {
+	DNSCmd.Flags().BoolP("all", "A", false, "Show the DNS names of the clusters of all the VMs")
+	DNSCmd.Flags().StringP("output", "o", "", output.Usage)
+
+	DNSInstallCmd.Flags().String("address", dns.DefaultAddress, "Address of the DNS server of the network daemon")
+
+	DNSCmd.AddCommand(
+		DNSInstallCmd,
+		DNSUninstallCmd,
+	)
+}
+
+func RunDNS(vmName string, format output.Format) error {
+	records, err := dns.List(context.Background(), vmName)
+	if err != nil {
+		return err
+	}
+
+	return output.Print(os.Stdout, format, records)
+}
+
+func RunDNSInstall(address string) error {
+	path, err := dns.InstallResolver(address)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("✅ Installed resolver configuration at %s", path)
+	return nil
+}
+
+func RunDNSUninstall() error {
+	if err := dns.UninstallResolver(); err != nil {
+		return err
+	}
+
+	logrus.Info("✅ Uninstalled resolver configuration")
+	return nil
+}
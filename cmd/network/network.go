@@ -27,5 +27,8 @@ func init() {
 	NetworkCmd.AddCommand(
 		SetupCmd,
 		CleanupCmd,
+		DaemonCmd,
+		StatusCmd,
+		DNSCmd,
 	)
 }
@@ -0,0 +1,60 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/networking"
+	"github.com/utkarsh-pro/kindli/pkg/output"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// StatusCmd represents the status command
+var StatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the routes and the iptables rules installed for the VM",
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		all, _ := cmd.Flags().GetBool("all")
+		out, _ := cmd.Flags().GetString("output")
+
+		format, err := output.ParseFormat(out)
+		utils.ExitIfNotNil(err)
+
+		if all {
+			vmName = ""
+		}
+
+		utils.ExitIfNotNil(RunStatus(vmName, format))
+	},
+}
+
+func init() {
+	StatusCmd.Flags().BoolP("all", "A", false, "Show the routes and the rules of all the running VMs")
+	StatusCmd.Flags().StringP("output", "o", "", output.Usage)
+}
+
+func RunStatus(vmName string, format output.Format) error {
+	rules, err := networking.Status(vmName)
+	if err != nil {
+		return err
+	}
+
+	return output.Print(os.Stdout, format, rules)
+}
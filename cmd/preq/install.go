@@ -49,39 +49,8 @@ func install() error {
 	for _, missing := range missings {
 		log.Infof("⚠️ %s missing: Attempting to install...", missing)
 
-		switch missing {
-		case "brew":
-			if err := ppreq.InstallBrew(); err != nil {
-				return err
-			}
-		case "git":
-			if err := ppreq.InstallGit(); err != nil {
-				return err
-			}
-		case "make":
-			if err := ppreq.InstallMake(); err != nil {
-				return err
-			}
-		case "automake":
-			if err := ppreq.InstallAutoMake(); err != nil {
-				return err
-			}
-		case "autoconf":
-			if err := ppreq.InstallAutoConf(); err != nil {
-				return err
-			}
-		case "limactl":
-			if err := ppreq.InstallLima(); err != nil {
-				return err
-			}
-		case "vde_switch":
-			if err := ppreq.InstallSwitch(); err != nil {
-				return err
-			}
-		case "vde_vmnet":
-			if err := ppreq.InstallVMNet(); err != nil {
-				return err
-			}
+		if err := ppreq.Install(missing); err != nil {
+			return err
 		}
 		log.Infof("✅ Installed %s", missing)
 	}
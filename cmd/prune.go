@@ -87,7 +87,7 @@ Prune process will perform the following operations:
 		cleanLima, err := cmd.Flags().GetBool("clean-lima")
 		utils.ExitIfNotNil(err)
 		if cleanLima {
-			if err := sh.RunSilent("limactl prune"); err != nil {
+			if err := sh.NewCommand("limactl", "prune").RunSilent(); err != nil {
 				logrus.Error("Failed to remove lima cache: ", err)
 			}
 		}
@@ -0,0 +1,45 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package registry
+
+import (
+	"github.com/spf13/cobra"
+	pregistry "github.com/utkarsh-pro/kindli/pkg/registry"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// EnableCmd represents the enable command
+var EnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Run a local registry in the VM which is used by the clusters created afterwards",
+	Long: `Run a local registry in the VM which is used by the clusters created afterwards
+
+The registry is published on localhost of the host, images pushed to it, e.g.
+"docker push localhost:5000/app:dev", are pulled by the same name in the
+clusters of the VM.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		port, _ := cmd.Flags().GetInt("port")
+		image, _ := cmd.Flags().GetString("image")
+
+		utils.ExitIfNotNil(pregistry.Enable(vmName, port, image))
+	},
+}
+
+func init() {
+	EnableCmd.Flags().Int("port", 0, "Port of the registry on the host, defaults to the next free port starting from 5000")
+	EnableCmd.Flags().String("image", pregistry.DefaultImage, "Image of the registry")
+}
@@ -17,20 +17,39 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/cmd/addon"
+	"github.com/utkarsh-pro/kindli/cmd/cache"
+	cdb "github.com/utkarsh-pro/kindli/cmd/db"
 	"github.com/utkarsh-pro/kindli/cmd/image"
+	"github.com/utkarsh-pro/kindli/cmd/ipam"
+	ckubeconfig "github.com/utkarsh-pro/kindli/cmd/kubeconfig"
+	"github.com/utkarsh-pro/kindli/cmd/metallb"
 	"github.com/utkarsh-pro/kindli/cmd/network"
 	"github.com/utkarsh-pro/kindli/cmd/preq"
+	"github.com/utkarsh-pro/kindli/cmd/registry"
+	"github.com/utkarsh-pro/kindli/cmd/snapshot"
 	"github.com/utkarsh-pro/kindli/cmd/vm"
+	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/db"
+	"github.com/utkarsh-pro/kindli/pkg/k8s"
 	"github.com/utkarsh-pro/kindli/pkg/kind"
+	"github.com/utkarsh-pro/kindli/pkg/kubeconfig"
+	"github.com/utkarsh-pro/kindli/pkg/manifest"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
 )
 
+var dryRun bool
+
+var downloadManifests bool
+
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:   "kindli",
-	Short: "Kindli lets users create upto 100 kind clusters in a Linux based virtual machine",
+	Short: "Kindli lets users create kind clusters in a Linux based virtual machine",
 }
 
 func Execute() {
@@ -41,19 +60,38 @@ func Execute() {
 }
 
 func init() {
+	cobra.OnInitialize(initState)
+
 	RootCmd.AddCommand(
 		preq.PreqCmd,
 		vm.VMCmd,
 		network.NetworkCmd,
 		image.ImageCmd,
+		cdb.DBCmd,
+		ipam.IPAMCmd,
+		metallb.MetalLBCmd,
+		addon.AddonCmd,
+		snapshot.SnapshotCmd,
+		registry.RegistryCmd,
+		cache.CacheCmd,
+		ckubeconfig.KubeconfigCmd,
 		CreateCmd,
+		ApplyCmd,
+		DestroyCmd,
 		DeleteCmd,
+		StopCmd,
+		StartCmd,
 		InitCmd,
 		PruneCmd,
 		DockerEnvCmd,
+		UseCmd,
+		EnvCmd,
 		ListCmd,
+		DoctorCmd,
 	)
 
+	RootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print the commands kindli would execute instead of executing them")
+	RootCmd.PersistentFlags().BoolVar(&downloadManifests, "download-manifests", false, "Download the manifests which are not embedded in this build, they are verified against their pinned digests")
 	RootCmd.PersistentFlags().String("vm-name", "kindli", "Name of the VM")
 	RootCmd.RegisterFlagCompletionFunc(
 		"vm-name",
@@ -101,3 +139,22 @@ func init() {
 		},
 	)
 }
+
+// initState initializes the command runner and the database
+func initState() {
+	dbPath := filepath.Join(config.Dir(), "db.sqlite")
+
+	manifest.SetDownload(downloadManifests)
+
+	if dryRun {
+		sh.SetRunner(sh.NewDryRunner(os.Stdout))
+		k8s.SetDryRun(os.Stdout)
+		kubeconfig.SetDryRun(os.Stdout)
+
+		// Work on a copy of the database so that the state is left untouched
+		db.SetupEphemeral(dbPath)
+		return
+	}
+
+	db.Setup(dbPath)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package snapshot
+
+import (
+	"github.com/spf13/cobra"
+	psnapshot "github.com/utkarsh-pro/kindli/pkg/snapshot"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// CloneCmd represents the clone command
+var CloneCmd = &cobra.Command{
+	Use:   "clone <name> <new-cluster-name>",
+	Short: "Create a new kind cluster from a snapshot",
+	Long: `Create a new kind cluster from a snapshot.
+
+The new cluster is created in the VM of the snapshot from the kind config
+and the node images of the snapshot, with fresh service, pod and MetalLB
+subnets. The MetalLB version and the add-ons of the snapshot are installed
+in the new cluster. The etcd, kubelet and containerd state of the snapshot
+is bound to the snapshotted cluster and is not part of the new cluster.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeSnapshots,
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		cname, _ := cmd.Flags().GetString("cluster-name")
+
+		utils.ExitIfNotNil(psnapshot.Clone(
+			utils.CreateClusterName(cname, vmName),
+			args[0],
+			utils.CreateClusterName(args[1], vmName),
+		))
+	},
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package snapshot
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/output"
+	psnapshot "github.com/utkarsh-pro/kindli/pkg/snapshot"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// ListCmd represents the list command
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the snapshots of the kind cluster",
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		cname, _ := cmd.Flags().GetString("cluster-name")
+		all, _ := cmd.Flags().GetBool("all")
+		out, _ := cmd.Flags().GetString("output")
+
+		format, err := output.ParseFormat(out)
+		utils.ExitIfNotNil(err)
+
+		clusterName := utils.CreateClusterName(cname, vmName)
+		if all {
+			clusterName = ""
+		}
+
+		utils.ExitIfNotNil(RunList(clusterName, format))
+	},
+}
+
+func init() {
+	ListCmd.Flags().BoolP("all", "A", false, "List the snapshots of all the clusters")
+	ListCmd.Flags().StringP("output", "o", "", output.Usage)
+}
+
+func RunList(clusterName string, format output.Format) error {
+	snapshots, err := psnapshot.Snapshots(clusterName)
+	if err != nil {
+		return err
+	}
+
+	return output.Print(os.Stdout, format, snapshots)
+}
@@ -0,0 +1,41 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package snapshot
+
+import (
+	"github.com/spf13/cobra"
+	psnapshot "github.com/utkarsh-pro/kindli/pkg/snapshot"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// RestoreCmd represents the restore command
+var RestoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Roll the kind cluster back to a snapshot",
+	Long: `Roll the kind cluster back to a snapshot.
+
+The current nodes of the cluster are replaced by nodes created from the
+snapshot, with the same names and addresses. A deleted cluster can be
+restored as long as its subnets have not been allocated to another cluster.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapshots,
+	Run: func(cmd *cobra.Command, args []string) {
+		vmName, _ := cmd.Flags().GetString("vm-name")
+		cname, _ := cmd.Flags().GetString("cluster-name")
+
+		utils.ExitIfNotNil(psnapshot.Restore(utils.CreateClusterName(cname, vmName), args[0]))
+	},
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package snapshot
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// SnapshotCmd represents the snapshot command
+var SnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Commands for taking and restoring snapshots of the kind clusters",
+}
+
+func init() {
+	SnapshotCmd.AddCommand(
+		CreateCmd,
+		RestoreCmd,
+		CloneCmd,
+		ListCmd,
+		DeleteCmd,
+	)
+}
+
+// completeSnapshots completes the names of the snapshots of the cluster
+func completeSnapshots(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	vmName, _ := cmd.Flags().GetString("vm-name")
+	cname, _ := cmd.Flags().GetString("cluster-name")
+
+	snapshots, err := models.ListSnapshot(utils.CreateClusterName(cname, vmName))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	names := []string{}
+	for _, s := range snapshots {
+		names = append(names, s.Name)
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
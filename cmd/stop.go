@@ -0,0 +1,49 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/kind"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// StopCmd represents stop command
+var StopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop given kind cluster without deleting it",
+	Long: `Stop given kind cluster without deleting it
+
+The node containers of the cluster are stopped, or paused with --pause, the
+cluster keeps its subnets, LoadBalancer IPs and configs. Use "kindli start"
+to bring it back.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, err := cmd.Flags().GetString("vm-name")
+		utils.ExitIfNotNil(err)
+
+		cname, err := cmd.Flags().GetString("cluster-name")
+		utils.ExitIfNotNil(err)
+
+		pause, err := cmd.Flags().GetBool("pause")
+		utils.ExitIfNotNil(err)
+
+		utils.ExitIfNotNil(kind.Stop(utils.CreateClusterName(cname, name), pause))
+	},
+}
+
+func init() {
+	StopCmd.Flags().Bool("pause", false, "Pause the node containers instead of stopping them, keeps the memory of the cluster allocated but resumes faster")
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2022 Utkarsh Srivastava <utkarsh@sagacious.dev>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/kind"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// UseCmd represents use command
+var UseCmd = &cobra.Command{
+	Use:   "use [cluster]",
+	Short: "Switch the docker context and the kube context to the given kind cluster",
+	Long: `Switch the docker context and the kube context to the given kind cluster
+
+The cluster is either the full name of the cluster or its name in the VM given
+by --vm-name, --cluster-name is used if no cluster is given. Use "kindli env"
+to point only the current shell at the cluster.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := resolveCluster(cmd, args)
+		utils.ExitIfNotNil(err)
+
+		utils.ExitIfNotNil(kind.Use(c))
+	},
+}
+
+// resolveCluster returns the full name of the cluster given as argument or
+// by the flags
+func resolveCluster(cmd *cobra.Command, args []string) (string, error) {
+	vmName, err := cmd.Flags().GetString("vm-name")
+	if err != nil {
+		return "", err
+	}
+
+	cname, err := cmd.Flags().GetString("cluster-name")
+	if err != nil {
+		return "", err
+	}
+
+	if len(args) == 0 {
+		return utils.CreateClusterName(cname, vmName), nil
+	}
+
+	c, err := kind.Resolve(args[0], vmName)
+	if err != nil {
+		return "", err
+	}
+
+	return c.Name, nil
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/utkarsh-pro/kindli/pkg/sh"
 	"github.com/utkarsh-pro/kindli/pkg/utils"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
 )
 
 // EditCmd represents the edit command
@@ -35,5 +36,14 @@ var EditCmd = &cobra.Command{
 }
 
 func RunEdit(name string) error {
-	return sh.Run(fmt.Sprintf("limactl edit %s", name))
+	provider, err := vm.ProviderFor(name)
+	if err != nil {
+		return err
+	}
+
+	if provider.Name() != "lima" {
+		return fmt.Errorf("editing VMs of provider \"%s\" is not supported", provider.Name())
+	}
+
+	return sh.NewCommand("limactl", "edit", name).Run()
 }
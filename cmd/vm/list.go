@@ -17,9 +17,11 @@ package vm
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/output"
 	"github.com/utkarsh-pro/kindli/pkg/utils"
 	"github.com/utkarsh-pro/kindli/pkg/vm"
 )
@@ -30,13 +32,30 @@ var ListCmd = &cobra.Command{
 	Short:      "Prints the list of VMs",
 	Deprecated: "This command is deprecated use `kindli vm status -A` instead",
 	Run: func(cmd *cobra.Command, args []string) {
-		vms, err := RunList()
+		out, _ := cmd.Flags().GetString("output")
+
+		format, err := output.ParseFormat(out)
 		utils.ExitIfNotNil(err)
 
-		fmt.Println(strings.Join(vms, "\n"))
+		// Preserve the plain list of names when no format is requested
+		if format.Name == output.Table {
+			vms, err := RunList()
+			utils.ExitIfNotNil(err)
+
+			fmt.Println(strings.Join(vms, "\n"))
+			return
+		}
+
+		infos, err := vm.Status("")
+		utils.ExitIfNotNil(err)
+		utils.ExitIfNotNil(output.Print(os.Stdout, format, infos))
 	},
 }
 
+func init() {
+	ListCmd.Flags().StringP("output", "o", "", output.Usage)
+}
+
 func RunList() ([]string, error) {
 	return vm.List()
 }
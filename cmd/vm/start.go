@@ -17,7 +17,6 @@ package vm
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/utkarsh-pro/kindli/pkg/utils"
@@ -31,6 +30,7 @@ var (
 	arch     string
 	mounts   []string
 	fipsFlag bool
+	provider string
 )
 
 // StartCmd represents the start command
@@ -39,12 +39,19 @@ var StartCmd = &cobra.Command{
 	Short: "Start a new VM for Kindli",
 	Long: `Start a new VM for Kindli.
 
-NOTE: VM will be created using lima`,
+NOTE: VM will be created using lima unless a different provider is
+selected via --provider. The provider of an existing VM cannot be changed.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if arch != "" && arch != "x86_64" && arch != "aarch64" {
 			return fmt.Errorf("invalid --arch value, can be only \"x86_64\" or \"aarch64\"")
 		}
 
+		if provider != "" {
+			if _, err := vm.GetProvider(provider); err != nil {
+				return fmt.Errorf("invalid --provider value: %w", err)
+			}
+		}
+
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
@@ -55,55 +62,35 @@ NOTE: VM will be created using lima`,
 }
 
 func init() {
-	StartCmd.Flags().IntVar(&cpu, "cpu", 4, "specify number of cpu assigned to VM")
-	StartCmd.Flags().StringVar(&mem, "mem", "16GiB", "specify memory to be assigned to VM")
-	StartCmd.Flags().StringVar(&disk, "disk", "100GiB", "specify disk space assigned to the VM")
+	StartCmd.Flags().IntVar(&cpu, "cpu", vm.DefaultCPU, "specify number of cpu assigned to VM")
+	StartCmd.Flags().StringVar(&mem, "mem", vm.DefaultMemory, "specify memory to be assigned to VM")
+	StartCmd.Flags().StringVar(&disk, "disk", vm.DefaultDisk, "specify disk space assigned to the VM")
 	StartCmd.Flags().StringVar(&arch, "arch", "", "VM architecture")
 	StartCmd.Flags().StringSliceVar(&mounts, "mount", nil, "specify mounts in form of <PATH>:rw to make the mount available for read/write or in form of <PATH>:ro to make the mount available only for reading")
 	StartCmd.Flags().BoolVar(&fipsFlag, "fips", false, "enable FIPS mode for the VM")
+	StartCmd.Flags().StringVar(&provider, "provider", "", fmt.Sprintf("VM provider used to create the VM, one of %v (default \"lima\")", vm.Providers()))
+	StartCmd.RegisterFlagCompletionFunc(
+		"provider",
+		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return vm.Providers(), cobra.ShellCompDirectiveNoFileComp
+		},
+	)
 }
 
 func RunStart(name string) error {
-	return vm.Start(createOverrides(), true, name)
+	return vm.Start(provider, createOverrides(), true, name)
 }
 
 func createOverrides() map[string]interface{} {
-	overrides := map[string]interface{}{
-		"CPU":    cpu,
-		"Memory": mem,
-		"Disk":   disk,
-		"Arch":   arch,
-		"FIPS":   fipsFlag,
-	}
-
-	parsedMounts, err := parseMounts(mounts)
+	overrides, err := vm.Spec{
+		CPU:    cpu,
+		Memory: mem,
+		Disk:   disk,
+		Arch:   arch,
+		Mounts: mounts,
+		FIPS:   fipsFlag,
+	}.Overrides()
 	utils.ExitIfNotNil(err)
 
-	overrides["Mounts"] = parsedMounts
-
 	return overrides
 }
-
-func parseMounts(mounts []string) ([]map[string]interface{}, error) {
-	mapped := []map[string]interface{}{}
-
-	for _, mount := range mounts {
-		splitted := strings.Split(mount, ":")
-		if len(splitted) != 2 {
-			return nil, fmt.Errorf("failed to parse mount: %s", mount)
-		}
-
-		writable := false
-
-		if splitted[1] == "rw" {
-			writable = true
-		}
-
-		mapped = append(mapped, map[string]interface{}{
-			"location": splitted[0],
-			"writable": writable,
-		})
-	}
-
-	return mapped, nil
-}
@@ -16,9 +16,10 @@ limitations under the License.
 package vm
 
 import (
-	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/utkarsh-pro/kindli/pkg/output"
 	"github.com/utkarsh-pro/kindli/pkg/utils"
 	"github.com/utkarsh-pro/kindli/pkg/vm"
 )
@@ -30,27 +31,30 @@ var StatusCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		name, _ := cmd.Flags().GetString("vm-name")
 		all, _ := cmd.Flags().GetBool("all")
+		out, _ := cmd.Flags().GetString("output")
+
+		format, err := output.ParseFormat(out)
+		utils.ExitIfNotNil(err)
 
 		if all {
-			utils.ExitIfNotNil(RunStatus(""))
+			utils.ExitIfNotNil(RunStatus("", format))
 			return
 		}
 
-		utils.ExitIfNotNil(RunStatus(name))
+		utils.ExitIfNotNil(RunStatus(name, format))
 	},
 }
 
 func init() {
 	StatusCmd.Flags().BoolP("all", "A", false, "Show status of all VMs")
+	StatusCmd.Flags().StringP("output", "o", "", output.Usage)
 }
 
-func RunStatus(name string) error {
-	status, err := vm.Status(name)
+func RunStatus(name string, format output.Format) error {
+	infos, err := vm.Status(name)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(status)
-
-	return nil
+	return output.Print(os.Stdout, format, infos)
 }
@@ -0,0 +1,118 @@
+// fetch-manifests downloads the manifests embedded in the kindli binary
+// which are missing from the tree and verifies every manifest against the
+// digest pinned in the sha256sums.txt of its directory. Run it from the root
+// of the repository.
+//
+// With -pin the digests of the manifests which have none pinned yet are
+// recorded, review them before committing.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/addon"
+	"github.com/utkarsh-pro/kindli/pkg/manifest"
+	"github.com/utkarsh-pro/kindli/pkg/metallb"
+)
+
+type target struct {
+	dir  string
+	file string
+	url  string
+}
+
+func main() {
+	pin := flag.Bool("pin", false, "Record the digests of the manifests which have none pinned")
+	flag.Parse()
+
+	targets := []target{}
+	for _, version := range metallb.Versions() {
+		targets = append(targets, target{
+			dir:  filepath.Join("pkg", "metallb", "manifests"),
+			file: metallb.ManifestFile(version),
+			url:  metallb.ManifestURL(version),
+		})
+	}
+
+	for _, a := range addon.List() {
+		targets = append(targets, target{
+			dir:  filepath.Join("pkg", "addon", "manifests"),
+			file: a.ManifestFile(),
+			url:  a.URL,
+		})
+	}
+
+	failed := false
+	for _, t := range targets {
+		if err := fetch(t, *pin); err != nil {
+			logrus.Errorf("%s: %s", t.file, err)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// fetch downloads the manifest if it is missing and verifies it against
+// its pinned digest, the digest is pinned first if pin is true
+func fetch(t target, pin bool) error {
+	sumsPath := filepath.Join(t.dir, manifest.SumsFile)
+	byt, err := os.ReadFile(sumsPath)
+	if err != nil {
+		return err
+	}
+
+	sums, err := manifest.ParseSums(byt)
+	if err != nil {
+		return err
+	}
+
+	digest, ok := sums[t.file]
+	if !ok && !pin {
+		return fmt.Errorf("no digest pinned in %s, run with -pin to record it", sumsPath)
+	}
+
+	dst := filepath.Join(t.dir, t.file)
+	if _, err := os.Stat(dst); err != nil {
+		logrus.Infof("Downloading %s", t.url)
+		// Pinned manifests are written only if they match their digest
+		if _, err := manifest.Download(t.url, dst, digest); err != nil {
+			return err
+		}
+	}
+
+	content, err := os.ReadFile(dst)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		if err := manifest.Verify(content, digest); err != nil {
+			os.Remove(dst)
+			return err
+		}
+
+		return nil
+	}
+
+	digest = manifest.Digest(content)
+	logrus.Infof("Pinning %s %s", digest, t.file)
+
+	file, err := os.OpenFile(sumsPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(file, "%s  %s\n", digest, t.file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -16,13 +16,10 @@ limitations under the License.
 package main
 
 import (
-	"path/filepath"
-
 	"github.com/mattn/go-colorable"
 	"github.com/sirupsen/logrus"
 	"github.com/utkarsh-pro/kindli/cmd"
 	"github.com/utkarsh-pro/kindli/pkg/config"
-	"github.com/utkarsh-pro/kindli/pkg/db"
 	"github.com/utkarsh-pro/kindli/pkg/models"
 )
 
@@ -35,10 +32,9 @@ func init() {
 	config.CleanEnv()
 	config.Logger()
 
-	// Initialize database
-	models.VMPreload()
-	models.ClusterPreload()
-	db.Setup(filepath.Join(config.Dir(), "db.sqlite"))
+	// Register database migrations - database is initialized once the
+	// flags are parsed
+	models.RegisterMigrations()
 }
 
 func main() {
@@ -0,0 +1,371 @@
+package addon
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/k8s"
+	"github.com/utkarsh-pro/kindli/pkg/manifest"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// readyTimeout is the time given to an add-on to become ready
+const readyTimeout = 10 * time.Minute
+
+//go:embed manifests
+var manifests embed.FS
+
+// Workload is a workload of an add-on which must be ready for the add-on
+// to be ready
+type Workload struct {
+	// Kind is either "Deployment" or "DaemonSet"
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// Addon is a component installed in a cluster after it is created
+type Addon struct {
+	Name        string
+	Description string
+	Version     string
+	// URL is the upstream location of the manifest of the add-on
+	URL string
+	// Requires are the add-ons which must be enabled before this add-on
+	Requires []string
+	// Conflicts are the add-ons which cannot be enabled along with this
+	// add-on
+	Conflicts []string
+	// CNI add-ons replace the default CNI of kind, hence they can only be
+	// enabled while creating the cluster and cannot be disabled
+	CNI bool
+	// Ready are the workloads waited for after applying the manifest
+	Ready []Workload
+	// Transform adjusts the objects of the manifest for kind before they
+	// are applied
+	Transform func(objs []*unstructured.Unstructured) error
+}
+
+// ManifestFile returns the file name of the manifest of the add-on
+func (a *Addon) ManifestFile() string {
+	return fmt.Sprintf("%s-%s.yaml", a.Name, a.Version)
+}
+
+// Embedded returns true if the manifest of the add-on is embedded
+func (a *Addon) Embedded() bool {
+	return loader().Embedded(a.ManifestFile())
+}
+
+// Manifest returns the manifest of the add-on
+func (a *Addon) Manifest() ([]byte, error) {
+	return loader().Load(a.ManifestFile(), a.URL)
+}
+
+var addons = map[string]*Addon{}
+
+// Register registers the add-on, registering an add-on twice panics
+func Register(a *Addon) {
+	if _, ok := addons[a.Name]; ok {
+		panic(fmt.Sprintf("addon %s registered twice", a.Name))
+	}
+
+	addons[a.Name] = a
+}
+
+// Get returns the add-on with the given name
+func Get(name string) (*Addon, error) {
+	a, ok := addons[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown addon \"%s\", available addons: %s", name, strings.Join(Names(), ", "))
+	}
+
+	return a, nil
+}
+
+// List returns all the add-ons sorted by their names
+func List() []*Addon {
+	list := []*Addon{}
+	for _, a := range addons {
+		list = append(list, a)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// Names returns the names of all the add-ons sorted
+func Names() []string {
+	names := []string{}
+	for _, a := range List() {
+		names = append(names, a.Name)
+	}
+
+	return names
+}
+
+// Enabled returns the add-ons enabled in the cluster
+func Enabled(clusterName string) ([]*models.ClusterAddon, error) {
+	enabled, err := models.ListClusterAddon(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addons of cluster \"%s\": %w", clusterName, err)
+	}
+
+	return enabled, nil
+}
+
+// Enable installs the add-on, along with the add-ons it requires, in the
+// cluster and waits for it to be ready
+func Enable(clusterName, name string) error {
+	c := models.NewCluster(clusterName, "", "")
+	if err := c.GetByName(); err != nil {
+		return fmt.Errorf("failed to find cluster with name \"%s\": %w", clusterName, err)
+	}
+
+	enabled, err := enabledSet(clusterName)
+	if err != nil {
+		return err
+	}
+
+	return enable(c, name, enabled, map[string]bool{})
+}
+
+func enable(c *models.Cluster, name string, enabled map[string]bool, visiting map[string]bool) error {
+	a, err := Get(name)
+	if err != nil {
+		return err
+	}
+
+	if enabled[name] {
+		logrus.Infof("addon %s is already enabled in the cluster \"%s\"", name, c.Name)
+		return nil
+	}
+
+	if visiting[name] {
+		return fmt.Errorf("addon %s requires itself", name)
+	}
+	visiting[name] = true
+
+	for other := range enabled {
+		if conflicts(a, other) {
+			return fmt.Errorf("addon %s cannot be enabled along with addon %s", name, other)
+		}
+	}
+
+	if a.CNI {
+		disabled, err := defaultCNIDisabled(c)
+		if err != nil {
+			return err
+		}
+
+		if !disabled {
+			return fmt.Errorf("addon %s replaces the default CNI and can only be enabled while creating the cluster - `kindli create --addon %s`", name, name)
+		}
+	}
+
+	for _, req := range a.Requires {
+		if !enabled[req] {
+			logrus.Infof("Enabling addon %s required by addon %s", req, name)
+		}
+
+		if err := enable(c, req, enabled, visiting); err != nil {
+			return err
+		}
+	}
+
+	logrus.Infof("Enabling addon %s %s in the cluster \"%s\"", name, a.Version, c.Name)
+
+	if err := install(c, a); err != nil {
+		return fmt.Errorf("failed to enable addon %s: %w", name, err)
+	}
+
+	if err := models.NewClusterAddon(c.Name, a.Name, a.Version).Save(); err != nil {
+		return fmt.Errorf("failed to save addon %s of the cluster: %w", name, err)
+	}
+
+	enabled[name] = true
+	logrus.Infof("✅ Enabled addon %s", name)
+
+	return nil
+}
+
+// Disable removes the add-on from the cluster
+func Disable(clusterName, name string) error {
+	a, err := Get(name)
+	if err != nil {
+		return err
+	}
+
+	enabled, err := enabledSet(clusterName)
+	if err != nil {
+		return err
+	}
+
+	if !enabled[name] {
+		return fmt.Errorf("addon %s is not enabled in the cluster \"%s\"", name, clusterName)
+	}
+
+	if a.CNI {
+		return fmt.Errorf("addon %s is the CNI of the cluster and cannot be disabled", name)
+	}
+
+	for other := range enabled {
+		o, err := Get(other)
+		if err != nil {
+			continue
+		}
+
+		for _, req := range o.Requires {
+			if req == name {
+				return fmt.Errorf("addon %s is required by addon %s, disable it first", name, other)
+			}
+		}
+	}
+
+	manifest, err := a.Manifest()
+	if err != nil {
+		return err
+	}
+
+	client, err := k8s.New(kubeContext(clusterName))
+	if err != nil {
+		return err
+	}
+
+	if err := client.Delete(context.Background(), manifest); err != nil {
+		return fmt.Errorf("failed to disable addon %s: %w", name, err)
+	}
+
+	if err := models.NewClusterAddon(clusterName, name, "").Delete(); err != nil {
+		return fmt.Errorf("failed to save addon %s of the cluster: %w", name, err)
+	}
+
+	logrus.Infof("✅ Disabled addon %s", name)
+	return nil
+}
+
+// Forget removes the add-ons of the cluster from the kindli store
+func Forget(clusterName string) error {
+	if err := models.DeleteClusterAddons(clusterName); err != nil {
+		return fmt.Errorf("failed to delete addons of cluster \"%s\": %w", clusterName, err)
+	}
+
+	return nil
+}
+
+// install applies the manifest of the add-on and waits for its workloads
+func install(c *models.Cluster, a *Addon) error {
+	manifest, err := a.Manifest()
+	if err != nil {
+		return err
+	}
+
+	objs, err := k8s.Decode(manifest)
+	if err != nil {
+		return err
+	}
+
+	if a.Transform != nil {
+		if err := a.Transform(objs); err != nil {
+			return fmt.Errorf("failed to adjust manifest: %w", err)
+		}
+	}
+
+	client, err := k8s.New(kubeContext(c.Name))
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if err := client.ApplyObjects(ctx, objs, readyTimeout); err != nil {
+		return err
+	}
+
+	for _, w := range a.Ready {
+		var err error
+		switch w.Kind {
+		case "DaemonSet":
+			err = client.WaitForDaemonSet(ctx, w.Namespace, w.Name, readyTimeout)
+		default:
+			err = client.WaitForDeployment(ctx, w.Namespace, w.Name, readyTimeout)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func enabledSet(clusterName string) (map[string]bool, error) {
+	list, err := Enabled(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	set := map[string]bool{}
+	for _, ca := range list {
+		set[ca.Addon] = true
+	}
+
+	return set, nil
+}
+
+// conflicts returns true if the add-on conflicts with the other add-on in
+// either direction
+func conflicts(a *Addon, other string) bool {
+	for _, c := range a.Conflicts {
+		if c == other {
+			return true
+		}
+	}
+
+	if o, ok := addons[other]; ok {
+		for _, c := range o.Conflicts {
+			if c == a.Name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// defaultCNIDisabled returns true if the cluster was created without the
+// default CNI of kind
+func defaultCNIDisabled(c *models.Cluster) (bool, error) {
+	cfg, err := c.LoadConfigAsYAMLFromDisk()
+	if err != nil {
+		return false, fmt.Errorf("failed to read kind config of the cluster: %w", err)
+	}
+
+	val, _ := utils.MapGet(cfg, "networking", "disableDefaultCNI")
+	disabled, _ := val.(bool)
+
+	return disabled, nil
+}
+
+func kubeContext(clusterName string) string {
+	return "kind-" + clusterName
+}
+
+// loader returns the loader of the add-on manifests, tests replace it
+var loader = func() *manifest.Loader {
+	return &manifest.Loader{
+		FS:       manifests,
+		Dir:      "manifests",
+		CacheDir: filepath.Join(config.Dir(), "addons"),
+	}
+}
@@ -0,0 +1,290 @@
+package addon
+
+import (
+	"bytes"
+	"embed"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/utkarsh-pro/kindli/pkg/db"
+	"github.com/utkarsh-pro/kindli/pkg/k8s"
+	"github.com/utkarsh-pro/kindli/pkg/manifest"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+)
+
+//go:embed testdata/manifests
+var testManifests embed.FS
+
+var (
+	// builtins are the add-ons registered by builtin.go
+	builtins []*Addon
+	// builtinLoader is the loader of the embedded manifests
+	builtinLoader = loader
+
+	// dryRun records the objects applied and deleted by the add-ons
+	dryRun = &bytes.Buffer{}
+
+	actionRegex = regexp.MustCompile(`(?m)^kubectl --context kind-addons (apply|delete)\b.* ConfigMap \S*?(test-[a-z-]+)$`)
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "kindli-addon-test-*")
+	if err != nil {
+		panic(err)
+	}
+
+	models.RegisterMigrations()
+	db.Setup(filepath.Join(dir, "db.sqlite"))
+	k8s.SetDryRun(dryRun)
+
+	builtins = List()
+	loader = func() *manifest.Loader {
+		return &manifest.Loader{
+			FS:       testManifests,
+			Dir:      "testdata/manifests",
+			CacheDir: filepath.Join(dir, "addons"),
+		}
+	}
+
+	for _, a := range []*Addon{
+		{Name: "test-base", Version: "v1"},
+		{Name: "test-middle", Version: "v1", Requires: []string{"test-base"}},
+		{Name: "test-top", Version: "v1", Requires: []string{"test-middle", "test-base"}},
+		{Name: "test-cycle-a", Version: "v1", Requires: []string{"test-cycle-b"}},
+		{Name: "test-cycle-b", Version: "v1", Requires: []string{"test-cycle-a"}},
+		{Name: "test-other", Version: "v1", Conflicts: []string{"test-base"}},
+	} {
+		Register(a)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// saveCluster saves the "addons" cluster with the given add-ons enabled
+func saveCluster(t *testing.T, enabled ...string) {
+	t.Helper()
+
+	c := models.NewCluster("addons", filepath.Join(t.TempDir(), "addons.yaml"), "test")
+	if err := c.Save(); err != nil {
+		t.Fatalf("failed to save cluster: %v", err)
+	}
+
+	for _, name := range enabled {
+		if err := models.NewClusterAddon(c.Name, name, "v1").Save(); err != nil {
+			t.Fatalf("failed to save addon: %v", err)
+		}
+	}
+
+	dryRun.Reset()
+	t.Cleanup(func() {
+		Forget(c.Name)
+		c.Delete()
+	})
+}
+
+// actions returns the add-ons applied and deleted since the cluster was
+// saved, in order
+func actions() []string {
+	got := []string{}
+	for _, match := range actionRegex.FindAllStringSubmatch(dryRun.String(), -1) {
+		got = append(got, match[1]+" "+match[2])
+	}
+
+	return got
+}
+
+func enabledNames(t *testing.T) []string {
+	t.Helper()
+
+	enabled, err := Enabled("addons")
+	if err != nil {
+		t.Fatalf("Enabled() error = %v", err)
+	}
+
+	names := []string{}
+	for _, ca := range enabled {
+		names = append(names, ca.Addon)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func TestEnable(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     []string
+		addon       string
+		wantActions []string
+		wantEnabled []string
+		wantErr     bool
+	}{
+		{
+			name:        "required add-ons are enabled first",
+			addon:       "test-top",
+			wantActions: []string{"apply test-base", "apply test-middle", "apply test-top"},
+			wantEnabled: []string{"test-base", "test-middle", "test-top"},
+		},
+		{
+			name:        "enabled required add-ons are kept",
+			enabled:     []string{"test-base"},
+			addon:       "test-top",
+			wantActions: []string{"apply test-middle", "apply test-top"},
+			wantEnabled: []string{"test-base", "test-middle", "test-top"},
+		},
+		{
+			name:        "enabled add-on is kept",
+			enabled:     []string{"test-base"},
+			addon:       "test-base",
+			wantActions: []string{},
+			wantEnabled: []string{"test-base"},
+		},
+		{
+			name:        "requirement cycle",
+			addon:       "test-cycle-a",
+			wantActions: []string{},
+			wantEnabled: []string{},
+			wantErr:     true,
+		},
+		{
+			name:        "required add-on conflicts with an enabled add-on",
+			enabled:     []string{"test-other"},
+			addon:       "test-middle",
+			wantActions: []string{},
+			wantEnabled: []string{"test-other"},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown add-on",
+			addon:       "test-unknown",
+			wantActions: []string{},
+			wantEnabled: []string{},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saveCluster(t, tt.enabled...)
+
+			err := Enable("addons", tt.addon)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Enable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := actions(); !reflect.DeepEqual(got, tt.wantActions) {
+				t.Errorf("actions = %q, want %q", got, tt.wantActions)
+			}
+
+			if got := enabledNames(t); !reflect.DeepEqual(got, tt.wantEnabled) {
+				t.Errorf("enabled = %q, want %q", got, tt.wantEnabled)
+			}
+		})
+	}
+}
+
+func TestDisable(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     []string
+		addon       string
+		wantActions []string
+		wantEnabled []string
+		wantErr     bool
+	}{
+		{
+			name:        "add-on is deleted",
+			enabled:     []string{"test-base", "test-middle"},
+			addon:       "test-middle",
+			wantActions: []string{"delete test-middle"},
+			wantEnabled: []string{"test-base"},
+		},
+		{
+			name:        "add-on required by an enabled add-on",
+			enabled:     []string{"test-base", "test-middle"},
+			addon:       "test-base",
+			wantActions: []string{},
+			wantEnabled: []string{"test-base", "test-middle"},
+			wantErr:     true,
+		},
+		{
+			name:        "add-on which is not enabled",
+			enabled:     []string{"test-base"},
+			addon:       "test-middle",
+			wantActions: []string{},
+			wantEnabled: []string{"test-base"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saveCluster(t, tt.enabled...)
+
+			err := Disable("addons", tt.addon)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Disable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := actions(); !reflect.DeepEqual(got, tt.wantActions) {
+				t.Errorf("actions = %q, want %q", got, tt.wantActions)
+			}
+
+			if got := enabledNames(t); !reflect.DeepEqual(got, tt.wantEnabled) {
+				t.Errorf("enabled = %q, want %q", got, tt.wantEnabled)
+			}
+		})
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	registered := map[string]*Addon{}
+	for _, a := range builtins {
+		registered[a.Name] = a
+	}
+
+	var visit func(a *Addon, path map[string]bool)
+	visit = func(a *Addon, path map[string]bool) {
+		if path[a.Name] {
+			t.Errorf("addon %s requires itself", a.Name)
+			return
+		}
+
+		path[a.Name] = true
+		defer delete(path, a.Name)
+
+		for _, req := range a.Requires {
+			if r, ok := registered[req]; ok {
+				visit(r, path)
+			} else {
+				t.Errorf("addon %s requires unknown addon %s", a.Name, req)
+			}
+		}
+	}
+
+	for _, a := range builtins {
+		visit(a, map[string]bool{})
+
+		for _, c := range a.Conflicts {
+			if _, ok := registered[c]; !ok {
+				t.Errorf("addon %s conflicts with unknown addon %s", a.Name, c)
+			}
+		}
+
+		// Embedded manifests must match their pinned digests
+		l := builtinLoader()
+		if !l.Embedded(a.ManifestFile()) {
+			continue
+		}
+
+		if _, err := l.Load(a.ManifestFile(), a.URL); err != nil {
+			t.Errorf("failed to load manifest of addon %s: %v", a.Name, err)
+		}
+	}
+}
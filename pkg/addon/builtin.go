@@ -0,0 +1,119 @@
+package addon
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func init() {
+	Register(&Addon{
+		Name:        "ingress-nginx",
+		Description: "NGINX ingress controller",
+		Version:     "controller-v1.5.1",
+		URL:         "https://raw.githubusercontent.com/kubernetes/ingress-nginx/controller-v1.5.1/deploy/static/provider/kind/deploy.yaml",
+		Ready: []Workload{
+			{Kind: "Deployment", Namespace: "ingress-nginx", Name: "ingress-nginx-controller"},
+		},
+	})
+
+	Register(&Addon{
+		Name:        "metrics-server",
+		Description: "Resource metrics for kubectl top and autoscalers",
+		Version:     "v0.6.2",
+		URL:         "https://github.com/kubernetes-sigs/metrics-server/releases/download/v0.6.2/components.yaml",
+		Ready: []Workload{
+			{Kind: "Deployment", Namespace: "kube-system", Name: "metrics-server"},
+		},
+		// Kubelets of kind serve self signed certificates
+		Transform: addContainerArgs("Deployment", "metrics-server", "--kubelet-insecure-tls"),
+	})
+
+	Register(&Addon{
+		Name:        "cert-manager",
+		Description: "X.509 certificate management",
+		Version:     "v1.10.1",
+		URL:         "https://github.com/cert-manager/cert-manager/releases/download/v1.10.1/cert-manager.yaml",
+		Ready: []Workload{
+			{Kind: "Deployment", Namespace: "cert-manager", Name: "cert-manager"},
+			{Kind: "Deployment", Namespace: "cert-manager", Name: "cert-manager-cainjector"},
+			{Kind: "Deployment", Namespace: "cert-manager", Name: "cert-manager-webhook"},
+		},
+	})
+
+	Register(&Addon{
+		Name:        "local-path-provisioner",
+		Description: "Upstream local path dynamic volume provisioner",
+		Version:     "v0.0.30",
+		URL:         "https://raw.githubusercontent.com/rancher/local-path-provisioner/v0.0.30/deploy/local-path-storage.yaml",
+		Ready: []Workload{
+			{Kind: "Deployment", Namespace: "local-path-storage", Name: "local-path-provisioner"},
+		},
+	})
+
+	Register(&Addon{
+		Name:        "calico",
+		Description: "Calico CNI with network policy support",
+		Version:     "v3.24.5",
+		URL:         "https://raw.githubusercontent.com/projectcalico/calico/v3.24.5/manifests/calico.yaml",
+		Conflicts:   []string{"cilium"},
+		CNI:         true,
+		Ready: []Workload{
+			{Kind: "DaemonSet", Namespace: "kube-system", Name: "calico-node"},
+			{Kind: "Deployment", Namespace: "kube-system", Name: "calico-kube-controllers"},
+		},
+	})
+
+	Register(&Addon{
+		Name:        "cilium",
+		Description: "Cilium eBPF based CNI",
+		Version:     "v1.12.4",
+		URL:         "https://raw.githubusercontent.com/cilium/cilium/v1.12.4/install/kubernetes/quick-install.yaml",
+		Conflicts:   []string{"calico"},
+		CNI:         true,
+		Ready: []Workload{
+			{Kind: "DaemonSet", Namespace: "kube-system", Name: "cilium"},
+			{Kind: "Deployment", Namespace: "kube-system", Name: "cilium-operator"},
+		},
+	})
+}
+
+// addContainerArgs returns a transform which appends the args to the
+// containers of the workload
+func addContainerArgs(kind, name string, args ...string) func([]*unstructured.Unstructured) error {
+	return func(objs []*unstructured.Unstructured) error {
+		for _, obj := range objs {
+			if obj.GetKind() != kind || obj.GetName() != name {
+				continue
+			}
+
+			containers, _, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+			if err != nil {
+				return err
+			}
+
+			for _, container := range containers {
+				container, ok := container.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				existing, _, _ := unstructured.NestedStringSlice(container, "args")
+				container["args"] = toInterfaces(append(existing, args...))
+			}
+
+			return unstructured.SetNestedSlice(obj.Object, containers, "spec", "template", "spec", "containers")
+		}
+
+		return fmt.Errorf("%s %s not found in the manifest", kind, name)
+	}
+}
+
+func toInterfaces(strs []string) []interface{} {
+	res := []interface{}{}
+	for _, s := range strs {
+		res = append(res, s)
+	}
+
+	return res
+}
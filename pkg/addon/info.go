@@ -0,0 +1,81 @@
+package addon
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Info is the information of an add-on for a cluster
+type Info struct {
+	Name        string   `json:"name" yaml:"name"`
+	Version     string   `json:"version" yaml:"version"`
+	Description string   `json:"description" yaml:"description"`
+	CNI         bool     `json:"cni" yaml:"cni"`
+	Requires    []string `json:"requires" yaml:"requires"`
+	Conflicts   []string `json:"conflicts" yaml:"conflicts"`
+	Embedded    bool     `json:"embedded" yaml:"embedded"`
+	// Enabled is the version of the add-on enabled in the cluster, empty if
+	// the add-on is not enabled
+	Enabled string `json:"enabled" yaml:"enabled"`
+}
+
+// InfoList is a list of Info
+type InfoList []Info
+
+func (l InfoList) Header(wide bool) []string {
+	header := []string{"NAME", "VERSION", "ENABLED", "DESCRIPTION"}
+	if wide {
+		header = append(header, "CNI", "REQUIRES", "CONFLICTS", "EMBEDDED")
+	}
+
+	return header
+}
+
+func (l InfoList) Rows(wide bool) [][]string {
+	rows := [][]string{}
+	for _, i := range l {
+		row := []string{i.Name, i.Version, i.Enabled, i.Description}
+		if wide {
+			row = append(
+				row,
+				strconv.FormatBool(i.CNI),
+				strings.Join(i.Requires, ","),
+				strings.Join(i.Conflicts, ","),
+				strconv.FormatBool(i.Embedded),
+			)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// Infos returns the information of all the add-ons for the given cluster
+func Infos(clusterName string) (InfoList, error) {
+	enabled, err := Enabled(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := map[string]string{}
+	for _, ca := range enabled {
+		versions[ca.Addon] = ca.Version
+	}
+
+	list := InfoList{}
+	for _, a := range List() {
+		list = append(list, Info{
+			Name:        a.Name,
+			Version:     a.Version,
+			Description: a.Description,
+			CNI:         a.CNI,
+			Requires:    a.Requires,
+			Conflicts:   a.Conflicts,
+			Embedded:    a.Embedded(),
+			Enabled:     versions[a.Name],
+		})
+	}
+
+	return list, nil
+}
@@ -2,7 +2,9 @@ package db
 
 import (
 	"database/sql"
-	"fmt"
+	"errors"
+	"io"
+	"os"
 
 	_ "github.com/glebarez/go-sqlite"
 	"github.com/utkarsh-pro/kindli/pkg/utils"
@@ -10,10 +12,14 @@ import (
 
 var (
 	db       *sql.DB
-	preloads = []string{}
+	dbPath   string
+	migrated bool
 )
 
 // Setup sets up the database
+//
+// Pending migrations are applied when the database is used for the first
+// time, see Instance.
 func Setup(path string) {
 	if db != nil {
 		return
@@ -24,28 +30,47 @@ func Setup(path string) {
 	utils.ExitIfNotNil(err)
 	db.SetMaxOpenConns(1)
 
-	for _, query := range preloads {
-		_, err = db.Exec(query)
-		if err != nil {
-			err = fmt.Errorf("failed executing query: %s: %s", query, err)
-			utils.ExitIfNotNil(err)
-		}
+	dbPath = path
+}
+
+// SetupEphemeral sets up the database on a throwaway copy of the database
+// at the given path, any change made to the database is lost on exit
+func SetupEphemeral(path string) {
+	tmp, err := os.CreateTemp("", "kindli-*.sqlite")
+	utils.ExitIfNotNil(err)
+	defer tmp.Close()
+
+	src, err := os.Open(path)
+	if err == nil {
+		defer src.Close()
+
+		_, err = io.Copy(tmp, src)
+		utils.ExitIfNotNil(err)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		utils.ExitIfNotNil(err)
 	}
+
+	Setup(tmp.Name())
 }
 
 // Instance returns the database instance
+//
+// If the database has pending migrations then they are applied before
+// returning the instance. If the migrations fail then the program will
+// exit with an error.
 func Instance() *sql.DB {
 	if db == nil {
 		panic("db not initialized")
 	}
 
+	if !migrated {
+		utils.ExitIfNotNil(Migrate())
+	}
+
 	return db
 }
 
-// RegisterPreload takes in a query and invokes them just after
-// initializing the database.
-//
-// This function might be useful for creating tables, etc.
-func RegisterPreload(query string) {
-	preloads = append(preloads, query)
+// Path returns the path to the database file
+func Path() string {
+	return dbPath
 }
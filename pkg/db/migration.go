@@ -0,0 +1,223 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Migration is a versioned change to the database schema
+//
+// Migrations are applied in the order of their versions, each in its own
+// transaction. A migration once released must never be changed, add a new
+// migration instead.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tx *sql.Tx) error
+}
+
+// MigrationStatus is the status of a registered migration
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt string
+}
+
+var migrations = []Migration{}
+
+// RegisterMigrations registers the migrations with the database
+func RegisterMigrations(m ...Migration) {
+	migrations = append(migrations, m...)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+}
+
+// Exec returns a migration step which executes the given query
+func Exec(query string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// AddColumn returns a migration step which adds the column to the table
+// if the column is missing.
+//
+// It is useful for databases created by kindli versions which added columns
+// before the migrations were introduced.
+func AddColumn(table, column, definition string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		var count int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to inspect table %s: %w", table, err)
+		}
+
+		if count > 0 {
+			return nil
+		}
+
+		_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+		return err
+	}
+}
+
+// Migrate applies all the pending migrations
+//
+// The database is backed up before applying the migrations, the backups
+// are stored next to the database in the "backups" directory.
+func Migrate() error {
+	if db == nil {
+		panic("db not initialized")
+	}
+
+	statuses, err := Status()
+	if err != nil {
+		return err
+	}
+
+	pending := []Migration{}
+	current := 0
+	for _, status := range statuses {
+		if status.Applied {
+			current = status.Version
+			continue
+		}
+
+		pending = append(pending, status.Migration)
+	}
+
+	if len(pending) == 0 {
+		migrated = true
+		return nil
+	}
+
+	// Databases created before migrations were introduced have tables
+	// without any schema version
+	var tables int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name != 'schema_version'`).Scan(&tables); err != nil {
+		return fmt.Errorf("failed to inspect database: %w", err)
+	}
+
+	if tables > 0 {
+		backup, err := Backup(current)
+		if err != nil {
+			return fmt.Errorf("failed to backup database before migration: %w", err)
+		}
+
+		logrus.Infof("Migrating database from version %d (backup: %s)", current, backup)
+	}
+
+	for _, m := range pending {
+		logrus.Debugf("Applying migration %d: %s", m.Version, m.Description)
+		if err := apply(m); err != nil {
+			return fmt.Errorf("failed to apply migration %d (%s): %w", m.Version, m.Description, err)
+		}
+	}
+
+	migrated = true
+	return nil
+}
+
+// Status returns the status of all the registered migrations
+func Status() ([]MigrationStatus, error) {
+	if err := createVersionTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT version, applied_at FROM schema_version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]string{}
+	latest := 0
+	for rows.Next() {
+		var version int
+		var appliedAt string
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to get schema version: %w", err)
+		}
+
+		applied[version] = appliedAt
+		if version > latest {
+			latest = version
+		}
+	}
+
+	if len(migrations) > 0 && latest > migrations[len(migrations)-1].Version {
+		return nil, fmt.Errorf("database schema version %d is newer than the supported version %d - upgrade kindli", latest, migrations[len(migrations)-1].Version)
+	}
+
+	statuses := []MigrationStatus{}
+	for _, m := range migrations {
+		appliedAt, ok := applied[m.Version]
+		statuses = append(statuses, MigrationStatus{
+			Migration: m,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Backup backs up the database and returns the path to the backup
+func Backup(version int) (string, error) {
+	dir := filepath.Join(filepath.Dir(dbPath), "backups")
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("db-v%d-%s.sqlite", version, time.Now().Format("20060102150405")))
+	if _, err := db.Exec(`VACUUM INTO ?`, path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+func createVersionTable() error {
+	_, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS schema_version (
+	version INTEGER PRIMARY KEY,
+	description TEXT,
+	applied_at TEXT
+);`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+
+	return nil
+}
+
+func apply(m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.Up(tx); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO schema_version (version, description, applied_at) VALUES (?, ?, ?)`,
+		m.Version,
+		m.Description,
+		time.Now().Format(time.RFC3339),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,145 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/utkarsh-pro/kindli/pkg/k8s"
+	"github.com/utkarsh-pro/kindli/pkg/kind"
+	"github.com/utkarsh-pro/kindli/pkg/kubeconfig"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Domain is the domain under which the LoadBalancer services of the
+// clusters are resolved, as <service>.<namespace>.<cluster>.kindli.local
+const Domain = "kindli.local"
+
+// DefaultAddress is the address the DNS server of the network daemon
+// listens on
+const DefaultAddress = "127.0.0.1:15353"
+
+// Record is the name of a LoadBalancer service along with the addresses
+// assigned to the service
+type Record struct {
+	Name      string   `json:"name" yaml:"name"`
+	Cluster   string   `json:"cluster" yaml:"cluster"`
+	Namespace string   `json:"namespace" yaml:"namespace"`
+	Service   string   `json:"service" yaml:"service"`
+	Addresses []string `json:"addresses" yaml:"addresses"`
+}
+
+// RecordList is a list of Record
+type RecordList []Record
+
+func (l RecordList) Header(wide bool) []string {
+	header := []string{"NAME", "ADDRESSES"}
+	if wide {
+		header = append(header, "CLUSTER", "NAMESPACE", "SERVICE")
+	}
+
+	return header
+}
+
+func (l RecordList) Rows(wide bool) [][]string {
+	rows := [][]string{}
+	for _, r := range l {
+		row := []string{r.Name, strings.Join(r.Addresses, ",")}
+		if wide {
+			row = append(row, r.Cluster, r.Namespace, r.Service)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// Name returns the name under which the service of the cluster is resolved
+func Name(service, namespace, cluster string) string {
+	return strings.ToLower(fmt.Sprintf("%s.%s.%s.%s", service, namespace, cluster, Domain))
+}
+
+// List returns the records of the LoadBalancer services of the running
+// clusters of the VM, if vmName is empty then the records of the clusters
+// of all the VMs are returned
+func List(ctx context.Context, vmName string) (RecordList, error) {
+	clusters, err := kind.RunningClusters(vmName)
+	if err != nil {
+		return nil, err
+	}
+
+	records := RecordList{}
+	for _, c := range clusters {
+		client, err := newClient(c)
+		if err != nil {
+			return nil, err
+		}
+
+		services, err := client.Clientset().CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services of cluster %s: %w", c.Name, err)
+		}
+
+		records = append(records, serviceRecords(c.Name, services.Items)...)
+	}
+
+	return records, nil
+}
+
+// serviceRecords returns the records of the LoadBalancer services which
+// have addresses assigned, sorted by name
+func serviceRecords(cluster string, services []corev1.Service) RecordList {
+	records := RecordList{}
+	for _, svc := range services {
+		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			continue
+		}
+
+		addresses := []string{}
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.IP != "" {
+				addresses = append(addresses, ingress.IP)
+			}
+		}
+
+		if len(addresses) == 0 {
+			continue
+		}
+
+		records = append(records, Record{
+			Name:      Name(svc.Name, svc.Namespace, cluster),
+			Cluster:   cluster,
+			Namespace: svc.Namespace,
+			Service:   svc.Name,
+			Addresses: addresses,
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Name < records[j].Name
+	})
+
+	return records
+}
+
+// newClient returns a kubernetes client for the cluster, it uses the
+// kubeconfig generated for the host so the cluster doesn't need to be
+// merged into the kubeconfig of the user
+func newClient(c models.Cluster) (*k8s.Client, error) {
+	byt, err := kubeconfig.Get(c.Name, c.VM)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(byt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig of cluster %s: %w", c.Name, err)
+	}
+
+	return k8s.NewForConfig(kubeconfig.ContextName(c.Name), cfg)
+}
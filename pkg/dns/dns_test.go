@@ -0,0 +1,58 @@
+package dns
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func service(name, namespace string, typ corev1.ServiceType, ips ...string) corev1.Service {
+	svc := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.ServiceSpec{Type: typ},
+	}
+
+	for _, ip := range ips {
+		svc.Status.LoadBalancer.Ingress = append(svc.Status.LoadBalancer.Ingress, corev1.LoadBalancerIngress{IP: ip})
+	}
+
+	return svc
+}
+
+func TestServiceRecords(t *testing.T) {
+	services := []corev1.Service{
+		service("web", "default", corev1.ServiceTypeLoadBalancer, "172.18.1.10", "fc00:f853:ccd:e793::110"),
+		service("API", "Prod", corev1.ServiceTypeLoadBalancer, "172.18.1.11"),
+		service("pending", "default", corev1.ServiceTypeLoadBalancer),
+		service("hostname", "default", corev1.ServiceTypeLoadBalancer, ""),
+		service("internal", "default", corev1.ServiceTypeClusterIP),
+		service("node", "default", corev1.ServiceTypeNodePort, "172.18.1.12"),
+	}
+
+	want := RecordList{
+		{
+			Name:      "api.prod.kindli-dev.kindli.local",
+			Cluster:   "kindli-dev",
+			Namespace: "Prod",
+			Service:   "API",
+			Addresses: []string{"172.18.1.11"},
+		},
+		{
+			Name:      "web.default.kindli-dev.kindli.local",
+			Cluster:   "kindli-dev",
+			Namespace: "default",
+			Service:   "web",
+			Addresses: []string{"172.18.1.10", "fc00:f853:ccd:e793::110"},
+		},
+	}
+
+	if got := serviceRecords("kindli-dev", services); !reflect.DeepEqual(got, want) {
+		t.Errorf("serviceRecords() = %+v, want %+v", got, want)
+	}
+
+	if got := serviceRecords("kindli-dev", nil); len(got) != 0 {
+		t.Errorf("serviceRecords() of no services = %+v, want none", got)
+	}
+}
@@ -0,0 +1,57 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+// InstallResolver makes the host resolve the names under Domain with the
+// DNS server listening on address and returns the path of the resolver
+// configuration, a resolver file on MacOS and a systemd-resolved drop-in
+// on Linux. Requires root access.
+func InstallResolver(address string) (string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid DNS server address %s: %w", address, err)
+	}
+
+	path := resolverFile()
+	script := fmt.Sprintf(
+		"mkdir -p %s && printf '%%s' %s > %s",
+		sh.Quote(filepath.Dir(path)),
+		sh.Quote(resolverConfig(host, port)),
+		sh.Quote(path),
+	)
+	if err := sh.NewCommand("sudo", "sh", "-c", script).RunSilent(); err != nil {
+		return "", fmt.Errorf("failed to write resolver configuration: %w", err)
+	}
+
+	if err := reloadResolver(); err != nil {
+		return "", fmt.Errorf("failed to reload resolver: %w", err)
+	}
+
+	return path, nil
+}
+
+// UninstallResolver removes the resolver configuration installed by
+// InstallResolver
+func UninstallResolver() error {
+	path := resolverFile()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("resolver is not installed")
+	}
+
+	if err := sh.NewCommand("sudo", "rm", "-f", path).RunSilent(); err != nil {
+		return fmt.Errorf("failed to remove resolver configuration: %w", err)
+	}
+
+	if err := reloadResolver(); err != nil {
+		return fmt.Errorf("failed to reload resolver: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package dns
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+// resolverFile returns the path of the resolver file which routes the
+// queries of Domain to the DNS server
+func resolverFile() string {
+	return filepath.Join("/etc/resolver", Domain)
+}
+
+func resolverConfig(host, port string) string {
+	return fmt.Sprintf("nameserver %s\nport %s\n", host, port)
+}
+
+// reloadResolver flushes the DNS cache, the resolver files are picked up
+// on their own
+func reloadResolver() error {
+	return sh.NewCommand("sudo", "killall", "-HUP", "mDNSResponder").RunSilent()
+}
@@ -0,0 +1,21 @@
+package dns
+
+import (
+	"fmt"
+
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+// resolverFile returns the path of the systemd-resolved drop-in which
+// routes the queries of Domain to the DNS server
+func resolverFile() string {
+	return "/etc/systemd/resolved.conf.d/kindli.conf"
+}
+
+func resolverConfig(host, port string) string {
+	return fmt.Sprintf("[Resolve]\nDNS=%s:%s\nDomains=~%s\n", host, port, Domain)
+}
+
+func reloadResolver() error {
+	return sh.NewCommand("sudo", "systemctl", "restart", "systemd-resolved").RunSilent()
+}
@@ -0,0 +1,178 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ttl is the TTL of the answers in seconds, it is short as the addresses of
+// the services change whenever the services are recreated
+const ttl = 5
+
+// Server is a DNS server answering the A and AAAA queries for the names of
+// the LoadBalancer services of the clusters
+type Server struct {
+	mu sync.RWMutex
+	// records are the records of every cluster
+	records map[string]RecordList
+}
+
+func NewServer() *Server {
+	return &Server{records: map[string]RecordList{}}
+}
+
+// Set replaces the records of the cluster
+func (s *Server) Set(cluster string, records RecordList) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[cluster] = records
+}
+
+// Remove removes the records of the cluster
+func (s *Server) Remove(cluster string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, cluster)
+}
+
+// lookup returns the addresses of the name, false if there is no record
+// with the name
+func (s *Server) lookup(name string) ([]net.IP, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, records := range s.records {
+		for _, r := range records {
+			if r.Name != name {
+				continue
+			}
+
+			ips := []net.IP{}
+			for _, addr := range r.Addresses {
+				if ip := net.ParseIP(addr); ip != nil {
+					ips = append(ips, ip)
+				}
+			}
+
+			return ips, true
+		}
+	}
+
+	return nil, false
+}
+
+// ListenAndServe answers the queries received on the UDP address until ctx
+// is done
+func (s *Server) ListenAndServe(ctx context.Context, address string) error {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	logrus.Infof("Serving *.%s on %s", Domain, address)
+
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("failed to read query: %w", err)
+		}
+
+		resp, err := s.handle(buf[:n])
+		if err != nil {
+			logrus.Debugf("invalid query from %s: %s", addr, err)
+			continue
+		}
+
+		if _, err := conn.WriteTo(resp, addr); err != nil {
+			logrus.Debugf("failed to answer %s: %s", addr, err)
+		}
+	}
+}
+
+// handle returns the answer to the query
+func (s *Server) handle(query []byte) ([]byte, error) {
+	var p dnsmessage.Parser
+	header, err := p.Start(query)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := p.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		OpCode:             header.OpCode,
+		Authoritative:      true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: false,
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(q.Name.String(), "."))
+	ips, found := s.lookup(name)
+	switch {
+	case header.OpCode != 0:
+		resp.RCode = dnsmessage.RCodeNotImplemented
+	case name != Domain && !strings.HasSuffix(name, "."+Domain):
+		resp.RCode = dnsmessage.RCodeRefused
+	case !found:
+		resp.RCode = dnsmessage.RCodeNameError
+	}
+
+	b := dnsmessage.NewBuilder(make([]byte, 0, 512), resp)
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+
+	if err := b.Question(q); err != nil {
+		return nil, err
+	}
+
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+
+	if resp.RCode == dnsmessage.RCodeSuccess && q.Class == dnsmessage.ClassINET {
+		rh := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: ttl}
+		for _, ip := range ips {
+			switch {
+			case q.Type == dnsmessage.TypeA && ip.To4() != nil:
+				r := dnsmessage.AResource{}
+				copy(r.A[:], ip.To4())
+				err = b.AResource(rh, r)
+			case q.Type == dnsmessage.TypeAAAA && ip.To4() == nil:
+				r := dnsmessage.AAAAResource{}
+				copy(r.AAAA[:], ip.To16())
+				err = b.AAAAResource(rh, r)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return b.Finish()
+}
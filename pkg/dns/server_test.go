@@ -0,0 +1,150 @@
+package dns
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func query(t *testing.T, name string, qtype dnsmessage.Type) []byte {
+	t.Helper()
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 42, RecursionDesired: true})
+	if err := b.StartQuestions(); err != nil {
+		t.Fatalf("failed to build query: %v", err)
+	}
+
+	q := dnsmessage.Question{Name: dnsmessage.MustNewName(name), Type: qtype, Class: dnsmessage.ClassINET}
+	if err := b.Question(q); err != nil {
+		t.Fatalf("failed to build query: %v", err)
+	}
+
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatalf("failed to build query: %v", err)
+	}
+
+	return msg
+}
+
+func TestServerHandle(t *testing.T) {
+	server := NewServer()
+	server.Set("kindli-dev", RecordList{
+		{Name: "web.default.kindli-dev.kindli.local", Addresses: []string{"172.18.1.10", "fc00:f853:ccd:e793::110"}},
+		{Name: "db.data.kindli-dev.kindli.local", Addresses: []string{"172.18.1.11"}},
+	})
+
+	tests := []struct {
+		name      string
+		qname     string
+		qtype     dnsmessage.Type
+		wantRCode dnsmessage.RCode
+		want      []string
+	}{
+		{
+			name:  "A record",
+			qname: "web.default.kindli-dev.kindli.local.",
+			qtype: dnsmessage.TypeA,
+			want:  []string{"172.18.1.10"},
+		},
+		{
+			name:  "AAAA record",
+			qname: "web.default.kindli-dev.kindli.local.",
+			qtype: dnsmessage.TypeAAAA,
+			want:  []string{"fc00:f853:ccd:e793::110"},
+		},
+		{
+			name:  "names are case insensitive",
+			qname: "DB.Data.kindli-dev.kindli.local.",
+			qtype: dnsmessage.TypeA,
+			want:  []string{"172.18.1.11"},
+		},
+		{
+			name:  "AAAA query of an IPv4 only service has no answers",
+			qname: "db.data.kindli-dev.kindli.local.",
+			qtype: dnsmessage.TypeAAAA,
+			want:  []string{},
+		},
+		{
+			name:      "unknown name",
+			qname:     "api.default.kindli-dev.kindli.local.",
+			qtype:     dnsmessage.TypeA,
+			wantRCode: dnsmessage.RCodeNameError,
+			want:      []string{},
+		},
+		{
+			name:      "name outside of the domain",
+			qname:     "example.com.",
+			qtype:     dnsmessage.TypeA,
+			wantRCode: dnsmessage.RCodeRefused,
+			want:      []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := server.handle(query(t, tt.qname, tt.qtype))
+			if err != nil {
+				t.Fatalf("handle() error = %v", err)
+			}
+
+			var msg dnsmessage.Message
+			if err := msg.Unpack(resp); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+
+			if msg.Header.ID != 42 || !msg.Header.Response || !msg.Header.Authoritative {
+				t.Errorf("header = %+v, want an authoritative response with ID 42", msg.Header)
+			}
+
+			if msg.Header.RCode != tt.wantRCode {
+				t.Errorf("rcode = %s, want %s", msg.Header.RCode, tt.wantRCode)
+			}
+
+			if len(msg.Questions) != 1 || msg.Questions[0].Name.String() != tt.qname {
+				t.Errorf("questions = %v, want the query", msg.Questions)
+			}
+
+			got := []string{}
+			for _, answer := range msg.Answers {
+				if answer.Header.TTL != ttl {
+					t.Errorf("ttl = %d, want %d", answer.Header.TTL, ttl)
+				}
+
+				switch r := answer.Body.(type) {
+				case *dnsmessage.AResource:
+					got = append(got, net.IP(r.A[:]).String())
+				case *dnsmessage.AAAAResource:
+					got = append(got, net.IP(r.AAAA[:]).String())
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("answers = %q, want %q", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("answers = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestServerHandleInvalidQuery(t *testing.T) {
+	if _, err := NewServer().handle([]byte{0, 1, 2}); err == nil {
+		t.Error("handle() error = nil, want an error")
+	}
+}
+
+func TestServerRemove(t *testing.T) {
+	server := NewServer()
+	server.Set("kindli-dev", RecordList{{Name: "web.default.kindli-dev.kindli.local", Addresses: []string{"172.18.1.10"}}})
+	server.Remove("kindli-dev")
+
+	if _, found := server.lookup("web.default.kindli-dev.kindli.local"); found {
+		t.Error("lookup() found the record of a removed cluster")
+	}
+}
@@ -0,0 +1,156 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/kind"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Serve runs the DNS server on the address and keeps its records in sync
+// with the LoadBalancer services of the running clusters until ctx is done
+//
+// The running clusters are checked every interval, the services of every
+// running cluster are watched.
+func Serve(ctx context.Context, address string, interval time.Duration) error {
+	server := NewServer()
+	go Watch(ctx, server, interval)
+
+	return server.ListenAndServe(ctx, address)
+}
+
+// clusterWatch is the watch of the services of a cluster
+type clusterWatch struct {
+	cancel context.CancelFunc
+	// done is closed once the watch has exited
+	done chan struct{}
+}
+
+// Watch keeps the records of the server in sync with the LoadBalancer
+// services of the running clusters until ctx is done, see Serve
+func Watch(ctx context.Context, server *Server, interval time.Duration) {
+	watched := map[string]clusterWatch{}
+	for {
+		clusters, err := kind.RunningClusters("")
+		if err != nil {
+			logrus.Warnf("failed to list clusters: %s", err)
+		}
+
+		running := map[string]bool{}
+		for _, c := range clusters {
+			running[c.Name] = true
+			if _, ok := watched[c.Name]; ok {
+				continue
+			}
+
+			logrus.Infof("Watching services of cluster %s", c.Name)
+
+			cctx, cancel := context.WithCancel(ctx)
+			w := clusterWatch{cancel: cancel, done: make(chan struct{})}
+			watched[c.Name] = w
+
+			go func(c models.Cluster) {
+				defer close(w.done)
+				watchCluster(cctx, server, c, interval)
+			}(c)
+		}
+
+		for name, w := range watched {
+			if err == nil && !running[name] {
+				logrus.Infof("Stopped watching services of cluster %s", name)
+
+				// The records are removed once the watch has exited, a
+				// watch in flight would set them again otherwise
+				w.cancel()
+				<-w.done
+				delete(watched, name)
+				server.Remove(name)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// watchCluster keeps the records of the cluster in sync until ctx is done,
+// the watch is started again after interval if it fails
+func watchCluster(ctx context.Context, server *Server, c models.Cluster, interval time.Duration) {
+	for {
+		if err := watchServices(ctx, server, c); err != nil {
+			logrus.Warnf("failed to watch services of cluster %s: %s", c.Name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// watchServices lists the services of the cluster and then watches them
+// until the watch ends, the records of the cluster are updated on every
+// change
+func watchServices(ctx context.Context, server *Server, c models.Cluster) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	services := client.Clientset().CoreV1().Services(metav1.NamespaceAll)
+	list, err := services.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	known := map[string]corev1.Service{}
+	for _, svc := range list.Items {
+		known[svc.Namespace+"/"+svc.Name] = svc
+	}
+	server.Set(c.Name, recordsOf(c.Name, known))
+
+	w, err := services.Watch(ctx, metav1.ListOptions{ResourceVersion: list.ResourceVersion})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	for event := range w.ResultChan() {
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			if svc, ok := event.Object.(*corev1.Service); ok {
+				known[svc.Namespace+"/"+svc.Name] = *svc
+			}
+		case watch.Deleted:
+			if svc, ok := event.Object.(*corev1.Service); ok {
+				delete(known, svc.Namespace+"/"+svc.Name)
+			}
+		case watch.Error:
+			return fmt.Errorf("watch failed: %w", apierrors.FromObject(event.Object))
+		}
+
+		server.Set(c.Name, recordsOf(c.Name, known))
+	}
+
+	return nil
+}
+
+func recordsOf(cluster string, services map[string]corev1.Service) RecordList {
+	items := []corev1.Service{}
+	for _, svc := range services {
+		items = append(items, svc)
+	}
+
+	return serviceRecords(cluster, items)
+}
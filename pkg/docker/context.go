@@ -1,32 +1,77 @@
 package docker
 
 import (
-	"fmt"
 	"os"
 	"strings"
 
 	"github.com/utkarsh-pro/kindli/pkg/sh"
 )
 
+// ContextDescription is the description of the docker contexts created by
+// kindli, it tells them apart from the other contexts whatever their host
+const ContextDescription = "kindli VM"
+
 // CreateContext will create a new docker context
 func CreateContext(name, dockerHost string) error {
-	return sh.RunSilent(fmt.Sprintf("docker context create %s --docker %s", name, dockerHost))
+	return sh.NewCommand("docker", "context", "create", name, "--description", ContextDescription, "--docker", dockerHost).RunSilent()
 }
 
 // DeleteContext deletes a docker context
 func DeleteContext(name string) error {
-	return sh.RunSilent(fmt.Sprintf("docker context delete %s", name))
+	return sh.NewCommand("docker", "context", "delete", name).RunSilent()
 }
 
 // UseContext sets the given context as the default context
 func UseContext(name string) error {
 	os.Setenv("DOCKER_CONTEXT", name)
-	return sh.RunSilent(fmt.Sprintf("docker context use %s", name))
+	return sh.NewCommand("docker", "context", "use", name).RunSilent()
+}
+
+// ListContexts returns the names of all the docker contexts
+func ListContexts() ([]string, error) {
+	resp, err := sh.NewCommand("docker", "context", "ls", "-q").Query().Output()
+	if err != nil {
+		return nil, err
+	}
+
+	contexts := []string{}
+	for _, ctx := range strings.Split(string(resp), "\n") {
+		if ctx != "" {
+			contexts = append(contexts, ctx)
+		}
+	}
+
+	return contexts, nil
+}
+
+// ContextHost returns the docker host of the given context
+func ContextHost(name string) (string, error) {
+	resp, err := sh.NewCommand("docker", "context", "inspect", name, "-f", "{{ .Endpoints.docker.Host }}").Query().Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(resp)), nil
+}
+
+// ContextDescriptionOf returns the description of the given context
+func ContextDescriptionOf(name string) (string, error) {
+	resp, err := sh.NewCommand("docker", "context", "inspect", name, "-f", "{{ .Metadata.Description }}").Query().Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(resp)), nil
+}
+
+// UpdateContext updates the docker host of the given context
+func UpdateContext(name, dockerHost string) error {
+	return sh.NewCommand("docker", "context", "update", name, "--description", ContextDescription, "--docker", dockerHost).RunSilent()
 }
 
 // ExistsContext returns true if the given context already exists
 func ExistsContext(name string) (bool, error) {
-	resp, err := sh.RunIO("docker context ls -q")
+	resp, err := sh.NewCommand("docker", "context", "ls", "-q").Query().Output()
 	if err != nil {
 		return false, err
 	}
@@ -39,3 +84,14 @@ func ExistsContext(name string) (bool, error) {
 
 	return false, nil
 }
+
+// CurrentContext returns the default docker context, DOCKER_CONTEXT of the
+// environment is ignored
+func CurrentContext() (string, error) {
+	resp, err := sh.NewCommand("docker", "context", "show").WithEnv("DOCKER_CONTEXT", "").Query().Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(resp)), nil
+}
@@ -7,9 +7,10 @@ import (
 	"github.com/utkarsh-pro/kindli/pkg/sh"
 )
 
-// NetworkInsepct will inspect a docker network and will return the response as per the format string
-func NetworkInspect(network, format string) (string, error) {
-	resp, err := sh.RunIO(fmt.Sprintf("docker network inspect %s -f='%s'", network, format))
+// NetworkInspectContext inspects a docker network of the daemon of the given
+// context and returns the response as per the format string
+func NetworkInspectContext(context, network, format string) (string, error) {
+	resp, err := sh.NewCommand("docker", "network", "inspect", network, "-f", format).WithEnv("DOCKER_CONTEXT", context).Query().Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to inspect docker network: %s", err)
 	}
@@ -0,0 +1,524 @@
+package doctor
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/docker"
+	"github.com/utkarsh-pro/kindli/pkg/ipam"
+	"github.com/utkarsh-pro/kindli/pkg/kind"
+	"github.com/utkarsh-pro/kindli/pkg/metallb"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/snapshot"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
+)
+
+// Issue is an inconsistency between the kindli store and reality
+type Issue struct {
+	// Kind is the kind of the resource the issue is about, e.g. "vm"
+	Kind string
+	// Name is the name of the resource
+	Name string
+	// Description describes the issue
+	Description string
+	// Remedy describes what fixing the issue does or, for issues that
+	// cannot be fixed automatically, what the user can do about it
+	Remedy string
+
+	fix func() error
+}
+
+// Fixable returns true if the issue can be fixed automatically
+func (i *Issue) Fixable() bool {
+	return i.fix != nil
+}
+
+// Fix fixes the issue
+func (i *Issue) Fix() error {
+	if i.fix == nil {
+		return fmt.Errorf("issue cannot be fixed automatically: %s", i.Remedy)
+	}
+
+	return i.fix()
+}
+
+// Diagnose compares the kindli store with the VMs, docker contexts, kind
+// clusters and the persisted configs and returns all the inconsistencies
+func Diagnose() ([]*Issue, error) {
+	vms, err := models.ListVM()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VMs: %w", err)
+	}
+
+	clusters, err := models.ListCluster()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	issues := []*Issue{}
+	for _, check := range []func([]*models.VM, []models.Cluster) ([]*Issue, error){
+		checkVMs,
+		checkOrphanVMs,
+		checkDockerContexts,
+		checkClusters,
+		checkOrphanConfigs,
+		checkIPAllocations,
+		checkSnapshots,
+	} {
+		found, err := check(vms, clusters)
+		if err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, found...)
+	}
+
+	return issues, nil
+}
+
+// checkVMs reports VMs present in the store which don't exist anymore
+func checkVMs(vms []*models.VM, _ []models.Cluster) ([]*Issue, error) {
+	issues := []*Issue{}
+
+	for _, v := range vms {
+		provider, err := vm.GetProvider(v.Provider)
+		if err != nil {
+			issues = append(issues, &Issue{
+				Kind:        "vm",
+				Name:        v.Name,
+				Description: err.Error(),
+				Remedy:      "use a kindli build which supports the provider",
+			})
+			continue
+		}
+
+		exists, err := provider.Exists(v.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if VM %s exists: %w", v.Name, err)
+		}
+
+		if exists {
+			continue
+		}
+
+		v := v
+		issues = append(issues, &Issue{
+			Kind:        "vm",
+			Name:        v.Name,
+			Description: fmt.Sprintf("VM is in the store but does not exist in %s", provider.Name()),
+			Remedy:      "remove the VM and its clusters from the store",
+			fix: func() error {
+				return forgetVM(v)
+			},
+		})
+	}
+
+	return issues, nil
+}
+
+// checkOrphanVMs reports VMs created by kindli which are missing from the store
+func checkOrphanVMs(vms []*models.VM, _ []models.Cluster) ([]*Issue, error) {
+	known := map[string]bool{}
+	for _, v := range vms {
+		known[v.Name] = true
+	}
+
+	issues := []*Issue{}
+	for _, name := range vm.Providers() {
+		provider, _ := vm.GetProvider(name)
+
+		adopter, ok := provider.(vm.Adopter)
+		if !ok {
+			continue
+		}
+
+		found, err := adopter.List()
+		if err != nil {
+			logrus.Warnf("failed to list VMs of provider %s: %s", name, err)
+			continue
+		}
+
+		for _, vmName := range found {
+			if known[vmName] {
+				continue
+			}
+
+			vmName, providerName, adopter := vmName, name, adopter
+			issues = append(issues, &Issue{
+				Kind:        "vm",
+				Name:        vmName,
+				Description: fmt.Sprintf("VM exists in %s but is missing from the store", providerName),
+				Remedy:      "adopt the VM into the store",
+				fix: func() error {
+					return adopter.Adopt(vmName)
+				},
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// checkDockerContexts reports docker contexts of the VMs which are missing
+// or point to the wrong docker host and contexts of VMs which don't exist
+func checkDockerContexts(vms []*models.VM, clusters []models.Cluster) ([]*Issue, error) {
+	contexts, err := docker.ListContexts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list docker contexts: %w", err)
+	}
+
+	hosts := map[string]string{}
+	created := map[string]bool{}
+	for _, ctx := range contexts {
+		host, err := docker.ContextHost(ctx)
+		if err != nil {
+			logrus.Debugf("failed to inspect docker context %s: %s", ctx, err)
+			continue
+		}
+
+		description, err := docker.ContextDescriptionOf(ctx)
+		if err != nil {
+			logrus.Debugf("failed to inspect docker context %s: %s", ctx, err)
+		}
+
+		hosts[ctx] = host
+		created[ctx] = description == docker.ContextDescription || legacyContextHost(host)
+	}
+
+	inUse := map[string]bool{}
+	for _, c := range clusters {
+		inUse[c.VM] = true
+	}
+
+	issues := []*Issue{}
+	known := map[string]bool{}
+	for _, v := range vms {
+		known[v.Name] = true
+
+		provider, err := vm.GetProvider(v.Provider)
+		if err != nil {
+			continue
+		}
+
+		want := provider.DockerHost(v.Name)
+		got, ok := hosts[v.Name]
+
+		name := v.Name
+		switch {
+		case !ok && inUse[v.Name]:
+			issues = append(issues, &Issue{
+				Kind:        "docker-context",
+				Name:        name,
+				Description: "VM has clusters but no docker context",
+				Remedy:      "create the docker context",
+				fix: func() error {
+					return docker.CreateContext(name, "host="+want)
+				},
+			})
+		case ok && got != want:
+			issues = append(issues, &Issue{
+				Kind:        "docker-context",
+				Name:        name,
+				Description: fmt.Sprintf("docker context points to %s instead of %s", got, want),
+				Remedy:      "update the docker context",
+				fix: func() error {
+					return docker.UpdateContext(name, "host="+want)
+				},
+			})
+		}
+	}
+
+	for ctx := range hosts {
+		if known[ctx] || !created[ctx] {
+			continue
+		}
+
+		ctx := ctx
+		issues = append(issues, &Issue{
+			Kind:        "docker-context",
+			Name:        ctx,
+			Description: "docker context points to a kindli VM which does not exist",
+			Remedy:      "delete the docker context",
+			fix: func() error {
+				return docker.DeleteContext(ctx)
+			},
+		})
+	}
+
+	return issues, nil
+}
+
+// legacyContextHost returns true if the docker host is one of the hosts of
+// the contexts created by older kindli, which didn't describe the contexts:
+// the docker socket of a lima VM or the docker port of a qemu VM
+func legacyContextHost(host string) bool {
+	if strings.Contains(host, config.Dir()) {
+		return true
+	}
+
+	u, err := url.Parse(host)
+	if err != nil || u.Scheme != "tcp" {
+		return false
+	}
+
+	_, vmNetwork, _ := net.ParseCIDR(models.VMNetworkIPv4)
+	ip := net.ParseIP(u.Hostname())
+
+	return ip != nil && vmNetwork.Contains(ip)
+}
+
+// checkClusters reports clusters whose VM, configs or kind cluster are
+// missing and kind clusters which are missing from the store
+func checkClusters(vms []*models.VM, clusters []models.Cluster) ([]*Issue, error) {
+	issues := []*Issue{}
+
+	byVM := map[string][]models.Cluster{}
+	for _, c := range clusters {
+		byVM[c.VM] = append(byVM[c.VM], c)
+	}
+
+	known := map[string]bool{}
+	for _, v := range vms {
+		known[v.Name] = true
+	}
+
+	for vmName, vmClusters := range byVM {
+		if known[vmName] {
+			continue
+		}
+
+		for _, c := range vmClusters {
+			name := c.Name
+			issues = append(issues, &Issue{
+				Kind:        "cluster",
+				Name:        name,
+				Description: fmt.Sprintf("cluster belongs to VM %s which is not in the store", vmName),
+				Remedy:      "remove the cluster from the store",
+				fix: func() error {
+					return kind.Forget(name)
+				},
+			})
+		}
+	}
+
+	for _, c := range clusters {
+		if _, err := os.Stat(c.KindConfigPath); err != nil {
+			issues = append(issues, &Issue{
+				Kind:        "cluster",
+				Name:        c.Name,
+				Description: fmt.Sprintf("kind config %s is missing", c.KindConfigPath),
+				Remedy:      "recreate the cluster with `kindli delete` and `kindli create`",
+			})
+		}
+
+		// Clusters created with --skip-metallb have no metallb config
+		if c.MetalLBVersion != "" {
+			if _, err := metallb.LoadConfigFromDisk(c.Name); err != nil {
+				issues = append(issues, &Issue{
+					Kind:        "cluster",
+					Name:        c.Name,
+					Description: "metallb config is missing",
+					Remedy:      "run `kindli create` for the cluster again to configure metallb",
+				})
+			}
+		}
+	}
+
+	for _, v := range vms {
+		running, err := vm.Running(v.Name)
+		if err != nil || !running {
+			logrus.Debugf("skipping kind clusters of VM %s as it is not running", v.Name)
+			continue
+		}
+
+		kindClusters, err := kind.Clusters(v.Name)
+		if err != nil {
+			logrus.Warnf("failed to get kind clusters of VM %s: %s", v.Name, err)
+			continue
+		}
+
+		inKind := map[string]bool{}
+		for _, name := range kindClusters {
+			inKind[name] = true
+		}
+
+		stored := map[string]bool{}
+		for _, c := range byVM[v.Name] {
+			stored[c.Name] = true
+
+			if inKind[c.Name] {
+				continue
+			}
+
+			name := c.Name
+			issues = append(issues, &Issue{
+				Kind:        "cluster",
+				Name:        name,
+				Description: fmt.Sprintf("cluster is in the store but kind cluster does not exist in VM %s", v.Name),
+				Remedy:      "remove the cluster from the store",
+				fix: func() error {
+					return kind.Forget(name)
+				},
+			})
+		}
+
+		for _, name := range kindClusters {
+			if stored[name] {
+				continue
+			}
+
+			name, vmName := name, v.Name
+			issues = append(issues, &Issue{
+				Kind:        "cluster",
+				Name:        name,
+				Description: fmt.Sprintf("kind cluster exists in VM %s but is missing from the store", vmName),
+				Remedy:      "adopt the cluster into the store",
+				fix: func() error {
+					return kind.Adopt(name, vmName)
+				},
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// checkOrphanConfigs reports persisted configs of clusters which are not in
+// the store
+func checkOrphanConfigs(_ []*models.VM, clusters []models.Cluster) ([]*Issue, error) {
+	known := map[string]bool{}
+	for _, c := range clusters {
+		known[c.Name] = true
+	}
+
+	issues := []*Issue{}
+	for resource, dir := range map[string]string{
+		"kind-config":    kind.ConfigDir(),
+		"metallb-config": metallb.ConfigDir(),
+	} {
+		files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			name := strings.TrimSuffix(filepath.Base(file), ".yaml")
+			if known[name] {
+				continue
+			}
+
+			file := file
+			issues = append(issues, &Issue{
+				Kind:        resource,
+				Name:        name,
+				Description: fmt.Sprintf("%s belongs to a cluster which is not in the store", file),
+				Remedy:      "remove the config",
+				fix: func() error {
+					return os.Remove(file)
+				},
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// checkIPAllocations reports IP allocations of clusters and VMs which are
+// not in the store
+func checkIPAllocations(vms []*models.VM, clusters []models.Cluster) ([]*Issue, error) {
+	known := map[string]bool{}
+	for i := range clusters {
+		known[clusters[i].IPOwner()] = true
+	}
+
+	for _, v := range vms {
+		known[v.IPOwner()] = true
+	}
+
+	allocs, err := ipam.Allocations()
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []*Issue{}
+	reported := map[string]bool{}
+	for _, alloc := range allocs {
+		if known[alloc.Owner] || reported[alloc.Owner] {
+			continue
+		}
+		reported[alloc.Owner] = true
+
+		owner := alloc.Owner
+		issues = append(issues, &Issue{
+			Kind:        "ip-allocation",
+			Name:        owner,
+			Description: "IP allocations belong to a cluster or a VM which is not in the store",
+			Remedy:      "release the IP allocations",
+			fix: func() error {
+				return ipam.Release(owner)
+			},
+		})
+	}
+
+	return issues, nil
+}
+
+// checkSnapshots reports snapshots of VMs which are not in the store, their
+// images and volumes are gone with the VM
+func checkSnapshots(vms []*models.VM, _ []models.Cluster) ([]*Issue, error) {
+	known := map[string]bool{}
+	for _, v := range vms {
+		known[v.Name] = true
+	}
+
+	snapshots, err := snapshot.Snapshots("")
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []*Issue{}
+	for _, s := range snapshots {
+		if known[s.VM] {
+			continue
+		}
+
+		s := s
+		issues = append(issues, &Issue{
+			Kind:        "snapshot",
+			Name:        s.Cluster + "/" + s.Name,
+			Description: fmt.Sprintf("snapshot belongs to VM %s which is not in the store", s.VM),
+			Remedy:      "delete the snapshot",
+			fix: func() error {
+				return snapshot.Delete(s.Cluster, s.Name)
+			},
+		})
+	}
+
+	return issues, nil
+}
+
+// forgetVM removes the VM and its clusters from the store
+func forgetVM(v *models.VM) error {
+	clusters, err := models.ListCluster()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range clusters {
+		if c.VM != v.Name {
+			continue
+		}
+
+		if err := kind.Forget(c.Name); err != nil {
+			return err
+		}
+	}
+
+	return v.Delete()
+}
@@ -0,0 +1,30 @@
+package doctor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/utkarsh-pro/kindli/pkg/config"
+)
+
+func TestLegacyContextHost(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		want bool
+	}{
+		{name: "lima socket", host: "unix://" + filepath.Join(config.Dir(), "kindli.sock"), want: true},
+		{name: "qemu VM IP", host: "tcp://192.168.105.11:2375", want: true},
+		{name: "docker of the host", host: "unix:///var/run/docker.sock", want: false},
+		{name: "remote docker", host: "tcp://10.0.0.5:2376", want: false},
+		{name: "ssh", host: "ssh://user@192.168.105.11", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := legacyContextHost(tt.host); got != tt.want {
+				t.Errorf("legacyContextHost(%s) = %v, want %v", tt.host, got, tt.want)
+			}
+		})
+	}
+}
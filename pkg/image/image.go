@@ -2,17 +2,217 @@ package image
 
 import (
 	"fmt"
+	"os"
+	"sort"
 
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/models"
 	"github.com/utkarsh-pro/kindli/pkg/sh"
 )
 
-func Load(image, cluster string) error {
-	if cluster == "" {
-		cluster = "kindli"
+const (
+	ResultLoaded  = "Loaded"
+	ResultFailed  = "Failed"
+	ResultSkipped = "Skipped"
+
+	// DefaultHostContext is the docker context of the docker daemon of the
+	// host
+	DefaultHostContext = "default"
+)
+
+// LoadOptions are the images to load into the clusters
+type LoadOptions struct {
+	// Images are loaded from the docker daemon of the VM, images which are
+	// missing there are pulled from their registry
+	Images []string
+	// Archives are docker or OCI image archives on the host
+	Archives []string
+	// FromHost streams the images from the docker daemon of the host into
+	// the VM instead of using or pulling the images of the VM
+	FromHost bool
+	// HostContext is the docker context of the host docker daemon
+	HostContext string
+}
+
+// Result is the outcome of loading an image or an archive into a cluster
+type Result struct {
+	Cluster string `json:"cluster" yaml:"cluster"`
+	VM      string `json:"vm" yaml:"vm"`
+	Image   string `json:"image" yaml:"image"`
+	Result  string `json:"result" yaml:"result"`
+	Error   string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Results is the list of results of Load
+type Results []Result
+
+func (r Results) Header(wide bool) []string {
+	return []string{"CLUSTER", "VM", "IMAGE", "RESULT", "ERROR"}
+}
+
+func (r Results) Rows(wide bool) [][]string {
+	rows := [][]string{}
+	for _, res := range r {
+		rows = append(rows, []string{res.Cluster, res.VM, res.Image, res.Result, res.Error})
+	}
+
+	return rows
+}
+
+// Failed returns the number of images which failed to load
+func (r Results) Failed() int {
+	failed := 0
+	for _, res := range r {
+		if res.Result == ResultFailed {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+// IsArchive returns true if the argument is an image archive on the host
+// rather than an image reference
+func IsArchive(arg string) bool {
+	info, err := os.Stat(arg)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Load loads the images and the archives into the nodes of the clusters
+//
+// The images are prepared once per VM, either by streaming them from the
+// host or by pulling them if they are missing in the VM, and then loaded
+// into every cluster of the VM. Clusters which aren't running are skipped.
+// A failure doesn't stop the other loads, the outcome of every load is
+// reported in the results.
+func Load(clusters []models.Cluster, opts LoadOptions) (Results, error) {
+	if opts.HostContext == "" {
+		opts.HostContext = DefaultHostContext
+	}
+
+	byVM := map[string][]models.Cluster{}
+	for _, c := range clusters {
+		byVM[c.VM] = append(byVM[c.VM], c)
+	}
+
+	vms := []string{}
+	for vmName := range byVM {
+		vms = append(vms, vmName)
+	}
+	sort.Strings(vms)
+
+	results := Results{}
+	for _, vmName := range vms {
+		prepared := map[string]error{}
+		for _, img := range opts.Images {
+			prepared[img] = prepare(img, vmName, opts)
+		}
+
+		for _, c := range byVM[vmName] {
+			results = append(results, loadCluster(c, opts, prepared)...)
+		}
+	}
+
+	if failed := results.Failed(); failed > 0 {
+		return results, fmt.Errorf("failed to load %d of %d images", failed, len(results))
+	}
+
+	return results, nil
+}
+
+// prepare makes the image available in the docker daemon of the VM
+func prepare(img, vmName string, opts LoadOptions) error {
+	if opts.FromHost {
+		logrus.Infof("Streaming image %s from the host into VM %s", img, vmName)
+		return Stream(img, opts.HostContext, vmName)
+	}
+
+	return ensure(img, vmName)
+}
+
+func loadCluster(c models.Cluster, opts LoadOptions, prepared map[string]error) Results {
+	results := Results{}
+	add := func(img string, err error) {
+		res := Result{Cluster: c.Name, VM: c.VM, Image: img, Result: ResultLoaded}
+		if err != nil {
+			res.Result, res.Error = ResultFailed, err.Error()
+		}
+
+		results = append(results, res)
+	}
+
+	if c.Status != models.ClusterRunning {
+		for _, img := range append(append([]string{}, opts.Images...), opts.Archives...) {
+			results = append(results, Result{Cluster: c.Name, VM: c.VM, Image: img, Result: ResultSkipped, Error: "cluster is " + c.Status})
+		}
+
+		return results
+	}
+
+	for _, img := range opts.Images {
+		if err := prepared[img]; err != nil {
+			add(img, err)
+			continue
+		}
+
+		logrus.Infof("Loading image %s into cluster \"%s\"", img, c.Name)
+		add(img, load(c, "docker-image", img))
+	}
+
+	for _, archive := range opts.Archives {
+		logrus.Infof("Loading archive %s into cluster \"%s\"", archive, c.Name)
+		add(archive, load(c, "image-archive", archive))
+	}
+
+	return results
+}
+
+func load(c models.Cluster, kind, img string) error {
+	if err := sh.NewCommand("kind", "load", kind, img, "--name", c.Name).WithEnv("DOCKER_CONTEXT", c.VM).RunSilent(); err != nil {
+		return fmt.Errorf("failed to load into the cluster: %w", err)
+	}
+
+	return nil
+}
+
+// Stream copies the image from the docker daemon of the host context into
+// the docker daemon of the VM without an intermediate archive
+func Stream(img, hostContext, vmName string) error {
+	save := sh.NewCommand("docker", "save", img).WithEnv("DOCKER_CONTEXT", hostContext)
+	load := sh.NewCommand("docker", "load").WithEnv("DOCKER_CONTEXT", vmName)
+
+	if err := sh.Pipe(save, load); err != nil {
+		return fmt.Errorf("failed to stream image \"%s\" from the host: %w", img, err)
+	}
+
+	return nil
+}
+
+// ensure pulls the image into the docker daemon of the VM if it is missing
+// there
+func ensure(img, vmName string) error {
+	inspect := sh.NewCommand("docker", "image", "inspect", img).WithEnv("DOCKER_CONTEXT", vmName).Query()
+	if err := inspect.RunSilent(); err == nil {
+		return nil
+	}
+
+	if err := sh.NewCommand("docker", "pull", img).WithEnv("DOCKER_CONTEXT", vmName).Run(); err != nil {
+		return fmt.Errorf("failed to pull image \"%s\": %w", img, err)
+	}
+
+	return nil
+}
+
+// Preload loads the image from the docker daemon of the VM into the nodes of
+// the cluster so that the nodes don't need to pull it, the image is pulled
+// into the VM first if it is missing there
+func Preload(image, cluster, vmName string) error {
+	if err := ensure(image, vmName); err != nil {
+		return err
 	}
 
-	if err := sh.Run(fmt.Sprintf("kind load docker-image %s --name %s", image, cluster)); err != nil {
-		return fmt.Errorf("failed to load image into the cluster \"%s\": %s", cluster, err)
+	if err := sh.NewCommand("kind", "load", "docker-image", image, "--name", cluster).WithEnv("DOCKER_CONTEXT", vmName).Run(); err != nil {
+		return fmt.Errorf("failed to load image into the cluster \"%s\": %w", cluster, err)
 	}
 
 	return nil
@@ -0,0 +1,55 @@
+package image
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    map[string]sh.Response
+		wantErr bool
+	}{
+		{
+			name: "image is saved on the host and loaded in the VM",
+			resp: map[string]sh.Response{"DOCKER_CONTEXT=default docker save": {Output: []byte("archive")}},
+		},
+		{
+			name:    "missing image on the host",
+			resp:    map[string]sh.Response{"DOCKER_CONTEXT=default docker save": {Err: errors.New("exit status 1")}},
+			wantErr: true,
+		},
+		{
+			name:    "failing load in the VM",
+			resp:    map[string]sh.Response{"DOCKER_CONTEXT=kindli docker load": {Err: errors.New("exit status 1")}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := sh.Record(t)
+			for prefix, resp := range tt.resp {
+				rec.On(prefix, resp)
+			}
+
+			if err := Stream("nginx:1.23", DefaultHostContext, "kindli"); (err != nil) != tt.wantErr {
+				t.Fatalf("Stream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// The commands of the pipe run concurrently
+			got := rec.Commands()
+			sort.Strings(got)
+
+			want := []string{"DOCKER_CONTEXT=default docker save nginx:1.23", "DOCKER_CONTEXT=kindli docker load"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("commands = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package ipam
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+)
+
+// Names of the pools
+const (
+	PoolServiceIPv4      = models.IPPoolServiceIPv4
+	PoolPodIPv4          = models.IPPoolPodIPv4
+	PoolLoadBalancerIPv4 = models.IPPoolLoadBalancerIPv4
+	PoolServiceIPv6      = models.IPPoolServiceIPv6
+	PoolPodIPv6          = models.IPPoolPodIPv6
+	PoolLoadBalancerIPv6 = models.IPPoolLoadBalancerIPv6
+	PoolKindNetworkIPv4  = models.IPPoolKindNetworkIPv4
+	PoolKindNetworkIPv6  = models.IPPoolKindNetworkIPv6
+)
+
+// ErrExhausted is returned when a pool has no free subnet left
+var ErrExhausted = errors.New("pool exhausted")
+
+// PoolList is a list of pools
+type PoolList []*models.IPPool
+
+func (l PoolList) Header(wide bool) []string {
+	return []string{"NAME", "CIDR", "PREFIX LENGTH"}
+}
+
+func (l PoolList) Rows(wide bool) [][]string {
+	rows := [][]string{}
+	for _, p := range l {
+		cidr := p.CIDR
+		if cidr == "" {
+			cidr = "<kind network>"
+		}
+
+		rows = append(rows, []string{p.Name, cidr, strconv.Itoa(p.PrefixLen)})
+	}
+
+	return rows
+}
+
+// AllocationList is a list of allocations
+type AllocationList []*models.IPAllocation
+
+func (l AllocationList) Header(wide bool) []string {
+	return []string{"CIDR", "POOL", "OWNER"}
+}
+
+func (l AllocationList) Rows(wide bool) [][]string {
+	rows := [][]string{}
+	for _, a := range l {
+		rows = append(rows, []string{a.CIDR, a.Pool, a.Owner})
+	}
+
+	return rows
+}
+
+// Pools returns all the pools
+func Pools() (PoolList, error) {
+	pools, err := models.ListIPPool()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IP pools: %w", err)
+	}
+
+	return pools, nil
+}
+
+// GetPool returns the pool with the given name
+func GetPool(name string) (*models.IPPool, error) {
+	pool := models.NewIPPool(name, "", 0)
+	if err := pool.GetByName(); err != nil {
+		return nil, fmt.Errorf("failed to find IP pool \"%s\": %w", name, err)
+	}
+
+	return pool, nil
+}
+
+// SetPool changes the parent CIDR and the size of the subnets allocated from
+// the pool. Existing allocations are left untouched.
+//
+// An empty CIDR is only allowed for the LoadBalancer pools and makes them
+// allocate from the subnet of the "kind" docker network.
+func SetPool(name, cidr string, prefixLen int) error {
+	pool, err := GetPool(name)
+	if err != nil {
+		return err
+	}
+
+	ipv6 := name == PoolServiceIPv6 || name == PoolPodIPv6 || name == PoolLoadBalancerIPv6 || name == PoolKindNetworkIPv6
+	bits := 32
+	if ipv6 {
+		bits = 128
+	}
+
+	if prefixLen <= 0 || prefixLen > bits {
+		return fmt.Errorf("invalid prefix length %d for pool \"%s\"", prefixLen, name)
+	}
+
+	if cidr == "" {
+		if name != PoolLoadBalancerIPv4 && name != PoolLoadBalancerIPv6 {
+			return fmt.Errorf("pool \"%s\" requires a CIDR", name)
+		}
+	} else {
+		ip, parent, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR for pool \"%s\": %w", name, err)
+		}
+
+		if (ip.To4() == nil) != ipv6 {
+			return fmt.Errorf("CIDR %s does not match the IP family of pool \"%s\"", cidr, name)
+		}
+
+		ones, _ := parent.Mask.Size()
+		if prefixLen < ones {
+			return fmt.Errorf("prefix length %d is larger than the CIDR %s", prefixLen, cidr)
+		}
+
+		cidr = parent.String()
+	}
+
+	pool.CIDR = cidr
+	pool.PrefixLen = prefixLen
+
+	if err := pool.Save(); err != nil {
+		return fmt.Errorf("failed to save IP pool \"%s\": %w", name, err)
+	}
+
+	return nil
+}
+
+// Allocations returns all the allocations
+func Allocations() (AllocationList, error) {
+	allocs, err := models.ListIPAllocation()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IP allocations: %w", err)
+	}
+
+	return allocs, nil
+}
+
+// Allocate allocates a free subnet from the pool to the owner, if the owner
+// already has a subnet from the pool then that subnet is returned.
+//
+// A subnet is free if it does not overlap with any allocation, any of the
+// excluded subnets or any route of the host.
+func Allocate(pool *models.IPPool, owner string, exclude ...*net.IPNet) (*net.IPNet, error) {
+	return AllocateFrom(pool, owner, nil, exclude...)
+}
+
+// AllocateFrom is Allocate for pools carved from the network, like the
+// LoadBalancer pools carved from the kind network of a VM. The allocation
+// of the network and the route of the host to it hold the pool and are
+// not considered used.
+func AllocateFrom(pool *models.IPPool, owner string, network *net.IPNet, exclude ...*net.IPNet) (*net.IPNet, error) {
+	if pool.CIDR == "" {
+		return nil, fmt.Errorf("pool \"%s\" has no CIDR", pool.Name)
+	}
+
+	_, parent, err := net.ParseCIDR(pool.CIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR of pool \"%s\": %w", pool.Name, err)
+	}
+
+	ones, bits := parent.Mask.Size()
+	if pool.PrefixLen < ones || pool.PrefixLen > bits {
+		return nil, fmt.Errorf("invalid prefix length %d for CIDR %s of pool \"%s\"", pool.PrefixLen, pool.CIDR, pool.Name)
+	}
+
+	allocs, err := models.ListIPAllocation()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IP allocations: %w", err)
+	}
+
+	used := append([]*net.IPNet{}, exclude...)
+	for _, alloc := range allocs {
+		_, subnet, err := net.ParseCIDR(alloc.CIDR)
+		if err != nil {
+			logrus.Warnf("ignoring invalid IP allocation %s: %s", alloc.CIDR, err)
+			continue
+		}
+
+		if alloc.Pool == pool.Name && alloc.Owner == owner {
+			return subnet, nil
+		}
+
+		if !sameNet(subnet, network) {
+			used = append(used, subnet)
+		}
+	}
+
+	routes, err := hostRoutes()
+	if err != nil {
+		logrus.Warnf("failed to read the routes of the host, overlap with host networks is not checked: %s", err)
+	}
+
+	for _, route := range routes {
+		if !sameNet(route, network) {
+			used = append(used, route)
+		}
+	}
+
+	subnet, err := nextFree(parent, pool.PrefixLen, used)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate from pool \"%s\" (%s): %w", pool.Name, pool.CIDR, err)
+	}
+
+	if err := models.NewIPAllocation(pool.Name, subnet.String(), owner).Save(); err != nil {
+		return nil, fmt.Errorf("failed to save IP allocation: %w", err)
+	}
+
+	return subnet, nil
+}
+
+// Reserve records the subnet as allocated from the pool to the owner, the
+// subnet must not overlap with the allocations of the other owners
+func Reserve(poolName, cidr, owner string) error {
+	_, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("invalid subnet %s: %w", cidr, err)
+	}
+
+	allocs, err := models.ListIPAllocation()
+	if err != nil {
+		return fmt.Errorf("failed to list IP allocations: %w", err)
+	}
+
+	for _, alloc := range allocs {
+		_, used, err := net.ParseCIDR(alloc.CIDR)
+		if err != nil || !Overlaps(subnet, used) {
+			continue
+		}
+
+		if alloc.Owner == owner && used.String() == subnet.String() {
+			return nil
+		}
+
+		return fmt.Errorf("subnet %s overlaps with %s allocated to %s", subnet, alloc.CIDR, alloc.Owner)
+	}
+
+	if err := models.NewIPAllocation(poolName, subnet.String(), owner).Save(); err != nil {
+		return fmt.Errorf("failed to save IP allocation: %w", err)
+	}
+
+	return nil
+}
+
+// ReserveNetwork records the network as allocated from the pool to the
+// owner, the network must not overlap with the allocations of the other
+// owners from the same pool
+//
+// Unlike Reserve, allocations from the other pools are allowed inside the
+// network as they are carved from it.
+func ReserveNetwork(poolName, cidr, owner string) error {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("invalid subnet %s: %w", cidr, err)
+	}
+
+	allocs, err := models.ListIPAllocation()
+	if err != nil {
+		return fmt.Errorf("failed to list IP allocations: %w", err)
+	}
+
+	for _, alloc := range allocs {
+		if alloc.Pool != poolName {
+			continue
+		}
+
+		_, used, err := net.ParseCIDR(alloc.CIDR)
+		if err != nil || !Overlaps(network, used) {
+			continue
+		}
+
+		if alloc.Owner == owner && used.String() == network.String() {
+			return nil
+		}
+
+		return fmt.Errorf("network %s overlaps with %s allocated to %s", network, alloc.CIDR, alloc.Owner)
+	}
+
+	if err := models.NewIPAllocation(poolName, network.String(), owner).Save(); err != nil {
+		return fmt.Errorf("failed to save IP allocation: %w", err)
+	}
+
+	return nil
+}
+
+// Release releases all the allocations of the owner
+func Release(owner string) error {
+	if err := models.DeleteIPAllocationsByOwner(owner); err != nil {
+		return fmt.Errorf("failed to release IP allocations of %s: %w", owner, err)
+	}
+
+	return nil
+}
+
+// Overlaps returns true if the two subnets share any address
+func Overlaps(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// sameNet returns true if the subnets are the same network, a nil network
+// is not the same as any subnet
+func sameNet(a, b *net.IPNet) bool {
+	return a != nil && b != nil && a.String() == b.String()
+}
+
+// nextFree returns the first subnet of size prefixLen in parent which does
+// not overlap with any of the used subnets
+func nextFree(parent *net.IPNet, prefixLen int, used []*net.IPNet) (*net.IPNet, error) {
+	_, bits := parent.Mask.Size()
+	mask := net.CIDRMask(prefixLen, bits)
+	step := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen))
+
+	start := toInt(parent.IP)
+	end := lastInt(parent)
+
+	for cur := start; cur.Cmp(end) <= 0; {
+		candidate := &net.IPNet{IP: toIP(cur, bits), Mask: mask}
+
+		blocker := firstOverlap(candidate, used)
+		if blocker == nil {
+			return candidate, nil
+		}
+
+		// Skip past the candidate or the blocker, whichever ends later,
+		// aligned to the size of the subnets
+		next := new(big.Int).Add(cur, step)
+		if blockerEnd := lastInt(blocker); blockerEnd.Cmp(next) >= 0 {
+			offset := new(big.Int).Sub(blockerEnd, start)
+			offset.Div(offset, step)
+			offset.Add(offset, big.NewInt(1))
+			next = offset.Mul(offset, step).Add(offset, start)
+		}
+
+		cur = next
+	}
+
+	return nil, ErrExhausted
+}
+
+func firstOverlap(subnet *net.IPNet, used []*net.IPNet) *net.IPNet {
+	for _, u := range used {
+		if (u.IP.To4() == nil) != (subnet.IP.To4() == nil) {
+			continue
+		}
+
+		if Overlaps(subnet, u) {
+			return u
+		}
+	}
+
+	return nil
+}
+
+func toInt(ip net.IP) *big.Int {
+	if v4 := ip.To4(); v4 != nil {
+		return new(big.Int).SetBytes(v4)
+	}
+
+	return new(big.Int).SetBytes(ip.To16())
+}
+
+func toIP(i *big.Int, bits int) net.IP {
+	byt := i.Bytes()
+	ip := make(net.IP, bits/8)
+	copy(ip[len(ip)-len(byt):], byt)
+
+	return ip
+}
+
+// lastInt returns the last address of the subnet
+func lastInt(subnet *net.IPNet) *big.Int {
+	ones, bits := subnet.Mask.Size()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+
+	return size.Add(size, toInt(subnet.IP.Mask(subnet.Mask))).Sub(size, big.NewInt(1))
+}
@@ -0,0 +1,335 @@
+package ipam
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/utkarsh-pro/kindli/pkg/db"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "kindli-ipam-test-*")
+	if err != nil {
+		panic(err)
+	}
+
+	models.RegisterMigrations()
+	db.Setup(filepath.Join(dir, "db.sqlite"))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func cidr(t *testing.T, s string) *net.IPNet {
+	t.Helper()
+
+	_, subnet, err := net.ParseCIDR(s)
+	if err != nil {
+		t.Fatalf("invalid CIDR %s: %v", s, err)
+	}
+
+	return subnet
+}
+
+// reset releases all the allocations and makes the host have no routes
+// for the duration of the test
+func reset(t *testing.T) *sh.Recorder {
+	t.Helper()
+
+	allocs, err := models.ListIPAllocation()
+	if err != nil {
+		t.Fatalf("failed to list allocations: %v", err)
+	}
+
+	for _, alloc := range allocs {
+		if err := alloc.Delete(); err != nil {
+			t.Fatalf("failed to delete allocation: %v", err)
+		}
+	}
+
+	return sh.Record(t)
+}
+
+func allocate(t *testing.T, pool, cidr, owner string) {
+	t.Helper()
+
+	if err := models.NewIPAllocation(pool, cidr, owner).Save(); err != nil {
+		t.Fatalf("failed to save allocation: %v", err)
+	}
+}
+
+func TestNextFree(t *testing.T) {
+	tests := []struct {
+		name      string
+		parent    string
+		prefixLen int
+		used      []string
+		want      string
+		wantErr   error
+	}{
+		{
+			name:      "first subnet of empty parent",
+			parent:    "10.0.0.0/24",
+			prefixLen: 26,
+			want:      "10.0.0.0/26",
+		},
+		{
+			name:      "used subnets are skipped",
+			parent:    "10.0.0.0/24",
+			prefixLen: 26,
+			used:      []string{"10.0.0.0/26", "10.0.0.64/26"},
+			want:      "10.0.0.128/26",
+		},
+		{
+			name:      "larger blocker is skipped at once",
+			parent:    "10.0.0.0/24",
+			prefixLen: 28,
+			used:      []string{"10.0.0.0/25"},
+			want:      "10.0.0.128/28",
+		},
+		{
+			name:      "partial overlap blocks the subnet",
+			parent:    "10.0.0.0/24",
+			prefixLen: 26,
+			used:      []string{"10.0.0.10/32"},
+			want:      "10.0.0.64/26",
+		},
+		{
+			name:      "other family is ignored",
+			parent:    "10.0.0.0/24",
+			prefixLen: 26,
+			used:      []string{"fd00::/8"},
+			want:      "10.0.0.0/26",
+		},
+		{
+			name:      "ipv6",
+			parent:    "fd00:10::/32",
+			prefixLen: 112,
+			used:      []string{"fd00:10::/112"},
+			want:      "fd00:10::1:0/112",
+		},
+		{
+			name:      "supernet exhausts the parent",
+			parent:    "10.96.0.0/12",
+			prefixLen: 20,
+			used:      []string{"10.0.0.0/8"},
+			wantErr:   ErrExhausted,
+		},
+		{
+			name:      "all subnets used",
+			parent:    "10.0.0.0/24",
+			prefixLen: 25,
+			used:      []string{"10.0.0.0/25", "10.0.0.128/25"},
+			wantErr:   ErrExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used := []*net.IPNet{}
+			for _, u := range tt.used {
+				used = append(used, cidr(t, u))
+			}
+
+			got, err := nextFree(cidr(t, tt.parent), tt.prefixLen, used)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("nextFree() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && got.String() != tt.want {
+				t.Errorf("nextFree() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    *models.IPPool
+		allocs  [][3]string
+		exclude []string
+		owner   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "first subnet",
+			pool:  models.NewIPPool("service-ipv4", "10.96.0.0/12", 20),
+			owner: "cluster/a",
+			want:  "10.96.0.0/20",
+		},
+		{
+			name:   "existing allocation of the owner is returned",
+			pool:   models.NewIPPool("service-ipv4", "10.96.0.0/12", 20),
+			allocs: [][3]string{{"service-ipv4", "10.96.0.0/20", "cluster/b"}, {"service-ipv4", "10.96.16.0/20", "cluster/a"}},
+			owner:  "cluster/a",
+			want:   "10.96.16.0/20",
+		},
+		{
+			name:   "allocations of the other pools are used",
+			pool:   models.NewIPPool("service-ipv4", "10.96.0.0/12", 20),
+			allocs: [][3]string{{"pod-ipv4", "10.96.0.0/19", "cluster/b"}},
+			owner:  "cluster/a",
+			want:   "10.96.32.0/20",
+		},
+		{
+			name:    "excluded subnets are skipped",
+			pool:    models.NewIPPool("service-ipv4", "10.96.0.0/12", 20),
+			exclude: []string{"10.96.0.0/20"},
+			owner:   "cluster/a",
+			want:    "10.96.16.0/20",
+		},
+		{
+			name:    "reserved supernet exhausts the pool",
+			pool:    models.NewIPPool("service-ipv4", "10.96.0.0/12", 20),
+			allocs:  [][3]string{{"reserved", "10.0.0.0/8", "user"}},
+			owner:   "cluster/a",
+			wantErr: ErrExhausted,
+		},
+		{
+			name:    "exhausted pool",
+			pool:    models.NewIPPool("loadbalancer-ipv4", "172.18.0.0/24", 25),
+			allocs:  [][3]string{{"loadbalancer-ipv4", "172.18.0.0/25", "cluster/b"}, {"loadbalancer-ipv4", "172.18.0.128/25", "cluster/c"}},
+			owner:   "cluster/a",
+			wantErr: ErrExhausted,
+		},
+		{
+			name:   "ipv6",
+			pool:   models.NewIPPool("service-ipv6", "fd00:10::/32", 112),
+			allocs: [][3]string{{"service-ipv6", "fd00:10::/112", "cluster/b"}},
+			owner:  "cluster/a",
+			want:   "fd00:10::1:0/112",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reset(t)
+			for _, alloc := range tt.allocs {
+				allocate(t, alloc[0], alloc[1], alloc[2])
+			}
+
+			exclude := []*net.IPNet{}
+			for _, e := range tt.exclude {
+				exclude = append(exclude, cidr(t, e))
+			}
+
+			got, err := Allocate(tt.pool, tt.owner, exclude...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Allocate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("Allocate() = %s, want %s", got, tt.want)
+			}
+
+			again, err := Allocate(tt.pool, tt.owner, exclude...)
+			if err != nil || again.String() != got.String() {
+				t.Errorf("second Allocate() = %v, %v, want %s", again, err, got)
+			}
+		})
+	}
+}
+
+func TestAllocateFrom(t *testing.T) {
+	reset(t)
+	allocate(t, PoolKindNetworkIPv4, "172.18.0.0/16", "vm/kindli")
+	allocate(t, PoolKindNetworkIPv4, "172.19.0.0/16", "vm/other")
+
+	pool := models.NewIPPool(PoolLoadBalancerIPv4, "172.18.0.0/16", 24)
+	nodes := cidr(t, "172.18.0.0/24")
+
+	got, err := AllocateFrom(pool, "cluster/a", cidr(t, "172.18.0.0/16"), nodes)
+	if err != nil {
+		t.Fatalf("AllocateFrom() error = %v", err)
+	}
+
+	if got.String() != "172.18.1.0/24" {
+		t.Errorf("AllocateFrom() = %s, want 172.18.1.0/24", got)
+	}
+
+	// The kind network of the VM is used unless the pool is carved from it
+	if _, err := Allocate(pool, "cluster/b"); !errors.Is(err, ErrExhausted) {
+		t.Errorf("Allocate() error = %v, want %v", err, ErrExhausted)
+	}
+}
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name    string
+		allocs  [][3]string
+		cidr    string
+		owner   string
+		wantErr bool
+	}{
+		{
+			name:  "free subnet",
+			cidr:  "10.0.0.0/24",
+			owner: "user",
+		},
+		{
+			name:   "same subnet of the same owner",
+			allocs: [][3]string{{"reserved", "10.0.0.0/24", "user"}},
+			cidr:   "10.0.0.0/24",
+			owner:  "user",
+		},
+		{
+			name:    "subnet of another owner",
+			allocs:  [][3]string{{"reserved", "10.0.0.0/24", "cluster/a"}},
+			cidr:    "10.0.0.0/24",
+			owner:   "user",
+			wantErr: true,
+		},
+		{
+			name:    "supernet of an allocation",
+			allocs:  [][3]string{{"service-ipv4", "10.96.0.0/20", "cluster/a"}},
+			cidr:    "10.0.0.0/8",
+			owner:   "user",
+			wantErr: true,
+		},
+		{
+			name:    "subnet of an allocation",
+			allocs:  [][3]string{{"pod-ipv4", "10.128.0.0/18", "cluster/a"}},
+			cidr:    "10.128.1.0/24",
+			owner:   "user",
+			wantErr: true,
+		},
+		{
+			name:    "different subnet of the same owner",
+			allocs:  [][3]string{{"reserved", "10.0.0.0/24", "user"}},
+			cidr:    "10.0.0.0/16",
+			owner:   "user",
+			wantErr: true,
+		},
+		{
+			name:    "invalid subnet",
+			cidr:    "10.0.0.0",
+			owner:   "user",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reset(t)
+			for _, alloc := range tt.allocs {
+				allocate(t, alloc[0], alloc[1], alloc[2])
+			}
+
+			if err := Reserve("reserved", tt.cidr, tt.owner); (err != nil) != tt.wantErr {
+				t.Fatalf("Reserve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
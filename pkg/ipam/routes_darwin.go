@@ -0,0 +1,78 @@
+package ipam
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+// hostRoutes returns the destinations of the IPv4 and IPv6 routes of the
+// host, default routes are omitted
+func hostRoutes() ([]*net.IPNet, error) {
+	routes := []*net.IPNet{}
+
+	for _, family := range []string{"inet", "inet6"} {
+		out, err := sh.NewCommand("netstat", "-rn", "-f", family).Query().Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list routes: %w", err)
+		}
+
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || fields[0] == "default" || fields[0] == "Destination" {
+				continue
+			}
+
+			// Routes to link level addresses are not networks
+			if strings.HasPrefix(fields[1], "link#") {
+				continue
+			}
+
+			if subnet := parseRoute(fields[0], family == "inet6"); subnet != nil {
+				routes = append(routes, subnet)
+			}
+		}
+	}
+
+	return routes, nil
+}
+
+// parseRoute parses the destination of a route as printed by netstat, IPv4
+// destinations may omit the trailing zero octets ("10/8", "192.168.1") and
+// IPv6 destinations may carry a zone ("fe80::%lo0/64")
+func parseRoute(dst string, ipv6 bool) *net.IPNet {
+	prefix := ""
+	if idx := strings.Index(dst, "/"); idx >= 0 {
+		dst, prefix = dst[:idx], dst[idx+1:]
+	}
+
+	if idx := strings.Index(dst, "%"); idx >= 0 {
+		dst = dst[:idx]
+	}
+
+	if ipv6 {
+		if prefix == "" {
+			prefix = "128"
+		}
+	} else {
+		octets := strings.Split(dst, ".")
+		if prefix == "" {
+			prefix = fmt.Sprint(len(octets) * 8)
+		}
+
+		for len(octets) < 4 {
+			octets = append(octets, "0")
+		}
+
+		dst = strings.Join(octets, ".")
+	}
+
+	_, subnet, err := net.ParseCIDR(dst + "/" + prefix)
+	if err != nil {
+		return nil
+	}
+
+	return subnet
+}
@@ -0,0 +1,52 @@
+package ipam
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+// hostRoutes returns the destinations of the IPv4 and IPv6 routes of the
+// host, default routes are omitted
+func hostRoutes() ([]*net.IPNet, error) {
+	routes := []*net.IPNet{}
+
+	for _, family := range []string{"-4", "-6"} {
+		out, err := sh.NewCommand("ip", family, "-o", "route", "show", "table", "main").Query().Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list routes: %w", err)
+		}
+
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || fields[0] == "default" {
+				continue
+			}
+
+			if subnet := parseRoute(fields[0], family == "-6"); subnet != nil {
+				routes = append(routes, subnet)
+			}
+		}
+	}
+
+	return routes, nil
+}
+
+func parseRoute(dst string, ipv6 bool) *net.IPNet {
+	if !strings.Contains(dst, "/") {
+		if ipv6 {
+			dst += "/128"
+		} else {
+			dst += "/32"
+		}
+	}
+
+	_, subnet, err := net.ParseCIDR(dst)
+	if err != nil {
+		return nil
+	}
+
+	return subnet
+}
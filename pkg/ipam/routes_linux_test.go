@@ -0,0 +1,35 @@
+package ipam
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+func TestAllocateHostRoutes(t *testing.T) {
+	routes := "10.0.0.0/8 dev tun0 scope link\n" +
+		"172.18.0.0/16 via 192.168.105.2 dev kindli0\n" +
+		"192.168.105.0/24 dev kindli0 proto kernel scope link src 192.168.105.1\n"
+
+	rec := reset(t)
+	rec.On("ip -4 -o route", sh.Response{Output: []byte(routes)})
+
+	// A VPN route covering the whole pool leaves no free subnet
+	service := models.NewIPPool(PoolServiceIPv4, "10.96.0.0/12", 20)
+	if _, err := Allocate(service, "cluster/a"); !errors.Is(err, ErrExhausted) {
+		t.Errorf("Allocate() error = %v, want %v", err, ErrExhausted)
+	}
+
+	// The route to the kind network holds the pool carved from it
+	lb := models.NewIPPool(PoolLoadBalancerIPv4, "172.18.0.0/16", 24)
+	got, err := AllocateFrom(lb, "cluster/a", cidr(t, "172.18.0.0/16"))
+	if err != nil {
+		t.Fatalf("AllocateFrom() error = %v", err)
+	}
+
+	if got.String() != "172.18.0.0/24" {
+		t.Errorf("AllocateFrom() = %s, want 172.18.0.0/24", got)
+	}
+}
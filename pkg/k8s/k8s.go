@@ -0,0 +1,333 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// FieldManager is the field manager of the resources applied by kindli
+const FieldManager = "kindli"
+
+// pollInterval is the interval at which the waits poll the cluster
+const pollInterval = 2 * time.Second
+
+// dryRunOut is where the actions are printed instead of being executed,
+// nil if dry run is disabled
+var dryRunOut io.Writer
+
+// SetDryRun makes the clients print the actions which change the cluster
+// to out instead of executing them, nil disables dry run
+func SetDryRun(out io.Writer) {
+	dryRunOut = out
+}
+
+// Client is a Kubernetes client bound to a kubeconfig context
+type Client struct {
+	context string
+
+	clientset kubernetes.Interface
+	dynamic   dynamic.Interface
+	mapper    *restmapper.DeferredDiscoveryRESTMapper
+}
+
+// New returns a client for the given context of the default kubeconfig
+//
+// In dry run the kubeconfig is not loaded as the context may not exist yet,
+// such a client only supports Apply and the waits.
+func New(kubeContext string) (*Client, error) {
+	if dryRunOut != nil {
+		return &Client{context: kubeContext}, nil
+	}
+
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig context \"%s\": %w", kubeContext, err)
+	}
+
+	return NewForConfig(kubeContext, cfg)
+}
+
+// NewForConfig returns a client for the given rest config
+func NewForConfig(kubeContext string, cfg *rest.Config) (*Client, error) {
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	disco, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	return &Client{
+		context:   kubeContext,
+		clientset: clientset,
+		dynamic:   dyn,
+		mapper:    restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(disco)),
+	}, nil
+}
+
+// Clientset returns the typed client
+func (c *Client) Clientset() kubernetes.Interface {
+	return c.clientset
+}
+
+// Apply server side applies every object of the multi document YAML or
+// JSON manifest, in order.
+//
+// Objects whose kind isn't known to the cluster yet, e.g. custom resources
+// whose CRDs were just applied, are retried until the timeout.
+func (c *Client) Apply(ctx context.Context, manifest []byte, timeout time.Duration) error {
+	objs, err := Decode(manifest)
+	if err != nil {
+		return err
+	}
+
+	return c.ApplyObjects(ctx, objs, timeout)
+}
+
+// ApplyObjects server side applies the objects in order, see Apply
+func (c *Client) ApplyObjects(ctx context.Context, objs []*unstructured.Unstructured, timeout time.Duration) error {
+	for _, obj := range objs {
+		if dryRunOut != nil {
+			fmt.Fprintf(dryRunOut, "kubectl --context %s apply --server-side -f - # %s %s\n", c.context, obj.GetKind(), objName(obj))
+			continue
+		}
+
+		err := wait.PollImmediate(pollInterval, timeout, func() (bool, error) {
+			if err := c.apply(ctx, obj); err != nil {
+				if meta.IsNoMatchError(err) {
+					logrus.Debugf("kind %s is not known to the cluster yet, retrying", obj.GroupVersionKind())
+					c.mapper.Reset()
+					return false, nil
+				}
+
+				return false, err
+			}
+
+			return true, nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), objName(obj), err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) apply(ctx context.Context, obj *unstructured.Unstructured) error {
+	resource, err := c.resource(obj)
+	if err != nil {
+		return err
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	force := true
+	_, err = resource.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	})
+
+	return err
+}
+
+// resource returns the dynamic client of the resource of the object
+func (c *Client) resource(obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return c.dynamic.Resource(mapping.Resource), nil
+	}
+
+	ns := obj.GetNamespace()
+	if ns == "" {
+		ns = corev1.NamespaceDefault
+	}
+
+	return c.dynamic.Resource(mapping.Resource).Namespace(ns), nil
+}
+
+// WaitForDeployment waits until the deployment is available
+func (c *Client) WaitForDeployment(ctx context.Context, namespace, name string, timeout time.Duration) error {
+	if dryRunOut != nil {
+		fmt.Fprintf(dryRunOut, "kubectl --context %s wait --for=condition=available --timeout=%s deployment -n %s %s\n", c.context, timeout, namespace, name)
+		return nil
+	}
+
+	err := wait.PollImmediate(pollInterval, timeout, func() (bool, error) {
+		deploy, err := c.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			logrus.Debugf("waiting for deployment %s/%s: %s", namespace, name, err)
+			return false, nil
+		}
+
+		for _, cond := range deploy.Status.Conditions {
+			if cond.Type == appsv1.DeploymentAvailable && cond.Status == corev1.ConditionTrue {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return fmt.Errorf("deployment %s/%s is not available after %s", namespace, name, timeout)
+	}
+
+	return err
+}
+
+// WaitForAPIServer waits until the API server reports itself ready
+func (c *Client) WaitForAPIServer(ctx context.Context, timeout time.Duration) error {
+	if dryRunOut != nil {
+		fmt.Fprintf(dryRunOut, "kubectl --context %s get --raw /readyz\n", c.context)
+		return nil
+	}
+
+	err := wait.PollImmediate(pollInterval, timeout, func() (bool, error) {
+		_, err := c.clientset.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx)
+		if err != nil {
+			logrus.Debugf("waiting for API server: %s", err)
+			return false, nil
+		}
+
+		return true, nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return fmt.Errorf("API server of %s is not ready after %s", c.context, timeout)
+	}
+
+	return err
+}
+
+// WaitForDaemonSet waits until every scheduled pod of the daemonset is
+// updated and ready
+func (c *Client) WaitForDaemonSet(ctx context.Context, namespace, name string, timeout time.Duration) error {
+	if dryRunOut != nil {
+		fmt.Fprintf(dryRunOut, "kubectl --context %s rollout status --timeout=%s daemonset -n %s %s\n", c.context, timeout, namespace, name)
+		return nil
+	}
+
+	err := wait.PollImmediate(pollInterval, timeout, func() (bool, error) {
+		ds, err := c.clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			logrus.Debugf("waiting for daemonset %s/%s: %s", namespace, name, err)
+			return false, nil
+		}
+
+		return ds.Status.ObservedGeneration >= ds.Generation &&
+			ds.Status.DesiredNumberScheduled > 0 &&
+			ds.Status.UpdatedNumberScheduled == ds.Status.DesiredNumberScheduled &&
+			ds.Status.NumberReady == ds.Status.DesiredNumberScheduled, nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		return fmt.Errorf("daemonset %s/%s is not ready after %s", namespace, name, timeout)
+	}
+
+	return err
+}
+
+// Delete deletes every object of the manifest in the reverse order,
+// objects which don't exist are ignored
+func (c *Client) Delete(ctx context.Context, manifest []byte) error {
+	objs, err := Decode(manifest)
+	if err != nil {
+		return err
+	}
+
+	for i := len(objs) - 1; i >= 0; i-- {
+		obj := objs[i]
+		if dryRunOut != nil {
+			fmt.Fprintf(dryRunOut, "kubectl --context %s delete --ignore-not-found %s %s\n", c.context, obj.GetKind(), objName(obj))
+			continue
+		}
+
+		resource, err := c.resource(obj)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+
+			return fmt.Errorf("failed to delete %s %s: %w", obj.GetKind(), objName(obj), err)
+		}
+
+		propagation := metav1.DeletePropagationBackground
+		err = resource.Delete(ctx, obj.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s %s: %w", obj.GetKind(), objName(obj), err)
+		}
+	}
+
+	return nil
+}
+
+// Decode decodes the multi document YAML or JSON manifest into objects,
+// empty documents are skipped
+func Decode(manifest []byte) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096)
+
+	objs := []*unstructured.Unstructured{}
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("failed to decode manifest: %w", err)
+		}
+
+		if len(obj.Object) == 0 {
+			continue
+		}
+
+		objs = append(objs, obj)
+	}
+
+	return objs, nil
+}
+
+func objName(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
@@ -0,0 +1,56 @@
+package kind
+
+import (
+	"fmt"
+
+	"github.com/utkarsh-pro/kindli/pkg/addon"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+// prepareAddons validates the add-ons requested for the cluster and disables
+// the default CNI of kind if any of them is a CNI
+func prepareAddons(cfg *v1alpha4.Cluster, names []string) error {
+	cnis := []string{}
+	for _, name := range names {
+		a, err := addon.Get(name)
+		if err != nil {
+			return err
+		}
+
+		if a.CNI {
+			cnis = append(cnis, name)
+		}
+	}
+
+	if len(cnis) > 1 {
+		return fmt.Errorf("only one CNI addon can be enabled, got %v", cnis)
+	}
+
+	if len(cnis) == 1 {
+		cfg.Networking.DisableDefaultCNI = true
+	}
+
+	return nil
+}
+
+// enableAddons enables the requested add-ons whose CNI field matches cni,
+// CNI add-ons are enabled before anything else as the nodes are not ready
+// without them
+func enableAddons(clusterName string, names []string, cni bool) error {
+	for _, name := range names {
+		a, err := addon.Get(name)
+		if err != nil {
+			return err
+		}
+
+		if a.CNI != cni {
+			continue
+		}
+
+		if err := addon.Enable(clusterName, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
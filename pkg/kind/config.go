@@ -0,0 +1,100 @@
+package kind
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+const (
+	configKind       = "Cluster"
+	configAPIVersion = "kind.x-k8s.io/v1alpha4"
+)
+
+// newConfig returns an empty kind config
+func newConfig() *v1alpha4.Cluster {
+	return &v1alpha4.Cluster{
+		TypeMeta: v1alpha4.TypeMeta{
+			Kind:       configKind,
+			APIVersion: configAPIVersion,
+		},
+	}
+}
+
+// loadUserKindConfig parses the kind config at path, an empty config is
+// returned if the path is empty.
+//
+// Unknown fields are rejected so that typos don't silently get dropped
+// from the config passed to kind.
+func loadUserKindConfig(path string) (*v1alpha4.Cluster, error) {
+	cfg := newConfig()
+	if path == "" {
+		return cfg, nil
+	}
+
+	byt, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.UnmarshalStrict(byt, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse kind config %s: %w", path, err)
+	}
+
+	if err := validateConfig(cfg, path); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// inlineKindConfig validates the kind config given in place of a file,
+// missing kind and apiVersion are defaulted
+func inlineKindConfig(cfg *v1alpha4.Cluster) (*v1alpha4.Cluster, error) {
+	if cfg.Kind == "" {
+		cfg.Kind = configKind
+	}
+
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = configAPIVersion
+	}
+
+	if err := validateConfig(cfg, "(inline)"); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func validateConfig(cfg *v1alpha4.Cluster, source string) error {
+	if cfg.Kind != configKind {
+		return fmt.Errorf("unsupported kind \"%s\" in kind config %s, expected \"%s\"", cfg.Kind, source, configKind)
+	}
+
+	if cfg.APIVersion != configAPIVersion {
+		return fmt.Errorf("unsupported apiVersion \"%s\" in kind config %s, expected \"%s\"", cfg.APIVersion, source, configAPIVersion)
+	}
+
+	return nil
+}
+
+// persistAlteredConfig writes the kind config of the cluster to the
+// instances directory and returns the path to it
+func persistAlteredConfig(name string, cfg *v1alpha4.Cluster) (string, error) {
+	path := filepath.Join(instanceDirPath, fmt.Sprintf("%s.yaml", name))
+	file, err := os.Create(path)
+	if err != nil {
+		return path, fmt.Errorf("failed to create config file: %s", err)
+	}
+	defer file.Close()
+
+	return path, writeConfig(file, cfg)
+}
+
+func writeConfig(w io.Writer, cfg *v1alpha4.Cluster) error {
+	return yaml.NewEncoder(w).Encode(cfg)
+}
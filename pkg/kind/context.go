@@ -0,0 +1,112 @@
+package kind
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/docker"
+	"github.com/utkarsh-pro/kindli/pkg/kubeconfig"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// EnvVar is an environment variable pointing the tools at a cluster
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+// Resolve returns the cluster with the given name, the name is either the
+// full name of the cluster or its name in the VM
+func Resolve(name, vmName string) (*models.Cluster, error) {
+	for _, candidate := range []string{name, utils.CreateClusterName(name, vmName)} {
+		c := models.NewCluster(candidate, "", "")
+		if err := c.GetByName(); err == nil {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cluster \"%s\" does not exist in VM %s", name, vmName)
+}
+
+// Use makes the docker context of the VM of the cluster and the kube
+// context of the cluster the defaults
+//
+// The kube context is merged into the kubeconfig if it is missing. The
+// docker context is switched back if the kube context cannot be switched.
+func Use(name string) error {
+	c := models.NewCluster(name, "", "")
+	if err := c.GetByName(); err != nil {
+		return fmt.Errorf("instance with name \"%s\" does not exists", name)
+	}
+
+	previous, err := docker.CurrentContext()
+	if err != nil {
+		return fmt.Errorf("failed to get current docker context: %w", err)
+	}
+
+	if err := vm.UseDockerContext(c.VM); err != nil {
+		return fmt.Errorf("failed to switch docker context: %w", err)
+	}
+
+	if err := useKubeContext(c); err != nil {
+		if rerr := docker.UseContext(previous); rerr != nil {
+			logrus.Warnf("failed to switch docker context back to %s: %s", previous, rerr)
+		}
+
+		return fmt.Errorf("failed to switch kube context: %w", err)
+	}
+
+	logrus.Infof("✅ Using docker context %s and kube context %s", c.VM, kubeconfig.ContextName(name))
+	return nil
+}
+
+// Env returns the environment variables which point docker and kubectl at
+// the cluster, the standalone kubeconfig of the cluster is exported if it
+// is missing
+func Env(name string) ([]EnvVar, error) {
+	c := models.NewCluster(name, "", "")
+	if err := c.GetByName(); err != nil {
+		return nil, fmt.Errorf("instance with name \"%s\" does not exists", name)
+	}
+
+	dockerHost, err := vm.DockerHost(c.VM)
+	if err != nil {
+		return nil, err
+	}
+
+	path := kubeconfig.Path(name)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if path, err = kubeconfig.Export(name, c.VM); err != nil {
+			return nil, err
+		}
+	}
+
+	return []EnvVar{
+		{Name: "DOCKER_CONTEXT", Value: c.VM},
+		{Name: "DOCKER_HOST", Value: dockerHost},
+		{Name: "KUBECONFIG", Value: path},
+	}, nil
+}
+
+// useKubeContext makes the context of the cluster the current context
+func useKubeContext(c *models.Cluster) error {
+	cfg, err := clientcmd.NewDefaultPathOptions().GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	if _, ok := cfg.Contexts[kubeconfig.ContextName(c.Name)]; ok {
+		return kubeconfig.SetCurrentContext(kubeconfig.ContextName(c.Name))
+	}
+
+	if _, err := kubeconfig.Export(c.Name, c.VM); err != nil {
+		return err
+	}
+
+	return kubeconfig.Merge(c.Name, true)
+}
@@ -0,0 +1,187 @@
+package kind
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/utkarsh-pro/kindli/pkg/metallb"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+const (
+	unknown = "UNKNOWN"
+
+	// StatusRunning means that the kind cluster exists in the VM and its
+	// nodes are running
+	StatusRunning = models.ClusterRunning
+	// StatusStopped means that the nodes of the kind cluster are stopped
+	StatusStopped = models.ClusterStopped
+	// StatusPaused means that the nodes of the kind cluster are paused
+	StatusPaused = models.ClusterPaused
+	// StatusMissing means that the kind cluster does not exist in the VM
+	StatusMissing = "Missing"
+	// StatusVMStopped means that the VM of the cluster is not running
+	StatusVMStopped = "VMStopped"
+)
+
+// ClusterInfo is the information of a kindli cluster
+type ClusterInfo struct {
+	Name             string `json:"name" yaml:"name"`
+	VM               string `json:"vm" yaml:"vm"`
+	ServiceSubnet    string `json:"serviceSubnet" yaml:"serviceSubnet"`
+	PodSubnet        string `json:"podSubnet" yaml:"podSubnet"`
+	IPFamily         string `json:"ipFamily" yaml:"ipFamily"`
+	LoadBalancerIPv4 string `json:"loadBalancerIPv4" yaml:"loadBalancerIPv4"`
+	LoadBalancerIPv6 string `json:"loadBalancerIPv6" yaml:"loadBalancerIPv6"`
+	FIPS             string `json:"fips" yaml:"fips"`
+	Status           string `json:"status" yaml:"status"`
+	KindConfigPath   string `json:"kindConfigPath" yaml:"kindConfigPath"`
+}
+
+// ClusterInfoList is a list of ClusterInfo
+type ClusterInfoList []ClusterInfo
+
+func (l ClusterInfoList) Header(wide bool) []string {
+	header := []string{"NAME", "VMNAME", "STATUS", "SERVICES SUBNET", "PODS SUBNET", "IP FAMILY", "LOADBALANCER(IPv4)", "LOADBALANCER(IPv6)", "FIPS"}
+	if wide {
+		header = append(header, "KIND CONFIG")
+	}
+
+	return header
+}
+
+func (l ClusterInfoList) Rows(wide bool) [][]string {
+	rows := [][]string{}
+	for _, c := range l {
+		row := []string{c.Name, c.VM, c.Status, c.ServiceSubnet, c.PodSubnet, c.IPFamily, c.LoadBalancerIPv4, c.LoadBalancerIPv6, c.FIPS}
+		if wide {
+			row = append(row, c.KindConfigPath)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func newClusterInfo(c models.Cluster) ClusterInfo {
+	info := ClusterInfo{
+		Name:             c.Name,
+		VM:               c.VM,
+		ServiceSubnet:    unknown,
+		PodSubnet:        unknown,
+		IPFamily:         unknown,
+		LoadBalancerIPv4: unknown,
+		LoadBalancerIPv6: unknown,
+		FIPS:             unknown,
+		Status:           unknown,
+		KindConfigPath:   c.KindConfigPath,
+	}
+
+	cfg, err := loadUserKindConfig(c.KindConfigPath)
+	if err != nil {
+		return info
+	}
+
+	info.ServiceSubnet = cfg.Networking.ServiceSubnet
+	info.PodSubnet = cfg.Networking.PodSubnet
+	info.IPFamily = string(cfg.Networking.IPFamily)
+	if info.IPFamily == "" {
+		info.IPFamily = string(v1alpha4.IPv4Family)
+	}
+
+	mcfg, err := metallb.LoadConfigFromDisk(c.Name)
+	if err != nil {
+		return info
+	}
+
+	info.LoadBalancerIPv4, info.LoadBalancerIPv6 = "", ""
+	addresses, _ := utils.MapGet(mcfg, "spec", "addresses")
+	list, _ := addresses.([]interface{})
+	for _, addr := range list {
+		str, _ := addr.(string)
+		if strings.Contains(str, ":") {
+			info.LoadBalancerIPv6 = str
+		} else {
+			info.LoadBalancerIPv4 = str
+		}
+	}
+
+	fipsStatus, _ := vm.FipsCheck(c.VM)
+	if fipsStatus {
+		info.FIPS = "ENABLED"
+	} else {
+		info.FIPS = "DISABLED"
+	}
+
+	return info
+}
+
+// runningClusters returns the set of kind clusters in the VM, nil is
+// returned if the VM is not running
+func runningClusters(vmName string) map[string]bool {
+	running, err := vm.Running(vmName)
+	if err != nil || !running {
+		return nil
+	}
+
+	clusters, err := Clusters(vmName)
+	if err != nil {
+		return map[string]bool{}
+	}
+
+	set := map[string]bool{}
+	for _, c := range clusters {
+		set[c] = true
+	}
+
+	return set
+}
+
+func clusterStatus(running map[string]bool, c models.Cluster) string {
+	if running == nil {
+		return StatusVMStopped
+	}
+
+	if !running[c.Name] {
+		return StatusMissing
+	}
+
+	if c.Status == "" {
+		return StatusRunning
+	}
+
+	return c.Status
+}
+
+// RunningClusters returns the clusters in the given VM whose nodes are
+// running, if vmName is empty then the running clusters of all the VMs are
+// returned
+func RunningClusters(vmName string) ([]models.Cluster, error) {
+	clusters, err := models.ListCluster()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	running := []models.Cluster{}
+	statuses := map[string]map[string]bool{}
+
+	for _, c := range clusters {
+		if c.VM != vmName && vmName != "" {
+			continue
+		}
+
+		if _, ok := statuses[c.VM]; !ok {
+			statuses[c.VM] = runningClusters(c.VM)
+		}
+
+		if clusterStatus(statuses[c.VM], c) == StatusRunning {
+			running = append(running, c)
+		}
+	}
+
+	return running, nil
+}
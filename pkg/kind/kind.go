@@ -1,37 +1,54 @@
 package kind
 
 import (
-	"bytes"
 	_ "embed"
+	"errors"
 	"fmt"
-	"html/template"
+	"io"
 	"os"
 	"path/filepath"
-	"text/tabwriter"
+	"regexp"
+	"strings"
 
 	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/addon"
 	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/ipam"
 	"github.com/utkarsh-pro/kindli/pkg/kubeconfig"
 	"github.com/utkarsh-pro/kindli/pkg/metallb"
 	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/registry"
 	"github.com/utkarsh-pro/kindli/pkg/sh"
 	"github.com/utkarsh-pro/kindli/pkg/utils"
 	"github.com/utkarsh-pro/kindli/pkg/vm"
-	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
 )
 
 var (
 	instancesDirName = "kind"
 	instanceDirPath  = ""
-
-	//go:embed kind.template
-	kindTemplate string
 )
 
 type CreateConfig struct {
 	Name        string
 	VMName      string
 	SkipMetalLB bool
+	// MetalLBVersion is the version of metallb to install, empty for the
+	// default version
+	MetalLBVersion string
+	// Addons are the add-ons to enable once the cluster is created
+	Addons []string
+	// KindConfig is used instead of the kind config file when set
+	KindConfig *v1alpha4.Cluster
+}
+
+// userKindConfig returns the kind config the cluster is created from
+func (cfg CreateConfig) userKindConfig(path string) (*v1alpha4.Cluster, error) {
+	if cfg.KindConfig != nil {
+		return inlineKindConfig(cfg.KindConfig)
+	}
+
+	return loadUserKindConfig(path)
 }
 
 func init() {
@@ -43,14 +60,20 @@ func init() {
 // a new kind instance in the VM based on the config file passed
 func Create(cfgPath string, cfg CreateConfig) error {
 	// Load user's kind config
-	userKindCfg, err := loadUserKindConfig(cfgPath)
+	userKindCfg, err := cfg.userKindConfig(cfgPath)
 	if err != nil {
 		return fmt.Errorf("failed to read user config: %s", err)
 	}
 
-	// Get the name of the user's kind config => Also a sanity test for the config
-	name, err := getSetUserKindCfgName(userKindCfg, cfg.Name)
-	if err != nil {
+	// Name of the cluster is always decided by kindli
+	name := cfg.Name
+	userKindCfg.Name = name
+
+	if err := prepareAddons(userKindCfg, cfg.Addons); err != nil {
+		return err
+	}
+
+	if err := registry.Patch(userKindCfg, cfg.VMName); err != nil {
 		return err
 	}
 
@@ -62,11 +85,11 @@ func Create(cfgPath string, cfg CreateConfig) error {
 			return fmt.Errorf("failed to set kubeconfig context: %w", err)
 		}
 
-		if err := metallb.Install(name); err != nil {
+		if err := metallb.Install(name, cfg.MetalLBVersion); err != nil {
 			return fmt.Errorf("failed to create metallb config for the kind cluster: %w", err)
 		}
 
-		return nil
+		return enableAddons(name, cfg.Addons, false)
 	}
 
 	// Create kind cluster
@@ -74,14 +97,80 @@ func Create(cfgPath string, cfg CreateConfig) error {
 		return fmt.Errorf("failed to create kind cluster: %s", err)
 	}
 
+	if err := registry.Wire(name, cfg.VMName); err != nil {
+		return fmt.Errorf("failed to wire the registries into the kind cluster: %w", err)
+	}
+
+	if path, err := kubeconfig.Export(name, cfg.VMName); err != nil {
+		logrus.Warnf("failed to export kubeconfig of the cluster: %s", err)
+	} else {
+		logrus.Infof("Kubeconfig of the cluster written to %s", path)
+	}
+
+	// The nodes are not ready until the CNI is installed
+	if err := enableAddons(name, cfg.Addons, true); err != nil {
+		return err
+	}
+
 	// Create metallb for the kind cluster
 	if !cfg.SkipMetalLB {
-		if err := metallb.Install(name); err != nil {
+		if err := metallb.Install(name, cfg.MetalLBVersion); err != nil {
 			return fmt.Errorf("failed to create metallb config for the kind cluster: %w", err)
 		}
 	}
 
-	return nil
+	return enableAddons(name, cfg.Addons, false)
+}
+
+// PrintConfig writes the kind config that Create would pass to kind to w
+//
+// For an existing cluster the persisted config is written. Otherwise the
+// subnets of the cluster are allocated only for the duration of the call,
+// a later Create may pick different subnets if other clusters are created
+// in the meantime.
+func PrintConfig(cfgPath string, cfg CreateConfig, w io.Writer) error {
+	if Exists(cfg.Name, cfg.VMName) {
+		c := models.NewCluster(cfg.Name, "", cfg.VMName)
+		if err := c.GetByName(); err != nil {
+			return fmt.Errorf("failed to find cluster with name \"%s\": %w", cfg.Name, err)
+		}
+
+		byt, err := c.LoadConfigFromDisk()
+		if err != nil {
+			return fmt.Errorf("failed to read kind config: %w", err)
+		}
+
+		_, err = w.Write(byt)
+		return err
+	}
+
+	userKindCfg, err := cfg.userKindConfig(cfgPath)
+	if err != nil {
+		return fmt.Errorf("failed to read user config: %s", err)
+	}
+
+	userKindCfg.Name = cfg.Name
+
+	if err := prepareAddons(userKindCfg, cfg.Addons); err != nil {
+		return err
+	}
+
+	if err := registry.Patch(userKindCfg, cfg.VMName); err != nil {
+		return err
+	}
+
+	cluster := models.NewCluster(cfg.Name, "", cfg.VMName)
+	defer func() {
+		if err := ipam.Release(cluster.IPOwner()); err != nil {
+			logrus.Warn("failed to release cluster networks: ", err)
+		}
+	}()
+
+	if err := createNetworking(cluster, userKindCfg); err != nil {
+		return fmt.Errorf("failed to setup cluster networks: %w", err)
+	}
+
+	return writeConfig(w, userKindCfg)
 }
 
 func Delete(name string) error {
@@ -90,11 +179,38 @@ func Delete(name string) error {
 		return fmt.Errorf("instance with name \"%s\" does not exists", name)
 	}
 
-	if err := sh.Run(fmt.Sprintf("kind delete cluster --name=%s", c.Name)); err != nil {
+	if err := sh.NewCommand("kind", "delete", "cluster", "--name", c.Name).WithEnv("DOCKER_CONTEXT", c.VM).Run(); err != nil {
 		return fmt.Errorf("failed to delete kind instance: %s", err)
 	}
 
-	if err := os.Remove(c.KindConfigPath); err != nil {
+	return Forget(c.Name)
+}
+
+// Forget removes the cluster from the kindli store along with its
+// persisted configs, the kind cluster itself is left untouched
+func Forget(name string) error {
+	c := models.NewCluster(name, "", "")
+	if err := c.GetByName(); err != nil {
+		return fmt.Errorf("instance with name \"%s\" does not exists", name)
+	}
+
+	if err := os.Remove(c.KindConfigPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete instance: %w", err)
+	}
+
+	if err := metallb.RemoveConfigFromDisk(c.Name); err != nil {
+		return fmt.Errorf("failed to delete instance: %w", err)
+	}
+
+	if err := ipam.Release(c.IPOwner()); err != nil {
+		return fmt.Errorf("failed to delete instance: %w", err)
+	}
+
+	if err := addon.Forget(c.Name); err != nil {
+		return fmt.Errorf("failed to delete instance: %w", err)
+	}
+
+	if err := kubeconfig.Remove(c.Name); err != nil {
 		return fmt.Errorf("failed to delete instance: %w", err)
 	}
 
@@ -105,94 +221,119 @@ func Delete(name string) error {
 	return nil
 }
 
-func Exists(name, vmName string) bool {
-	ok, err := models.NewCluster(name, "", vmName).Exists()
+// Clusters returns the names of the kind clusters running in the given VM
+func Clusters(vmName string) ([]string, error) {
+	out, err := sh.NewCommand("kind", "get", "clusters").
+		WithEnv("DOCKER_CONTEXT", vmName).
+		Query().
+		Output()
 	if err != nil {
-		logrus.Warn("failed to find instance in store: %w", err)
-		return false
+		return nil, fmt.Errorf("failed to get kind clusters: %w", err)
 	}
 
-	return ok
+	clusters := []string{}
+	for _, name := range strings.Split(string(out), "\n") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			clusters = append(clusters, name)
+		}
+	}
+
+	return clusters, nil
 }
 
-func List(vmName string) error {
-	clusters, err := models.ListCluster()
+// Adopt adds a kind cluster running in the given VM to the kindli store.
+//
+// The networking of the cluster is recovered from the kubeadm config of the
+// control plane node of the cluster.
+func Adopt(name, vmName string) error {
+	out, err := sh.NewCommand("docker", "exec", name+"-control-plane", "cat", "/kind/kubeadm.conf").
+		WithEnv("DOCKER_CONTEXT", vmName).
+		Query().
+		Output()
 	if err != nil {
-		return fmt.Errorf("failed to list clusters: %w", err)
+		return fmt.Errorf("failed to read kubeadm config of cluster %s: %w", name, err)
 	}
 
-	if len(clusters) == 0 {
-		logrus.Warn("No clusters found - create a cluster with `kindli create`")
-		return nil
-	}
+	cfg := newConfig()
+	cfg.Name = name
 
-	w := tabwriter.NewWriter(os.Stdout, 4, 8, 4, ' ', 0)
-	fmt.Fprintln(w, "NAME\tVMNAME\tSERVICES SUBNET\tPODS SUBNET\tIP FAMILY\tLOADBALANCER(IPv4)\tFIPS")
+	cluster := models.NewCluster(name, "", vmName)
 
-	for _, c := range clusters {
-		if c.VM == vmName || vmName == "" {
-			svcSubnet := "UNKNOWN"
-			podSubnet := "UNKNOWN"
-			ipFamily := "UNKNOWN"
-			lbIPv4 := "UNKNOWN"
-			fips := "UNKNOWN"
-
-			cfg, err := c.LoadConfigAsYAMLFromDisk()
-			if err != nil {
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.Name, c.VM, svcSubnet, podSubnet, ipFamily, lbIPv4, fips)
-				continue
-			}
+	for key, pools := range subnetPools {
+		match := regexp.MustCompile(key + `: "?([^"\s]+)"?`).FindSubmatch(out)
+		if match == nil {
+			continue
+		}
 
-			svcSubnetUncasted, ok := utils.MapGet(cfg, "networking", "serviceSubnet")
-			if ok {
-				svcSubnetCasted, ok := svcSubnetUncasted.(string)
-				if ok {
-					svcSubnet = svcSubnetCasted
-				}
-			}
+		*subnetField(cfg, key) = string(match[1])
 
-			podSubnetUncasted, ok := utils.MapGet(cfg, "networking", "podSubnet")
-			if ok {
-				podSubnetCasted, ok := podSubnetUncasted.(string)
-				if ok {
-					podSubnet = podSubnetCasted
-				}
+		for _, subnet := range strings.Split(string(match[1]), ",") {
+			pool := pools[0]
+			if strings.Contains(subnet, ":") {
+				pool = pools[1]
 			}
 
-			ipFamilyUncasted, ok := utils.MapGet(cfg, "networking", "ipFamily")
-			if ok {
-				ipFamilyCasted, ok := ipFamilyUncasted.(string)
-				if ok {
-					ipFamily = ipFamilyCasted
-				}
+			if err := ipam.Reserve(pool, subnet, cluster.IPOwner()); err != nil {
+				logrus.Warnf("failed to record %s of cluster %s: %s", key, name, err)
 			}
+		}
+	}
 
-			mcfg, err := metallb.LoadConfigFromDisk(c.Name)
-			if err != nil {
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", c.Name, c.VM, svcSubnet, podSubnet, ipFamily, lbIPv4)
-				continue
-			}
+	cluster.KindConfigPath, err = persistAlteredConfig(name, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to persist kind config locally: %w", err)
+	}
 
-			lbIPv4Uncasted, ok := utils.MapGet(mcfg, "spec", "addresses", "0")
-			if ok {
-				lbIPv4Casted, ok := lbIPv4Uncasted.(string)
-				if ok {
-					lbIPv4 = lbIPv4Casted
-				}
-			}
+	if err := cluster.Save(); err != nil {
+		return fmt.Errorf("failed to save cluster: %w", err)
+	}
 
-			fipsStatus, _ := vm.FipsCheck(c.VM)
-			if fipsStatus {
-				fips = "ENABLED"
-			} else {
-				fips = "DISABLED"
-			}
+	return nil
+}
+
+// ConfigDir returns the directory where the kind configs are persisted
+func ConfigDir() string {
+	return instanceDirPath
+}
+
+func Exists(name, vmName string) bool {
+	ok, err := models.NewCluster(name, "", vmName).Exists()
+	if err != nil {
+		logrus.Warn("failed to find instance in store: %w", err)
+		return false
+	}
+
+	return ok
+}
+
+// List returns the information of the clusters in the given VM, if vmName
+// is empty then the clusters of all the VMs are returned
+func List(vmName string) (ClusterInfoList, error) {
+	clusters, err := models.ListCluster()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	infos := ClusterInfoList{}
+	statuses := map[string]map[string]bool{}
 
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.Name, c.VM, svcSubnet, podSubnet, ipFamily, lbIPv4, fips)
+	for _, c := range clusters {
+		if c.VM != vmName && vmName != "" {
+			continue
+		}
+
+		if _, ok := statuses[c.VM]; !ok {
+			statuses[c.VM] = runningClusters(c.VM)
 		}
+
+		info := newClusterInfo(c)
+		info.Status = clusterStatus(statuses[c.VM], c)
+
+		infos = append(infos, info)
 	}
 
-	return w.Flush()
+	return infos, nil
 }
 
 func PureList(vmName string) ([]string, error) {
@@ -211,30 +352,42 @@ func PureList(vmName string) ([]string, error) {
 	return names, nil
 }
 
-func createKindCluster(name, vmName string, userKindCfg map[string]interface{}) error {
+func createKindCluster(name, vmName string, userKindCfg *v1alpha4.Cluster) error {
+	// The kind network of the VM must be distinct from the ones of the
+	// other VMs, kind would create it with the same subnet in every VM
+	if err := vm.EnsureKindNetwork(vmName); err != nil {
+		return err
+	}
+
 	// Save the new instance in the store
 	cluster := models.NewCluster(name, "", vmName)
-	if err := cluster.AssignID(); err != nil {
-		return fmt.Errorf("failed to assign ID to cluster: %w", err)
+
+	if err := createAllocatedKindCluster(cluster, userKindCfg); err != nil {
+		if rerr := ipam.Release(cluster.IPOwner()); rerr != nil {
+			logrus.Warn("failed to release cluster networks: ", rerr)
+		}
+
+		return err
 	}
 
-	// Setup networking info
-	createNetworking(int(cluster.ID), userKindCfg)
+	return nil
+}
 
-	// Custom Kind Config
-	customConfig, err := createCustomKindConfig(userKindCfg)
-	if err != nil {
-		return fmt.Errorf("failed to create kind config with overrides: %w", err)
+func createAllocatedKindCluster(cluster *models.Cluster, userKindCfg *v1alpha4.Cluster) error {
+	// Setup networking info
+	if err := createNetworking(cluster, userKindCfg); err != nil {
+		return fmt.Errorf("failed to setup cluster networks: %w", err)
 	}
 
 	// Persist the altered user config
-	cluster.KindConfigPath, err = persistAlteredConfig(name, customConfig)
+	var err error
+	cluster.KindConfigPath, err = persistAlteredConfig(cluster.Name, userKindCfg)
 	if err != nil {
 		return fmt.Errorf("failed to persist kind config locally: %w", err)
 	}
 
 	// Create kind cluster
-	if err := sh.Run(fmt.Sprintf("kind create cluster --config %s", cluster.KindConfigPath)); err != nil {
+	if err := sh.NewCommand("kind", "create", "cluster", "--config", cluster.KindConfigPath).WithEnv("DOCKER_CONTEXT", cluster.VM).Run(); err != nil {
 		return fmt.Errorf("failed to create kind cluster: %w", err)
 	}
 	if err := cluster.Save(); err != nil {
@@ -244,72 +397,6 @@ func createKindCluster(name, vmName string, userKindCfg map[string]interface{})
 	return nil
 }
 
-func getSetUserKindCfgName(userKindCfg map[string]interface{}, customName string) (string, error) {
-	userKindCfg["name"] = customName
-	return userKindCfg["name"].(string), nil
-}
-
-func persistAlteredConfig(name string, userKindCfg map[string]interface{}) (string, error) {
-	path := filepath.Join(instanceDirPath, fmt.Sprintf("%s.yaml", name))
-	file, err := os.Create(path)
-	if err != nil {
-		return path, fmt.Errorf("failed to create config file: %s", err)
-	}
-
-	return path, yaml.NewEncoder(file).Encode(userKindCfg)
-}
-
-func createNetworking(instance int, userKindCfg map[string]interface{}) {
-	svcSubnet := fmt.Sprintf("10.%d.0.0/16", instance)
-	podSubnet := fmt.Sprintf("10.1%02d.0.0/16", instance)
-
-	utils.MapSet(userKindCfg, svcSubnet, "networking", "serviceSubnet")
-	utils.MapSet(userKindCfg, podSubnet, "networking", "podSubnet")
-}
-
-func loadUserKindConfig(path string) (map[string]interface{}, error) {
-	mp := make(map[string]interface{})
-
-	if path != "" {
-		byt, err := os.ReadFile(path)
-		if err != nil {
-			return mp, err
-		}
-
-		mp, err = utils.MapFromYAML(byt)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return mp, nil
-}
-
-func createCustomKindConfig(userKindCfg map[string]interface{}) (map[string]interface{}, error) {
-	parsed, err := template.New("kind.template").Parse(kindTemplate)
-	if err != nil {
-		return nil, err
-	}
-
-	// Convert "nodes" field to YAML
-	nodes, ok := userKindCfg["nodes"]
-	if ok {
-		nodeByt, err := yaml.Marshal(nodes)
-		if err != nil {
-			return nil, err
-		}
-
-		userKindCfg["nodes"] = string(nodeByt)
-	}
-
-	buf := &bytes.Buffer{}
-	if err := parsed.Execute(buf, userKindCfg); err != nil {
-		return nil, err
-	}
-
-	return utils.MapFromYAML(buf.Bytes())
-}
-
 func kindifyClusterName(name string) string {
 	return "kind-" + name
 }
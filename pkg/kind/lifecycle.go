@@ -0,0 +1,156 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/k8s"
+	"github.com/utkarsh-pro/kindli/pkg/kubeconfig"
+	"github.com/utkarsh-pro/kindli/pkg/metallb"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+// startTimeout is the time given to the API server of a started cluster to
+// become ready
+const startTimeout = 5 * time.Minute
+
+// Nodes returns the names of the node containers of the kind cluster in
+// the given VM
+func Nodes(name, vmName string) ([]string, error) {
+	out, err := sh.NewCommand("kind", "get", "nodes", "--name", name).
+		WithEnv("DOCKER_CONTEXT", vmName).
+		Query().
+		Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes of kind cluster: %w", err)
+	}
+
+	nodes := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "No kind nodes") {
+			continue
+		}
+
+		nodes = append(nodes, line)
+	}
+
+	return nodes, nil
+}
+
+// Stop stops the node containers of the cluster, or pauses them if pause is
+// true, the cluster keeps its subnets and configs
+func Stop(name string, pause bool) error {
+	c := models.NewCluster(name, "", "")
+	if err := c.GetByName(); err != nil {
+		return fmt.Errorf("instance with name \"%s\" does not exists", name)
+	}
+
+	if c.Status != models.ClusterRunning {
+		logrus.Infof("cluster \"%s\" is already %s", name, strings.ToLower(c.Status))
+		return nil
+	}
+
+	nodes, err := existingNodes(c)
+	if err != nil {
+		return err
+	}
+
+	action, status := "stop", models.ClusterStopped
+	if pause {
+		action, status = "pause", models.ClusterPaused
+	}
+
+	if err := sh.NewCommand("docker", append([]string{action}, nodes...)...).WithEnv("DOCKER_CONTEXT", c.VM).RunSilent(); err != nil {
+		return fmt.Errorf("failed to %s nodes of cluster \"%s\": %w", action, name, err)
+	}
+
+	c.Status = status
+	if err := c.Update(); err != nil {
+		return fmt.Errorf("failed to save status of cluster: %w", err)
+	}
+
+	logrus.Infof("✅ Cluster \"%s\" is %s", name, strings.ToLower(status))
+	return nil
+}
+
+// Start starts the stopped or paused node containers of the cluster and
+// restores its kubeconfig context and LoadBalancer configuration
+func Start(name string) error {
+	c := models.NewCluster(name, "", "")
+	if err := c.GetByName(); err != nil {
+		return fmt.Errorf("instance with name \"%s\" does not exists", name)
+	}
+
+	if c.Status == models.ClusterRunning {
+		logrus.Infof("cluster \"%s\" is already running", name)
+		return nil
+	}
+
+	nodes, err := existingNodes(c)
+	if err != nil {
+		return err
+	}
+
+	action := "start"
+	if c.Status == models.ClusterPaused {
+		action = "unpause"
+	}
+
+	if err := sh.NewCommand("docker", append([]string{action}, nodes...)...).WithEnv("DOCKER_CONTEXT", c.VM).RunSilent(); err != nil {
+		return fmt.Errorf("failed to %s nodes of cluster \"%s\": %w", action, name, err)
+	}
+
+	c.Status = models.ClusterRunning
+	if err := c.Update(); err != nil {
+		return fmt.Errorf("failed to save status of cluster: %w", err)
+	}
+
+	// The context may have been removed from the kubeconfig while the
+	// cluster was stopped
+	if err := sh.NewCommand("kind", "export", "kubeconfig", "--name", name).WithEnv("DOCKER_CONTEXT", c.VM).RunSilent(); err != nil {
+		return fmt.Errorf("failed to export kubeconfig: %w", err)
+	}
+
+	// The address of the API server in the kind network may have changed
+	if _, err := kubeconfig.Export(name, c.VM); err != nil {
+		logrus.Warnf("failed to export kubeconfig of the cluster: %s", err)
+	}
+
+	client, err := k8s.New(kindifyClusterName(name))
+	if err != nil {
+		return err
+	}
+
+	if err := client.WaitForAPIServer(context.Background(), startTimeout); err != nil {
+		return err
+	}
+
+	if c.MetalLBVersion != "" {
+		if err := metallb.Configure(name); err != nil {
+			return fmt.Errorf("failed to restore metallb config: %w", err)
+		}
+	}
+
+	logrus.Infof("✅ Cluster \"%s\" is running", name)
+	return nil
+}
+
+// existingNodes returns the node containers of the cluster, it is an error
+// if the kind cluster doesn't exist
+func existingNodes(c *models.Cluster) ([]string, error) {
+	nodes, err := Nodes(c.Name, c.VM)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("kind cluster \"%s\" does not exist in VM %s", c.Name, c.VM)
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,175 @@
+package kind
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/utkarsh-pro/kindli/pkg/db"
+	"github.com/utkarsh-pro/kindli/pkg/k8s"
+	"github.com/utkarsh-pro/kindli/pkg/kubeconfig"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "kindli-kind-test-*")
+	if err != nil {
+		panic(err)
+	}
+
+	models.RegisterMigrations()
+	db.Setup(filepath.Join(dir, "db.sqlite"))
+
+	// The kubeconfig and the API server of the started clusters are not
+	// touched by the tests
+	k8s.SetDryRun(io.Discard)
+	kubeconfig.SetDryRun(io.Discard)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// saveCluster saves a cluster of the "test" VM with the given status
+func saveCluster(t *testing.T, name, status string) {
+	c := models.NewCluster(name, filepath.Join(t.TempDir(), name+".yaml"), "test")
+	c.Status = status
+	if err := c.Save(); err != nil {
+		t.Fatalf("failed to save cluster: %v", err)
+	}
+
+	t.Cleanup(func() { c.Delete() })
+}
+
+func clusterStatusOf(t *testing.T, name string) string {
+	c := models.NewCluster(name, "", "")
+	if err := c.GetByName(); err != nil {
+		t.Fatalf("failed to get cluster: %v", err)
+	}
+
+	return c.Status
+}
+
+func TestStop(t *testing.T) {
+	tests := []struct {
+		name       string
+		pause      bool
+		nodes      string
+		want       []string
+		wantStatus string
+		wantErr    bool
+	}{
+		{
+			name:  "nodes are stopped",
+			nodes: "test-a-control-plane\ntest-a-worker\n",
+			want: []string{
+				"DOCKER_CONTEXT=test kind get nodes --name test-a",
+				"DOCKER_CONTEXT=test docker stop test-a-control-plane test-a-worker",
+			},
+			wantStatus: models.ClusterStopped,
+		},
+		{
+			name:  "nodes are paused",
+			pause: true,
+			nodes: "test-a-control-plane\n",
+			want: []string{
+				"DOCKER_CONTEXT=test kind get nodes --name test-a",
+				"DOCKER_CONTEXT=test docker pause test-a-control-plane",
+			},
+			wantStatus: models.ClusterPaused,
+		},
+		{
+			name:  "missing kind cluster is an error",
+			nodes: "No kind nodes found for cluster \"test-a\".\n",
+			want: []string{
+				"DOCKER_CONTEXT=test kind get nodes --name test-a",
+			},
+			wantStatus: models.ClusterRunning,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saveCluster(t, "test-a", models.ClusterRunning)
+
+			rec := sh.Record(t)
+			rec.On("DOCKER_CONTEXT=test kind get nodes", sh.Response{Output: []byte(tt.nodes)})
+
+			err := Stop("test-a", tt.pause)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Stop() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := rec.Commands(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commands = %q, want %q", got, tt.want)
+			}
+
+			if got := clusterStatusOf(t, "test-a"); got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		want   []string
+	}{
+		{
+			name:   "stopped nodes are started",
+			status: models.ClusterStopped,
+			want: []string{
+				"DOCKER_CONTEXT=test kind get nodes --name test-b",
+				"DOCKER_CONTEXT=test docker start test-b-control-plane",
+				"DOCKER_CONTEXT=test kind export kubeconfig --name test-b",
+			},
+		},
+		{
+			name:   "paused nodes are unpaused",
+			status: models.ClusterPaused,
+			want: []string{
+				"DOCKER_CONTEXT=test kind get nodes --name test-b",
+				"DOCKER_CONTEXT=test docker unpause test-b-control-plane",
+				"DOCKER_CONTEXT=test kind export kubeconfig --name test-b",
+			},
+		},
+		{
+			name:   "running cluster is left as is",
+			status: models.ClusterRunning,
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saveCluster(t, "test-b", tt.status)
+
+			rec := sh.Record(t)
+			rec.On("DOCKER_CONTEXT=test kind get nodes", sh.Response{Output: []byte("test-b-control-plane\n")})
+
+			if err := Start("test-b"); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			// The commands refreshing the kubeconfig of the host follow
+			got := rec.Commands()
+			if len(got) > len(tt.want) {
+				got = got[:len(tt.want)]
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commands = %q, want %q", got, tt.want)
+			}
+
+			if got := clusterStatusOf(t, "test-b"); got != models.ClusterRunning {
+				t.Errorf("status = %s, want %s", got, models.ClusterRunning)
+			}
+		})
+	}
+}
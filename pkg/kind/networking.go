@@ -0,0 +1,161 @@
+package kind
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/ipam"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/networking"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+// subnetPools are the pools of the IPv4 and the IPv6 subnets of the
+// networking fields of the kind config
+var subnetPools = map[string][2]string{
+	"serviceSubnet": {ipam.PoolServiceIPv4, ipam.PoolServiceIPv6},
+	"podSubnet":     {ipam.PoolPodIPv4, ipam.PoolPodIPv6},
+}
+
+// createNetworking sets up the service and pod subnets of the cluster.
+//
+// Subnets provided by the user are validated and reserved for the cluster,
+// the missing subnets are allocated for every IP family of the cluster.
+func createNetworking(cluster *models.Cluster, userKindCfg *v1alpha4.Cluster) error {
+	family := userKindCfg.Networking.IPFamily
+	if family == "" {
+		family = v1alpha4.IPv4Family
+	}
+
+	var families []int
+	switch family {
+	case v1alpha4.IPv4Family:
+		families = []int{0}
+	case v1alpha4.IPv6Family:
+		families = []int{1}
+	case v1alpha4.DualStackFamily:
+		families = []int{0, 1}
+	default:
+		return fmt.Errorf("unsupported ipFamily \"%s\"", family)
+	}
+
+	for _, key := range []string{"serviceSubnet", "podSubnet"} {
+		pools := subnetPools[key]
+
+		field := subnetField(userKindCfg, key)
+
+		if userSubnets := *field; userSubnets != "" {
+			if err := reserveUserSubnets(cluster, key, userSubnets, family, pools); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		subnets := []string{}
+		for _, f := range families {
+			subnet, err := allocate(pools[f], cluster.IPOwner())
+			if err != nil {
+				return err
+			}
+
+			subnets = append(subnets, subnet)
+		}
+
+		*field = strings.Join(subnets, ",")
+	}
+
+	return nil
+}
+
+// reserveUserSubnets validates the comma separated subnets provided by the
+// user for the networking field key and reserves them for the cluster
+func reserveUserSubnets(cluster *models.Cluster, key, userSubnets string, family v1alpha4.ClusterIPFamily, pools [2]string) error {
+	subnets := map[string]*net.IPNet{}
+
+	var ipv4, ipv6 int
+	for _, userSubnet := range strings.Split(userSubnets, ",") {
+		ip, subnet, err := net.ParseCIDR(strings.TrimSpace(userSubnet))
+		if err != nil {
+			return fmt.Errorf("invalid networking.%s %s: %w", key, userSubnet, err)
+		}
+
+		if ip.To4() == nil {
+			subnets[pools[1]] = subnet
+			ipv6++
+		} else {
+			subnets[pools[0]] = subnet
+			ipv4++
+		}
+	}
+
+	want := map[v1alpha4.ClusterIPFamily][2]int{
+		v1alpha4.IPv4Family:      {1, 0},
+		v1alpha4.IPv6Family:      {0, 1},
+		v1alpha4.DualStackFamily: {1, 1},
+	}[family]
+	if ipv4 != want[0] || ipv6 != want[1] {
+		return fmt.Errorf("networking.%s %s does not match ipFamily \"%s\"", key, userSubnets, family)
+	}
+
+	vmNetworks := vmNetworks(cluster.VM)
+	for pool, subnet := range subnets {
+		for _, vmNetwork := range vmNetworks {
+			if ipam.Overlaps(subnet, vmNetwork) {
+				return fmt.Errorf("networking.%s %s overlaps with the VM network %s", key, subnet, vmNetwork)
+			}
+		}
+
+		if err := ipam.Reserve(pool, subnet.String(), cluster.IPOwner()); err != nil {
+			return fmt.Errorf("networking.%s cannot be used: %w", key, err)
+		}
+
+		logrus.Infof("Using networking.%s %s from the kind config", key, subnet)
+	}
+
+	return nil
+}
+
+// subnetField returns the networking field of the kind config for key
+func subnetField(cfg *v1alpha4.Cluster, key string) *string {
+	if key == "podSubnet" {
+		return &cfg.Networking.PodSubnet
+	}
+
+	return &cfg.Networking.ServiceSubnet
+}
+
+// vmNetworks returns the networks used by the VMs and the kind network of
+// the VM, the subnets of the clusters must not overlap with them
+func vmNetworks(vmName string) []*net.IPNet {
+	networks := []*net.IPNet{}
+	for _, cidr := range []string{models.VMNetworkIPv4, models.VMNetworkIPv6} {
+		_, vmNetwork, _ := net.ParseCIDR(cidr)
+		networks = append(networks, vmNetwork)
+	}
+
+	// The kind network only exists once the first cluster is created
+	for _, get := range []func(string, string) (*net.IPNet, error){networking.GetIPv4Subnet, networking.GetIPv6Subnet} {
+		if subnet, err := get(vmName, "kind"); err == nil {
+			networks = append(networks, subnet)
+		}
+	}
+
+	return networks
+}
+
+func allocate(poolName, owner string) (string, error) {
+	pool, err := ipam.GetPool(poolName)
+	if err != nil {
+		return "", err
+	}
+
+	subnet, err := ipam.Allocate(pool, owner)
+	if err != nil {
+		return "", err
+	}
+
+	return subnet.String(), nil
+}
@@ -1,109 +1,259 @@
 package kubeconfig
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
-	"github.com/utkarsh-pro/kindli/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
-// SetCurrentContext takes a context name and sets it as the current context
-func SetCurrentContext(name string) error {
-	return DoXOnKubeconfig(func(mp map[string]interface{}) (bool, error) {
-		val, ok := utils.MapGet(mp, "current-context")
-		if !ok {
-			utils.MapSet(mp, name, "current-context")
-			return true, nil
-		}
+// dryRunOut is where the changes of the kubeconfig files are printed
+// instead of being written, nil if dry run is disabled
+var dryRunOut io.Writer
 
-		ctx, ok := val.(string)
-		if !ok {
-			utils.MapSet(mp, name, "current-context")
-			return true, nil
-		}
+// SetDryRun makes the changes of the kubeconfig files to be printed to out
+// instead of being written, nil disables dry run
+func SetDryRun(out io.Writer) {
+	dryRunOut = out
+}
 
-		if ctx == name {
-			return false, nil
-		}
+// Dir returns the directory of the standalone kubeconfigs of the clusters
+func Dir() string {
+	return filepath.Join(config.Dir(), "kubeconfigs")
+}
 
-		utils.MapSet(mp, name, "current-context")
-		return true, nil
-	})
+// Path returns the path to the standalone kubeconfig of the cluster
+func Path(clusterName string) string {
+	return filepath.Join(Dir(), clusterName+".yaml")
 }
 
-// DoXOnKubeconfig executes a function on the kubeconfig file
-func DoXOnKubeconfig(x func(map[string]interface{}) (bool, error)) error {
-	byt, err := getKubeconfig()
-	if err != nil {
-		return fmt.Errorf("failed to perform action on kubeconfig: %w", err)
+// ContextName returns the name of the context of the cluster, it is the
+// same as the one used by kind
+func ContextName(clusterName string) string {
+	return "kind-" + clusterName
+}
+
+// KubeconfigPath returns the path to the kubeconfig file
+func KubeconfigPath() string {
+	env := os.Getenv("KUBECONFIG")
+	if env != "" {
+		return env
 	}
 
-	mp, err := parseKubeConfig(byt)
+	home, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("failed to perform action on kubeconfig: %w", err)
+		return "~/.kube/config"
 	}
 
-	if proceed, err := x(mp); err != nil {
-		return fmt.Errorf("failed to perform action on kubeconfig: %w", err)
-	} else if !proceed {
-		return nil
-	}
+	return filepath.Join(home, ".kube", "config")
+}
 
-	byt, err = serializeKubeconfig(mp)
+// Get returns the standalone kubeconfig of the cluster of the VM
+//
+// The server of the kubeconfig is the endpoint of the API server which is
+// reachable from the host, for the VMs whose kind networks are routed from
+// the host it is the address of the API server in the kind network.
+func Get(clusterName, vmName string) ([]byte, error) {
+	cfg, err := generate(clusterName, vmName)
 	if err != nil {
-		return fmt.Errorf("failed to perform action on kubeconfig: %w", err)
+		return nil, err
 	}
 
-	if err := writeKubeconfig(byt); err != nil {
-		return fmt.Errorf("failed to perform action on kubeconfig: %w", err)
+	byt, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize kubeconfig: %w", err)
 	}
 
-	return nil
+	return byt, nil
 }
 
-// KubeconfigPath returns the path to the kubeconfig file
-func KubeconfigPath() string {
-	env := os.Getenv("KUBECONFIG")
-	if env != "" {
-		return env
+// Export writes the standalone kubeconfig of the cluster, see Get, to
+// Path and returns the path
+func Export(clusterName, vmName string) (string, error) {
+	cfg, err := generate(clusterName, vmName)
+	if err != nil {
+		return "", err
 	}
 
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "~/.kube/config"
+	path := Path(clusterName)
+	if dryRunOut != nil {
+		fmt.Fprintf(dryRunOut, "kind get kubeconfig --name %s > %s\n", clusterName, path)
+		return path, nil
 	}
 
-	return filepath.Join(home, ".kube", "config")
+	if err := clientcmd.WriteToFile(*cfg, path); err != nil {
+		return "", fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+
+	return path, nil
 }
 
-func parseKubeConfig(byt []byte) (map[string]interface{}, error) {
-	mp, err := utils.MapFromYAML(byt)
+// Merge merges the standalone kubeconfig of the cluster into the kubeconfig
+// files of KUBECONFIG, or ~/.kube/config, and makes it the current context
+// if setCurrent is true
+//
+// The entries of the cluster are replaced, the rest of the kubeconfig is
+// left untouched. The kubeconfig files are locked while they are modified.
+func Merge(clusterName string, setCurrent bool) error {
+	standalone, err := clientcmd.LoadFromFile(Path(clusterName))
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+		return fmt.Errorf("failed to load kubeconfig of cluster \"%s\", export it first: %w", clusterName, err)
+	}
+
+	name := ContextName(clusterName)
+	if standalone.Contexts[name] == nil || standalone.Clusters[name] == nil || standalone.AuthInfos[name] == nil {
+		return fmt.Errorf("kubeconfig of cluster \"%s\" has no context %s", clusterName, name)
 	}
 
-	return mp, nil
+	// The entries are written to the file they originate from, clear the
+	// origin so that they are written to the kubeconfig
+	cluster, user, context := *standalone.Clusters[name], *standalone.AuthInfos[name], *standalone.Contexts[name]
+	cluster.LocationOfOrigin, user.LocationOfOrigin, context.LocationOfOrigin = "", "", ""
+
+	return modify(func(cfg *clientcmdapi.Config) {
+		cfg.Clusters[name] = &cluster
+		cfg.AuthInfos[name] = &user
+		cfg.Contexts[name] = &context
+
+		if setCurrent {
+			cfg.CurrentContext = name
+		}
+	})
 }
 
-func getKubeconfig() ([]byte, error) {
-	byt, err := os.ReadFile(KubeconfigPath())
+// Remove removes the context of the cluster from the kubeconfig files and
+// deletes the standalone kubeconfig of the cluster
+func Remove(clusterName string) error {
+	name := ContextName(clusterName)
+	err := modify(func(cfg *clientcmdapi.Config) {
+		delete(cfg.Clusters, name)
+		delete(cfg.AuthInfos, name)
+		delete(cfg.Contexts, name)
+
+		if cfg.CurrentContext == name {
+			cfg.CurrentContext = ""
+		}
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+		return err
 	}
 
-	return byt, nil
+	if dryRunOut != nil {
+		fmt.Fprintf(dryRunOut, "rm -f %s\n", Path(clusterName))
+		return nil
+	}
+
+	if err := os.Remove(Path(clusterName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove kubeconfig: %w", err)
+	}
+
+	return nil
 }
 
-func serializeKubeconfig(mp map[string]interface{}) ([]byte, error) {
-	return utils.MapToYAML(mp)
+// SetCurrentContext takes a context name and sets it as the current context
+func SetCurrentContext(name string) error {
+	return modify(func(cfg *clientcmdapi.Config) {
+		cfg.CurrentContext = name
+	})
 }
 
-func writeKubeconfig(byt []byte) error {
-	path := KubeconfigPath()
-	if err := os.WriteFile(path, byt, 0644); err != nil {
+// modify applies x to the kubeconfig and writes back the changed entries
+// to the files they come from
+func modify(x func(*clientcmdapi.Config)) error {
+	access := clientcmd.NewDefaultPathOptions()
+
+	cfg, err := access.GetStartingConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	x(cfg)
+
+	if dryRunOut != nil {
+		fmt.Fprintf(dryRunOut, "kubectl config view --raw > %s\n", KubeconfigPath())
+		return nil
+	}
+
+	if err := clientcmd.ModifyConfig(access, *cfg, true); err != nil {
 		return fmt.Errorf("failed to write kubeconfig: %w", err)
 	}
 
 	return nil
 }
+
+// generate returns the kubeconfig of the cluster with the server reachable
+// from the host
+func generate(clusterName, vmName string) (*clientcmdapi.Config, error) {
+	provider, err := vm.ProviderFor(vmName)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"get", "kubeconfig", "--name", clusterName}
+	if provider.Routed() {
+		args = append(args, "--internal")
+	}
+
+	out, err := sh.NewCommand("kind", args...).WithEnv("DOCKER_CONTEXT", vmName).Query().Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig of kind cluster \"%s\": %w", clusterName, err)
+	}
+
+	cfg, err := clientcmd.Load(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig of kind cluster \"%s\": %w", clusterName, err)
+	}
+
+	if !provider.Routed() {
+		return cfg, nil
+	}
+
+	for name, cluster := range cfg.Clusters {
+		server, err := routedServer(cluster.Server, vmName)
+		if err != nil {
+			return nil, err
+		}
+
+		logrus.Debugf("using server %s for cluster %s", server, name)
+		cluster.Server = server
+	}
+
+	return cfg, nil
+}
+
+// routedServer replaces the container name in the internal server address
+// with the address of the container in the kind network
+func routedServer(server, vmName string) (string, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", fmt.Errorf("invalid server %s: %w", server, err)
+	}
+
+	out, err := sh.NewCommand(
+		"docker", "inspect",
+		"-f", "{{range .NetworkSettings.Networks}}{{.IPAddress}} {{.GlobalIPv6Address}}{{end}}",
+		u.Hostname(),
+	).WithEnv("DOCKER_CONTEXT", vmName).Query().Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find address of %s: %w", u.Hostname(), err)
+	}
+
+	addrs := strings.Fields(string(out))
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("%s has no address in the kind network", u.Hostname())
+	}
+
+	u.Host = net.JoinHostPort(addrs[0], u.Port())
+	return u.String(), nil
+}
@@ -0,0 +1,190 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SumsFile is the file of the manifests directory holding the pinned
+// sha256 digests of the manifests, in the format of sha256sum
+const SumsFile = "sha256sums.txt"
+
+// downloadTimeout is the time given to a manifest download
+const downloadTimeout = 2 * time.Minute
+
+// download enables downloading the manifests which are not embedded
+var download bool
+
+// SetDownload allows the manifests which are neither embedded nor cached
+// to be downloaded from their upstream locations
+func SetDownload(enabled bool) {
+	download = enabled
+}
+
+// Loader loads the manifests embedded in the kindli binary
+//
+// Release builds embed the manifests, they are fetched before building by
+// `make generate`. Every manifest must have its digest pinned in SumsFile,
+// the manifests are verified against it whether they are embedded, cached
+// or downloaded. Manifests missing from the build are only downloaded if
+// downloads are enabled with SetDownload.
+type Loader struct {
+	// FS holds the embedded manifests
+	FS embed.FS
+	// Dir is the directory of the manifests in FS
+	Dir string
+	// CacheDir is the directory where the downloaded manifests are cached
+	CacheDir string
+}
+
+// Embedded returns true if the manifest is embedded
+func (l *Loader) Embedded(file string) bool {
+	_, err := l.FS.Open(path.Join(l.Dir, file))
+	return err == nil
+}
+
+// Load returns the manifest, url is the upstream location of the manifest
+// used when the manifest is neither embedded nor cached
+func (l *Loader) Load(file, url string) ([]byte, error) {
+	sums, err := ReadSums(l.FS, path.Join(l.Dir, SumsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	digest, ok := sums[file]
+	if !ok {
+		return nil, fmt.Errorf("no sha256 digest is pinned for %s, run `go run ./hack/fetch-manifests -pin` and review the change", file)
+	}
+
+	if byt, err := l.FS.ReadFile(path.Join(l.Dir, file)); err == nil {
+		if err := Verify(byt, digest); err != nil {
+			return nil, fmt.Errorf("embedded %s: %w", file, err)
+		}
+
+		return byt, nil
+	}
+
+	cached := filepath.Join(l.CacheDir, file)
+	if byt, err := os.ReadFile(cached); err == nil {
+		if err := Verify(byt, digest); err == nil {
+			return byt, nil
+		}
+
+		logrus.Warnf("cached %s does not match its pinned digest, ignoring it", file)
+	}
+
+	if !download {
+		return nil, fmt.Errorf("%s is not embedded in this build, run `make generate` before building or pass --download-manifests to download it", file)
+	}
+
+	logrus.Infof("%s is not embedded in this build, downloading it", file)
+
+	byt, err := Download(url, cached, digest)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not embedded and could not be downloaded: %w", file, err)
+	}
+
+	return byt, nil
+}
+
+// ReadSums returns the digests of the sums file of the FS, keyed by the
+// file names
+func ReadSums(fsys embed.FS, name string) (map[string]string, error) {
+	byt, err := fsys.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("missing manifest digests: %w", err)
+	}
+
+	return ParseSums(byt)
+}
+
+// ParseSums parses the content of a sums file
+func ParseSums(byt []byte) (map[string]string, error) {
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(byt))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid digest line \"%s\"", line)
+		}
+
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+
+	return sums, scanner.Err()
+}
+
+// Digest returns the hex encoded sha256 digest of the content
+func Digest(byt []byte) string {
+	sum := sha256.Sum256(byt)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify returns an error if the sha256 digest of the content is not digest
+func Verify(byt []byte, digest string) error {
+	if actual := Digest(byt); actual != digest {
+		return fmt.Errorf("sha256 mismatch, expected %s got %s", digest, actual)
+	}
+
+	return nil
+}
+
+// Download downloads the url to the given path and returns its content,
+// the content is written only if its sha256 digest is digest. An empty
+// digest skips the verification.
+func Download(url, dst, digest string) ([]byte, error) {
+	client := &http.Client{Timeout: downloadTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	byt, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", url, err)
+	}
+
+	if digest != "" {
+		if err := Verify(byt, digest); err != nil {
+			return nil, fmt.Errorf("GET %s: %w", url, err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return nil, err
+	}
+
+	tmp := dst + ".tmp"
+	if err := os.WriteFile(tmp, byt, 0644); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		return nil, err
+	}
+
+	return byt, nil
+}
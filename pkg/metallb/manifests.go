@@ -0,0 +1,128 @@
+package metallb
+
+import (
+	"embed"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/utkarsh-pro/kindli/pkg/manifest"
+)
+
+var (
+	//go:embed manifests
+	manifests embed.FS
+
+	imageRegex = regexp.MustCompile(`(?m)^\s*image:\s*"?([^"\s]+)"?`)
+)
+
+// Versions returns the MetalLB versions supported by kindli, oldest first
+func Versions() []string {
+	byt, err := manifests.ReadFile("manifests/versions.txt")
+	if err != nil {
+		panic(fmt.Sprintf("missing embedded metallb versions: %s", err))
+	}
+
+	versions := []string{}
+	for _, version := range strings.Split(string(byt), "\n") {
+		if version = strings.TrimSpace(version); version != "" {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions
+}
+
+// DefaultVersion returns the MetalLB version installed unless asked otherwise
+func DefaultVersion() string {
+	versions := Versions()
+	return versions[len(versions)-1]
+}
+
+// Embedded returns true if the manifest of the version is embedded in the
+// kindli binary, manifests which aren't embedded are downloaded on first use
+func Embedded(version string) bool {
+	return loader().Embedded(ManifestFile(version))
+}
+
+// ValidateVersion returns an error if the MetalLB version is not supported
+func ValidateVersion(version string) error {
+	for _, v := range Versions() {
+		if v == version {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported metallb version \"%s\", supported versions: %s", version, strings.Join(Versions(), ", "))
+}
+
+// Manifest returns the MetalLB manifest of the version
+func Manifest(version string) ([]byte, error) {
+	if err := ValidateVersion(version); err != nil {
+		return nil, err
+	}
+
+	return loader().Load(ManifestFile(version), ManifestURL(version))
+}
+
+// ManifestFile returns the file name of the manifest of the version
+func ManifestFile(version string) string {
+	return fmt.Sprintf("metallb-native-%s.yaml", version)
+}
+
+// ManifestURL returns the upstream location of the manifest of the version
+func ManifestURL(version string) string {
+	return fmt.Sprintf("https://raw.githubusercontent.com/metallb/metallb/%s/config/manifests/metallb-native.yaml", version)
+}
+
+// loader returns the loader of the MetalLB manifests, tests replace it
+var loader = func() *manifest.Loader {
+	return &manifest.Loader{
+		FS:       manifests,
+		Dir:      "manifests",
+		CacheDir: filepath.Join(instanceDirPath, "manifests"),
+	}
+}
+
+// Images returns the container images referenced by the manifest
+func Images(manifest []byte) []string {
+	seen := map[string]bool{}
+	images := []string{}
+	for _, match := range imageRegex.FindAllSubmatch(manifest, -1) {
+		image := string(match[1])
+		if !seen[image] {
+			seen[image] = true
+			images = append(images, image)
+		}
+	}
+
+	return images
+}
+
+// CompareVersions compares two "vX.Y.Z" versions and returns -1, 0 or 1 if
+// a is older than, same as or newer than b
+func CompareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := range pa {
+		if pa[i] < pb[i] {
+			return -1
+		}
+
+		if pa[i] > pb[i] {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func versionParts(version string) [3]int {
+	var parts [3]int
+	for i, part := range strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3) {
+		parts[i], _ = strconv.Atoi(part)
+	}
+
+	return parts
+}
@@ -1,16 +1,23 @@
 package metallb
 
 import (
+	"context"
 	_ "embed"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"text/template"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/image"
+	"github.com/utkarsh-pro/kindli/pkg/ipam"
+	"github.com/utkarsh-pro/kindli/pkg/k8s"
 	"github.com/utkarsh-pro/kindli/pkg/models"
 	"github.com/utkarsh-pro/kindli/pkg/networking"
-	"github.com/utkarsh-pro/kindli/pkg/sh"
 	"github.com/utkarsh-pro/kindli/pkg/utils"
 )
 
@@ -25,20 +32,73 @@ var (
 	metalLBAdv string
 )
 
+// readyTimeout is the time given to metallb to become ready
+const readyTimeout = 10 * time.Minute
+
 func init() {
 	instanceDirPath = filepath.Join(config.Dir(), instanceDirName)
 	utils.ExitIfNotNil(os.MkdirAll(instanceDirPath, 0777))
 }
 
-// Install install metallb in the given cluster
-func Install(clusterName string) error {
-	if err := sh.Run("kubectl apply -f https://raw.githubusercontent.com/metallb/metallb/v0.13.5/config/manifests/metallb-native.yaml"); err != nil {
+// Install installs metallb in the given cluster
+//
+// If version is empty then the version already installed in the cluster is
+// used, or the default version for clusters without metallb.
+func Install(clusterName, version string) error {
+	c := models.NewCluster(clusterName, "", "")
+	if err := c.GetByName(); err != nil {
+		return fmt.Errorf("failed to find cluster with name \"%s\": %w", clusterName, err)
+	}
+
+	switch {
+	case version == "" && c.MetalLBVersion != "":
+		version = c.MetalLBVersion
+	case version == "":
+		version = DefaultVersion()
+	case c.MetalLBVersion != "" && version != c.MetalLBVersion:
+		return fmt.Errorf("metallb %s is installed in the cluster, use `kindli metallb upgrade` to change the version", c.MetalLBVersion)
+	}
+
+	if err := apply(c, version); err != nil {
 		return fmt.Errorf("failed to install metallb: %w", err)
 	}
 
-	// if err := sh.Run("kubectl apply -f https://raw.githubusercontent.com/metallb/metallb/v0.12.1/manifests/metallb.yaml"); err != nil {
-	// 	return fmt.Errorf("failed to install metallb: %w", err)
-	// }
+	if err := Configure(clusterName); err != nil {
+		return fmt.Errorf("failed to configure metallb: %w", err)
+	}
+
+	return nil
+}
+
+// Upgrade upgrades metallb in the given cluster to the given version, the
+// default version is used if version is empty
+func Upgrade(clusterName, version string) error {
+	c := models.NewCluster(clusterName, "", "")
+	if err := c.GetByName(); err != nil {
+		return fmt.Errorf("failed to find cluster with name \"%s\": %w", clusterName, err)
+	}
+
+	if c.MetalLBVersion == "" {
+		return fmt.Errorf("metallb is not installed in the cluster \"%s\"", clusterName)
+	}
+
+	if version == "" {
+		version = DefaultVersion()
+	}
+
+	switch CompareVersions(version, c.MetalLBVersion) {
+	case 0:
+		logrus.Infof("metallb %s is already installed in the cluster \"%s\"", version, clusterName)
+		return nil
+	case -1:
+		return fmt.Errorf("cannot downgrade metallb from %s to %s", c.MetalLBVersion, version)
+	}
+
+	logrus.Infof("Upgrading metallb in the cluster \"%s\" from %s to %s", clusterName, c.MetalLBVersion, version)
+
+	if err := apply(c, version); err != nil {
+		return fmt.Errorf("failed to upgrade metallb: %w", err)
+	}
 
 	if err := Configure(clusterName); err != nil {
 		return fmt.Errorf("failed to configure metallb: %w", err)
@@ -54,40 +114,99 @@ func Configure(clusterName string) error {
 		return fmt.Errorf("failed to find cluster with name \"%s\": %w", clusterName, err)
 	}
 
-	intID := int(c.ID)
-	if intID >= 99 {
-		return fmt.Errorf("cannot configure more than 99 instances")
+	ipv4, ipv6, err := ipFamilies(c)
+	if err != nil {
+		return err
 	}
 
-	ipv4SubnetPrefix, ipv6SubnetPrefix, err := getSubnetPrefix("kind")
-	if err != nil {
-		return fmt.Errorf("failed to configure metallb: %w", err)
+	cfg := map[string]interface{}{}
+	if ipv4 {
+		lbRange, err := allocateRange(c, ipam.PoolLoadBalancerIPv4, networking.GetIPv4Subnet)
+		if err != nil {
+			return fmt.Errorf("failed to allocate LoadBalancer IPv4 range: %w", err)
+		}
+
+		cfg["ipv4Range"] = lbRange.String()
 	}
 
-	cfg := createNetworkConfig(ipv4SubnetPrefix, ipv6SubnetPrefix, intID)
+	if ipv6 {
+		lbRange, err := allocateRange(c, ipam.PoolLoadBalancerIPv6, networking.GetIPv6Subnet)
+		if err != nil {
+			return fmt.Errorf("failed to allocate LoadBalancer IPv6 range: %w", err)
+		}
+
+		cfg["ipv6Range"] = lbRange.String()
+	}
 
 	cfgPath, err := createConfig(cfg, clusterName)
 	if err != nil {
 		return fmt.Errorf("failed to generate metallb config: %w", err)
 	}
 
-	if err := sh.Run("kubectl wait --for=condition=available --timeout=600s deployment -n metallb-system controller"); err != nil {
+	cfgYAML, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return fmt.Errorf("failed to read metallb config: %w", err)
+	}
+
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if err := client.WaitForDeployment(ctx, "metallb-system", "controller", readyTimeout); err != nil {
 		return fmt.Errorf("failed to wait for metallb controller to be available: %w", err)
 	}
 
-	if err := sh.Run(fmt.Sprintf("kubectl apply -f %s", cfgPath)); err != nil {
+	if err := client.Apply(ctx, cfgYAML, readyTimeout); err != nil {
 		return fmt.Errorf("failed to apply IP Address Pool config to kubernetes: %w", err)
 	}
 
-	if err := sh.Run(fmt.Sprintf("cat <<EOF | kubectl apply -f -\n%s\nEOF", metalLBAdv)); err != nil {
+	if err := client.Apply(ctx, []byte(metalLBAdv), readyTimeout); err != nil {
 		return fmt.Errorf("failed to apply L2 Advertisement config to kubernetes: %w", err)
 	}
 
 	return nil
 }
 
+// apply preloads the images of the metallb version into the cluster, applies
+// its manifest and records the version of the cluster
+func apply(c *models.Cluster, version string) error {
+	manifest, err := Manifest(version)
+	if err != nil {
+		return err
+	}
+
+	for _, img := range Images(manifest) {
+		if err := image.Preload(img, c.Name, c.VM); err != nil {
+			logrus.Warnf("failed to preload image %s, the cluster will pull it: %s", img, err)
+		}
+	}
+
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Apply(context.Background(), manifest, readyTimeout); err != nil {
+		return fmt.Errorf("failed to apply metallb %s manifest: %w", version, err)
+	}
+
+	c.MetalLBVersion = version
+	if err := c.Update(); err != nil {
+		return fmt.Errorf("failed to save metallb version of the cluster: %w", err)
+	}
+
+	return nil
+}
+
+// newClient returns a kubernetes client for the kind cluster
+func newClient(c *models.Cluster) (*k8s.Client, error) {
+	return k8s.New("kind-" + c.Name)
+}
+
 func LoadConfigFromDisk(clusterName string) (map[string]interface{}, error) {
-	path := filepath.Join(instanceDirPath, fmt.Sprintf("%s.yaml", clusterName))
+	path := ConfigPath(clusterName)
 
 	yaml, err := os.ReadFile(path)
 	if err != nil {
@@ -102,32 +221,77 @@ func LoadConfigFromDisk(clusterName string) (map[string]interface{}, error) {
 	return cfgMap, nil
 }
 
-func getSubnetPrefix(network string) (string, string, error) {
-	ipv4, err := networking.GetIPv4SubnetPrefix(network)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to get ipv4 subnet prefix: %w", err)
+// RemoveConfigFromDisk removes the persisted metallb config of the cluster
+func RemoveConfigFromDisk(clusterName string) error {
+	path := ConfigPath(clusterName)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove metallb config: %w", err)
 	}
-	ipv6, err := networking.GetIPv6SubnetPrefix(network)
+
+	return nil
+}
+
+// ConfigDir returns the directory where the metallb configs are persisted
+func ConfigDir() string {
+	return instanceDirPath
+}
+
+// ConfigPath returns the path to the metallb config of the cluster
+func ConfigPath(clusterName string) string {
+	return filepath.Join(instanceDirPath, fmt.Sprintf("%s.yaml", clusterName))
+}
+
+// ipFamilies returns whether the cluster has IPv4 and IPv6 services as per
+// the ipFamily of its kind config
+func ipFamilies(c *models.Cluster) (bool, bool, error) {
+	cfg, err := c.LoadConfigAsYAMLFromDisk()
 	if err != nil {
-		return "", "", fmt.Errorf("failed to get ipv6 subnet prefix: %w", err)
+		return false, false, fmt.Errorf("failed to load kind config of the cluster: %w", err)
 	}
 
-	return ipv4, ipv6, nil
-}
+	family, ok := utils.MapGet(cfg, "networking", "ipFamily")
+	if !ok {
+		family = ""
+	}
 
-func createNetworkConfig(ipv4SubnetPrefix, ipv6SubnetPrefix string, instanceID int) map[string]interface{} {
-	return map[string]interface{}{
-		"ipv4Range": createIPv4NetworkConfig(ipv4SubnetPrefix, instanceID),
-		"ipv6Range": createIPv6NetworkConfig(ipv6SubnetPrefix, instanceID),
+	switch family {
+	case "", "ipv4":
+		return true, false, nil
+	case "ipv6":
+		return false, true, nil
+	case "dual":
+		return true, true, nil
+	default:
+		return false, false, fmt.Errorf("unsupported ipFamily \"%v\"", family)
 	}
 }
 
-func createIPv4NetworkConfig(subnetPrefix string, instanceID int) string {
-	return fmt.Sprintf("%s.%x.0/24", subnetPrefix, instanceID+1)
-}
+// allocateRange allocates the range of the LoadBalancer services of the
+// cluster from the pool
+//
+// If the pool has no CIDR then the range is allocated from the subnet of the
+// "kind" docker network returned by kindSubnet, leaving out the first range
+// which holds the IPs of the kind nodes.
+func allocateRange(c *models.Cluster, poolName string, kindSubnet func(vmName, network string) (*net.IPNet, error)) (*net.IPNet, error) {
+	pool, err := ipam.GetPool(poolName)
+	if err != nil {
+		return nil, err
+	}
+
+	if pool.CIDR != "" {
+		return ipam.Allocate(pool, c.IPOwner())
+	}
+
+	subnet, err := kindSubnet(c.VM, "kind")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subnet of kind network: %w", err)
+	}
+
+	_, bits := subnet.Mask.Size()
+	pool.CIDR = subnet.String()
+	nodes := &net.IPNet{IP: subnet.IP, Mask: net.CIDRMask(pool.PrefixLen, bits)}
 
-func createIPv6NetworkConfig(subnetPrefix string, instanceID int) string {
-	return fmt.Sprintf("%s:%x::", subnetPrefix, instanceID+1)
+	return ipam.AllocateFrom(pool, c.IPOwner(), subnet, nodes)
 }
 
 func createConfig(cfg map[string]interface{}, clusterName string) (string, error) {
@@ -136,7 +300,7 @@ func createConfig(cfg map[string]interface{}, clusterName string) (string, error
 		return "", fmt.Errorf("failed to create metallb config: %s", err)
 	}
 
-	path := filepath.Join(instanceDirPath, fmt.Sprintf("%s.yaml", clusterName))
+	path := ConfigPath(clusterName)
 	file, err := os.Create(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to create metallb config: %s", err)
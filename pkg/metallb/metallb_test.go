@@ -0,0 +1,303 @@
+package metallb
+
+import (
+	"embed"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/utkarsh-pro/kindli/pkg/db"
+	"github.com/utkarsh-pro/kindli/pkg/ipam"
+	"github.com/utkarsh-pro/kindli/pkg/k8s"
+	"github.com/utkarsh-pro/kindli/pkg/manifest"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/networking"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+//go:embed testdata/manifests
+var testManifests embed.FS
+
+const inspectKind = "DOCKER_CONTEXT=test docker network inspect kind -f '{{range .IPAM.Config}}{{.Subnet}} {{end}}'"
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "kindli-metallb-test-*")
+	if err != nil {
+		panic(err)
+	}
+
+	models.RegisterMigrations()
+	db.Setup(filepath.Join(dir, "db.sqlite"))
+
+	// The clusters are not touched by the tests
+	k8s.SetDryRun(io.Discard)
+
+	instanceDirPath = dir
+	loader = func() *manifest.Loader {
+		return &manifest.Loader{
+			FS:       testManifests,
+			Dir:      "testdata/manifests",
+			CacheDir: filepath.Join(dir, "manifests"),
+		}
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// saveCluster saves a cluster of the "test" VM with the given installed
+// metallb version and kind config ipFamily
+func saveCluster(t *testing.T, name, version, ipFamily string) *models.Cluster {
+	t.Helper()
+
+	cfg := filepath.Join(t.TempDir(), name+".yaml")
+	content := "kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\n"
+	if ipFamily != "" {
+		content += "networking:\n  ipFamily: " + ipFamily + "\n"
+	}
+
+	if err := os.WriteFile(cfg, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write kind config: %v", err)
+	}
+
+	c := models.NewCluster(name, cfg, "test")
+	c.MetalLBVersion = version
+	if err := c.Save(); err != nil {
+		t.Fatalf("failed to save cluster: %v", err)
+	}
+
+	t.Cleanup(func() {
+		c.Delete()
+		ipam.Release(c.IPOwner())
+		RemoveConfigFromDisk(name)
+	})
+
+	return c
+}
+
+func versionOf(t *testing.T, name string) string {
+	t.Helper()
+
+	c := models.NewCluster(name, "", "")
+	if err := c.GetByName(); err != nil {
+		t.Fatalf("failed to get cluster: %v", err)
+	}
+
+	return c.MetalLBVersion
+}
+
+// loaded returns the images loaded into the nodes by the commands
+func loaded(commands []string) []string {
+	images := []string{}
+	for _, cmd := range commands {
+		if fields := strings.Fields(cmd); len(fields) > 4 && fields[1] == "kind" && fields[2] == "load" {
+			images = append(images, fields[4])
+		}
+	}
+
+	return images
+}
+
+// dockerCommands returns the commands run against the docker daemon of the
+// VM, the commands reading the routes of the host are left out
+func dockerCommands(commands []string) []string {
+	docker := []string{}
+	for _, cmd := range commands {
+		if strings.HasPrefix(cmd, "DOCKER_CONTEXT=") {
+			docker = append(docker, cmd)
+		}
+	}
+
+	return docker
+}
+
+func images(version string) []string {
+	return []string{"quay.io/metallb/controller:" + version, "quay.io/metallb/speaker:" + version}
+}
+
+func TestAllocateRange(t *testing.T) {
+	tests := []struct {
+		name string
+		pool string
+		want []string
+	}{
+		{
+			name: "ipv4 ranges skip the range of the nodes",
+			pool: ipam.PoolLoadBalancerIPv4,
+			want: []string{"172.18.1.0/24", "172.18.2.0/24"},
+		},
+		{
+			name: "ipv6 ranges skip the range of the nodes",
+			pool: ipam.PoolLoadBalancerIPv6,
+			want: []string{"fc00:f853:ccd:e793::100/120", "fc00:f853:ccd:e793::200/120"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The kind network of the VM holds the ranges carved from it
+			if err := ipam.ReserveNetwork(ipam.PoolKindNetworkIPv4, "172.18.0.0/16", "vm/test"); err != nil {
+				t.Fatalf("failed to reserve kind network: %v", err)
+			}
+			t.Cleanup(func() { ipam.Release("vm/test") })
+
+			rec := sh.Record(t)
+			rec.On("DOCKER_CONTEXT=test docker network inspect kind", sh.Response{Output: []byte("172.18.0.0/16 fc00:f853:ccd:e793::/64\n")})
+
+			kindSubnet := networking.GetIPv4Subnet
+			if tt.pool == ipam.PoolLoadBalancerIPv6 {
+				kindSubnet = networking.GetIPv6Subnet
+			}
+
+			got := []string{}
+			for _, name := range []string{"range-a", "range-b"} {
+				c := saveCluster(t, name, "", "")
+
+				lbRange, err := allocateRange(c, tt.pool, kindSubnet)
+				if err != nil {
+					t.Fatalf("allocateRange() error = %v", err)
+				}
+
+				// Allocating again returns the range of the cluster
+				again, err := allocateRange(c, tt.pool, kindSubnet)
+				if err != nil || again.String() != lbRange.String() {
+					t.Fatalf("second allocateRange() = %v, %v, want %s", again, err, lbRange)
+				}
+
+				got = append(got, lbRange.String())
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ranges = %q, want %q", got, tt.want)
+			}
+
+			want := []string{inspectKind, inspectKind, inspectKind, inspectKind}
+			if commands := dockerCommands(rec.Commands()); !reflect.DeepEqual(commands, want) {
+				t.Errorf("commands = %q, want %q", commands, want)
+			}
+		})
+	}
+}
+
+func TestAllocateRangeFromPoolCIDR(t *testing.T) {
+	if err := ipam.SetPool(ipam.PoolLoadBalancerIPv4, "10.200.0.0/16", 24); err != nil {
+		t.Fatalf("SetPool() error = %v", err)
+	}
+	t.Cleanup(func() { ipam.SetPool(ipam.PoolLoadBalancerIPv4, "", 24) })
+
+	rec := sh.Record(t)
+	c := saveCluster(t, "range-c", "", "")
+
+	got, err := allocateRange(c, ipam.PoolLoadBalancerIPv4, networking.GetIPv4Subnet)
+	if err != nil {
+		t.Fatalf("allocateRange() error = %v", err)
+	}
+
+	if got.String() != "10.200.0.0/24" {
+		t.Errorf("allocateRange() = %s, want 10.200.0.0/24", got)
+	}
+
+	if commands := dockerCommands(rec.Commands()); len(commands) != 0 {
+		t.Errorf("commands = %q, want none", commands)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed string
+		version   string
+		want      string
+		wantErr   bool
+	}{
+		{name: "default version for new install", want: DefaultVersion()},
+		{name: "explicit version", version: "v0.13.12", want: "v0.13.12"},
+		{name: "installed version is kept", installed: "v0.13.12", want: "v0.13.12"},
+		{name: "installed version is reinstalled", installed: "v0.13.12", version: "v0.13.12", want: "v0.13.12"},
+		{name: "other version than the installed one", installed: "v0.13.12", version: "v0.14.9", wantErr: true},
+		{name: "unsupported version", version: "v0.1.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saveCluster(t, "install", tt.installed, "")
+
+			rec := sh.Record(t)
+			rec.On("DOCKER_CONTEXT=test docker network inspect kind", sh.Response{Output: []byte("172.18.0.0/16\n")})
+
+			err := Install("install", tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Install() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				if commands := rec.Commands(); len(commands) != 0 {
+					t.Errorf("commands = %q, want none", commands)
+				}
+
+				if got := versionOf(t, "install"); got != tt.installed {
+					t.Errorf("version = %s, want %s", got, tt.installed)
+				}
+
+				return
+			}
+
+			if got := loaded(rec.Commands()); !reflect.DeepEqual(got, images(tt.want)) {
+				t.Errorf("loaded images = %q, want %q", got, images(tt.want))
+			}
+
+			if got := versionOf(t, "install"); got != tt.want {
+				t.Errorf("version = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed string
+		version   string
+		want      string
+		wantErr   bool
+	}{
+		{name: "default version", installed: "v0.13.12", want: DefaultVersion()},
+		{name: "explicit version", installed: "v0.13.12", version: "v0.14.9", want: "v0.14.9"},
+		{name: "same version is a no-op", installed: "v0.14.9", version: "v0.14.9", want: "v0.14.9"},
+		{name: "downgrade", installed: "v0.14.9", version: "v0.13.12", wantErr: true},
+		{name: "not installed", version: "v0.14.9", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saveCluster(t, "upgrade", tt.installed, "")
+
+			rec := sh.Record(t)
+			rec.On("DOCKER_CONTEXT=test docker network inspect kind", sh.Response{Output: []byte("172.18.0.0/16\n")})
+
+			err := Upgrade("upgrade", tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Upgrade() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			wantImages := []string{}
+			want := tt.installed
+			if !tt.wantErr && tt.want != tt.installed {
+				wantImages = images(tt.want)
+				want = tt.want
+			}
+
+			if got := loaded(rec.Commands()); !reflect.DeepEqual(got, wantImages) {
+				t.Errorf("loaded images = %q, want %q", got, wantImages)
+			}
+
+			if got := versionOf(t, "upgrade"); got != want {
+				t.Errorf("version = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"github.com/utkarsh-pro/kindli/pkg/db"
+)
+
+// ClusterAddon is an add-on enabled in a cluster
+type ClusterAddon struct {
+	Cluster string
+	Addon   string
+	Version string
+}
+
+func NewClusterAddon(cluster, addon, version string) *ClusterAddon {
+	return &ClusterAddon{
+		Cluster: cluster,
+		Addon:   addon,
+		Version: version,
+	}
+}
+
+// Save records the add-on as enabled, the version is updated if the
+// add-on is already enabled
+func (ca *ClusterAddon) Save() error {
+	_, err := db.Instance().Exec(
+		`INSERT INTO cluster_addon (cluster, addon, version) VALUES (?, ?, ?)
+		ON CONFLICT (cluster, addon) DO UPDATE SET version = excluded.version`,
+		ca.Cluster,
+		ca.Addon,
+		ca.Version,
+	)
+
+	return err
+}
+
+func (ca *ClusterAddon) Delete() error {
+	_, err := db.Instance().Exec(`DELETE FROM cluster_addon WHERE cluster = ? AND addon = ?`, ca.Cluster, ca.Addon)
+
+	return err
+}
+
+// DeleteClusterAddons deletes the add-ons of the cluster
+func DeleteClusterAddons(cluster string) error {
+	_, err := db.Instance().Exec(`DELETE FROM cluster_addon WHERE cluster = ?`, cluster)
+
+	return err
+}
+
+// ListClusterAddon returns the add-ons enabled in the cluster
+func ListClusterAddon(cluster string) ([]*ClusterAddon, error) {
+	rows, err := db.Instance().Query(`SELECT cluster, addon, version FROM cluster_addon WHERE cluster = ? ORDER BY addon`, cluster)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addons := []*ClusterAddon{}
+	for rows.Next() {
+		ca := &ClusterAddon{}
+		if err := rows.Scan(&ca.Cluster, &ca.Addon, &ca.Version); err != nil {
+			return nil, err
+		}
+
+		addons = append(addons, ca)
+	}
+
+	return addons, rows.Err()
+}
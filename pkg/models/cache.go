@@ -0,0 +1,71 @@
+package models
+
+import (
+	"github.com/utkarsh-pro/kindli/pkg/db"
+)
+
+// Cache is a pull-through cache of an upstream registry running in the
+// docker daemon of a VM
+type Cache struct {
+	VM string `json:"vm" yaml:"vm"`
+	// Upstream is the host of the upstream registry as used in the image
+	// references, e.g. docker.io
+	Upstream string `json:"upstream" yaml:"upstream"`
+	// Remote is the URL of the upstream registry
+	Remote string `json:"remote" yaml:"remote"`
+	Image  string `json:"image" yaml:"image"`
+}
+
+func NewCache(vm, upstream string) *Cache {
+	return &Cache{VM: vm, Upstream: upstream}
+}
+
+func (c *Cache) Save() error {
+	_, err := db.Instance().Exec(
+		`INSERT INTO cache (vm, upstream, remote, image) VALUES (?, ?, ?, ?)`,
+		c.VM,
+		c.Upstream,
+		c.Remote,
+		c.Image,
+	)
+
+	return err
+}
+
+func (c *Cache) Delete() error {
+	_, err := db.Instance().Exec(`DELETE FROM cache WHERE vm = ? AND upstream = ?`, c.VM, c.Upstream)
+
+	return err
+}
+
+func (c *Cache) Exists() (bool, error) {
+	var count int
+	err := db.Instance().QueryRow(`SELECT COUNT(*) FROM cache WHERE vm = ? AND upstream = ?`, c.VM, c.Upstream).Scan(&count)
+
+	return count > 0, err
+}
+
+// ListCache returns the caches of the VM, or of all the VMs if vm is empty
+func ListCache(vm string) ([]*Cache, error) {
+	rows, err := db.Instance().Query(
+		`SELECT vm, upstream, remote, image FROM cache WHERE ? = '' OR vm = ? ORDER BY vm, upstream`,
+		vm,
+		vm,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	caches := []*Cache{}
+	for rows.Next() {
+		c := &Cache{}
+		if err := rows.Scan(&c.VM, &c.Upstream, &c.Remote, &c.Image); err != nil {
+			return nil, err
+		}
+
+		caches = append(caches, c)
+	}
+
+	return caches, rows.Err()
+}
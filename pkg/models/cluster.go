@@ -7,22 +7,25 @@ import (
 	"github.com/utkarsh-pro/kindli/pkg/utils"
 )
 
+const (
+	// ClusterRunning means the nodes of the cluster are running
+	ClusterRunning = "Running"
+	// ClusterStopped means the nodes of the cluster are stopped
+	ClusterStopped = "Stopped"
+	// ClusterPaused means the nodes of the cluster are paused
+	ClusterPaused = "Paused"
+)
+
 type Cluster struct {
 	ID             uint
 	Name           string
 	KindConfigPath string
 	VM             string
-}
-
-func ClusterPreload() {
-	db.RegisterPreload(`
-CREATE TABLE IF NOT EXISTS cluster (
-	id INTEGER PRIMARY KEY,
-	name TEXT,
-	kind_config_path TEXT UNIQUE,
-	vm TEXT,
-	FOREIGN KEY (vm) REFERENCES vm(name)
-);`)
+	// MetalLBVersion is the version of MetalLB installed in the cluster,
+	// empty if MetalLB is not installed
+	MetalLBVersion string
+	// Status is one of ClusterRunning, ClusterStopped or ClusterPaused
+	Status string
 }
 
 func NewCluster(name, kindConfigPath, vm string) *Cluster {
@@ -30,15 +33,32 @@ func NewCluster(name, kindConfigPath, vm string) *Cluster {
 		Name:           name,
 		KindConfigPath: kindConfigPath,
 		VM:             vm,
+		Status:         ClusterRunning,
 	}
 }
 
 func (cluster *Cluster) Save() error {
 	_, err := db.Instance().Exec(
-		`INSERT INTO cluster (name, kind_config_path, vm) VALUES (?, ?, ?)`,
+		`INSERT INTO cluster (name, kind_config_path, vm, metallb_version, status) VALUES (?, ?, ?, ?, ?)`,
 		cluster.Name,
 		cluster.KindConfigPath,
 		cluster.VM,
+		cluster.MetalLBVersion,
+		cluster.Status,
+	)
+
+	return err
+}
+
+// Update updates the stored cluster with the same name
+func (cluster *Cluster) Update() error {
+	_, err := db.Instance().Exec(
+		`UPDATE cluster SET kind_config_path = ?, vm = ?, metallb_version = ?, status = ? WHERE name = ?`,
+		cluster.KindConfigPath,
+		cluster.VM,
+		cluster.MetalLBVersion,
+		cluster.Status,
+		cluster.Name,
 	)
 
 	return err
@@ -58,11 +78,16 @@ func (cluster *Cluster) Exists() (bool, error) {
 }
 
 func (cluster *Cluster) GetByName() error {
-	err := db.Instance().QueryRow(`SELECT * FROM cluster WHERE name = ?`, cluster.Name).Scan(
+	err := db.Instance().QueryRow(
+		`SELECT id, name, kind_config_path, vm, metallb_version, status FROM cluster WHERE name = ?`,
+		cluster.Name,
+	).Scan(
 		&cluster.ID,
 		&cluster.Name,
 		&cluster.KindConfigPath,
 		&cluster.VM,
+		&cluster.MetalLBVersion,
+		&cluster.Status,
 	)
 
 	return err
@@ -70,7 +95,7 @@ func (cluster *Cluster) GetByName() error {
 
 func ListCluster() ([]Cluster, error) {
 	var clusters []Cluster
-	rows, err := db.Instance().Query(`SELECT * FROM cluster`)
+	rows, err := db.Instance().Query(`SELECT id, name, kind_config_path, vm, metallb_version, status FROM cluster`)
 
 	if err != nil {
 		return nil, err
@@ -83,6 +108,8 @@ func ListCluster() ([]Cluster, error) {
 			&cluster.Name,
 			&cluster.KindConfigPath,
 			&cluster.VM,
+			&cluster.MetalLBVersion,
+			&cluster.Status,
 		)
 
 		if err != nil {
@@ -95,29 +122,9 @@ func ListCluster() ([]Cluster, error) {
 	return clusters, nil
 }
 
-func (cluster *Cluster) AssignID() error {
-	clusters, err := ListCluster()
-	if err != nil {
-		return err
-	}
-
-	idIdx := make([]bool, 100)
-	for _, c := range clusters {
-		if int(c.ID) >= len(idIdx) {
-			panic("Cluster ID is too large")
-		}
-
-		idIdx[c.ID] = true
-	}
-
-	for i := range idIdx {
-		if !idIdx[i] {
-			cluster.ID = uint(i)
-			return nil
-		}
-	}
-
-	return nil
+// IPOwner returns the owner of the IP allocations of the cluster
+func (cluster *Cluster) IPOwner() string {
+	return "cluster/" + cluster.Name
 }
 
 func (cluster *Cluster) LoadConfigFromDisk() ([]byte, error) {
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"github.com/utkarsh-pro/kindli/pkg/db"
+)
+
+// Names of the IP pools known to kindli
+const (
+	IPPoolServiceIPv4      = "service-ipv4"
+	IPPoolPodIPv4          = "pod-ipv4"
+	IPPoolLoadBalancerIPv4 = "loadbalancer-ipv4"
+	IPPoolServiceIPv6      = "service-ipv6"
+	IPPoolPodIPv6          = "pod-ipv6"
+	IPPoolLoadBalancerIPv6 = "loadbalancer-ipv6"
+	IPPoolKindNetworkIPv4  = "kind-network-ipv4"
+	IPPoolKindNetworkIPv6  = "kind-network-ipv6"
+)
+
+// DefaultIPPools are the pools used unless configured otherwise
+//
+// LoadBalancer pools without a CIDR allocate from the subnet of the "kind"
+// docker network of the VM. The kind network pools hold the subnets of the
+// "kind" docker networks of the VMs.
+//
+// The migrations insert frozen copies of the pools, changing the defaults
+// here doesn't change the pools of existing stores.
+var DefaultIPPools = []*IPPool{
+	NewIPPool(IPPoolServiceIPv4, "10.96.0.0/12", 20),
+	NewIPPool(IPPoolPodIPv4, "10.128.0.0/9", 18),
+	NewIPPool(IPPoolLoadBalancerIPv4, "", 24),
+	NewIPPool(IPPoolServiceIPv6, "fd00:10::/32", 112),
+	NewIPPool(IPPoolPodIPv6, "fd00:11::/32", 56),
+	NewIPPool(IPPoolLoadBalancerIPv6, "", 120),
+	NewIPPool(IPPoolKindNetworkIPv4, "172.16.0.0/12", 16),
+	NewIPPool(IPPoolKindNetworkIPv6, "fc00:f853:ccd::/48", 64),
+}
+
+// IPPool is a parent CIDR from which subnets of a fixed size are allocated
+type IPPool struct {
+	Name      string `json:"name" yaml:"name"`
+	CIDR      string `json:"cidr" yaml:"cidr"`
+	PrefixLen int    `json:"prefixLen" yaml:"prefixLen"`
+}
+
+func NewIPPool(name, cidr string, prefixLen int) *IPPool {
+	return &IPPool{
+		Name:      name,
+		CIDR:      cidr,
+		PrefixLen: prefixLen,
+	}
+}
+
+// Save creates the pool or updates it if it already exists
+func (pool *IPPool) Save() error {
+	_, err := db.Instance().Exec(
+		`INSERT INTO ip_pool (name, cidr, prefix_len) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET cidr = excluded.cidr, prefix_len = excluded.prefix_len`,
+		pool.Name,
+		pool.CIDR,
+		pool.PrefixLen,
+	)
+
+	return err
+}
+
+func (pool *IPPool) GetByName() error {
+	return db.Instance().QueryRow(
+		`SELECT name, cidr, prefix_len FROM ip_pool WHERE name = ?`,
+		pool.Name,
+	).Scan(&pool.Name, &pool.CIDR, &pool.PrefixLen)
+}
+
+func ListIPPool() ([]*IPPool, error) {
+	rows, err := db.Instance().Query(`SELECT name, cidr, prefix_len FROM ip_pool ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pools := []*IPPool{}
+	for rows.Next() {
+		pool := &IPPool{}
+		if err := rows.Scan(&pool.Name, &pool.CIDR, &pool.PrefixLen); err != nil {
+			return nil, err
+		}
+
+		pools = append(pools, pool)
+	}
+
+	return pools, rows.Err()
+}
+
+// IPAllocation is a subnet allocated from a pool to an owner
+type IPAllocation struct {
+	ID    uint   `json:"id" yaml:"id"`
+	Pool  string `json:"pool" yaml:"pool"`
+	CIDR  string `json:"cidr" yaml:"cidr"`
+	Owner string `json:"owner" yaml:"owner"`
+}
+
+func NewIPAllocation(pool, cidr, owner string) *IPAllocation {
+	return &IPAllocation{
+		Pool:  pool,
+		CIDR:  cidr,
+		Owner: owner,
+	}
+}
+
+func (alloc *IPAllocation) Save() error {
+	_, err := db.Instance().Exec(
+		`INSERT INTO ip_allocation (pool, cidr, owner) VALUES (?, ?, ?)`,
+		alloc.Pool,
+		alloc.CIDR,
+		alloc.Owner,
+	)
+
+	return err
+}
+
+func (alloc *IPAllocation) Delete() error {
+	_, err := db.Instance().Exec(`DELETE FROM ip_allocation WHERE cidr = ?`, alloc.CIDR)
+
+	return err
+}
+
+// DeleteIPAllocationsByOwner deletes all the allocations of the owner
+func DeleteIPAllocationsByOwner(owner string) error {
+	_, err := db.Instance().Exec(`DELETE FROM ip_allocation WHERE owner = ?`, owner)
+
+	return err
+}
+
+func ListIPAllocation() ([]*IPAllocation, error) {
+	rows, err := db.Instance().Query(`SELECT id, pool, cidr, owner FROM ip_allocation ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	allocs := []*IPAllocation{}
+	for rows.Next() {
+		alloc := &IPAllocation{}
+		if err := rows.Scan(&alloc.ID, &alloc.Pool, &alloc.CIDR, &alloc.Owner); err != nil {
+			return nil, err
+		}
+
+		allocs = append(allocs, alloc)
+	}
+
+	return allocs, rows.Err()
+}
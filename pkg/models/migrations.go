@@ -0,0 +1,283 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/db"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// RegisterMigrations registers the schema migrations of all the models
+//
+// New migrations must be appended with the next version, released
+// migrations must never be changed.
+func RegisterMigrations() {
+	db.RegisterMigrations(
+		db.Migration{
+			Version:     1,
+			Description: "create vm table",
+			Up: db.Exec(`
+CREATE TABLE IF NOT EXISTS vm (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT UNIQUE,
+	lima_config_path TEXT UNIQUE,
+	docker_port INTEGER UNIQUE
+);`),
+		},
+		db.Migration{
+			Version:     2,
+			Description: "create cluster table",
+			Up: db.Exec(`
+CREATE TABLE IF NOT EXISTS cluster (
+	id INTEGER PRIMARY KEY,
+	name TEXT,
+	kind_config_path TEXT UNIQUE,
+	vm TEXT,
+	FOREIGN KEY (vm) REFERENCES vm(name)
+);`),
+		},
+		db.Migration{
+			Version:     3,
+			Description: "add provider to vm table",
+			Up:          db.AddColumn("vm", "provider", fmt.Sprintf("TEXT NOT NULL DEFAULT '%s'", DefaultVMProvider)),
+		},
+		db.Migration{
+			Version:     4,
+			Description: "create ip_pool and ip_allocation tables",
+			Up:          createIPAMTables,
+		},
+		db.Migration{
+			Version:     5,
+			Description: "record the subnets of existing clusters as ip allocations",
+			Up:          backfillIPAllocations,
+		},
+		db.Migration{
+			Version:     6,
+			Description: "add metallb_version to cluster table",
+			Up:          addMetalLBVersion,
+		},
+		db.Migration{
+			Version:     7,
+			Description: "create cluster_addon table",
+			Up: db.Exec(`
+CREATE TABLE IF NOT EXISTS cluster_addon (
+	cluster TEXT NOT NULL,
+	addon TEXT NOT NULL,
+	version TEXT NOT NULL,
+	PRIMARY KEY (cluster, addon)
+);`),
+		},
+		db.Migration{
+			Version:     8,
+			Description: "create snapshot table",
+			Up: db.Exec(`
+CREATE TABLE IF NOT EXISTS snapshot (
+	cluster TEXT NOT NULL,
+	name TEXT NOT NULL,
+	vm TEXT NOT NULL,
+	path TEXT NOT NULL,
+	nodes TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (cluster, name)
+);`),
+		},
+		db.Migration{
+			Version:     9,
+			Description: "add status to cluster table",
+			Up:          db.AddColumn("cluster", "status", fmt.Sprintf("TEXT NOT NULL DEFAULT '%s'", ClusterRunning)),
+		},
+		db.Migration{
+			Version:     10,
+			Description: "create registry table",
+			Up: db.Exec(`
+CREATE TABLE IF NOT EXISTS registry (
+	vm TEXT PRIMARY KEY,
+	port INTEGER NOT NULL UNIQUE,
+	image TEXT NOT NULL
+);`),
+		},
+		db.Migration{
+			Version:     11,
+			Description: "create cache table",
+			Up: db.Exec(`
+CREATE TABLE IF NOT EXISTS cache (
+	vm TEXT NOT NULL,
+	upstream TEXT NOT NULL,
+	remote TEXT NOT NULL,
+	image TEXT NOT NULL,
+	PRIMARY KEY (vm, upstream)
+);`),
+		},
+		db.Migration{
+			Version:     12,
+			Description: "add kind network ip pools",
+			Up: db.Exec(`
+INSERT OR IGNORE INTO ip_pool (name, cidr, prefix_len) VALUES
+	('kind-network-ipv4', '172.16.0.0/12', 16),
+	('kind-network-ipv6', 'fc00:f853:ccd::/48', 64);`),
+		},
+	)
+}
+
+// LegacyMetalLBVersion is the version of MetalLB installed by the kindli
+// versions which didn't record the version
+const LegacyMetalLBVersion = "v0.13.5"
+
+// addMetalLBVersion adds the metallb_version column, clusters which have a
+// persisted metallb config got the legacy version installed
+func addMetalLBVersion(tx *sql.Tx) error {
+	if err := db.AddColumn("cluster", "metallb_version", "TEXT NOT NULL DEFAULT ''")(tx); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT name FROM cluster`)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+
+		names = append(names, name)
+	}
+	rows.Close()
+
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(config.Dir(), "metallb", name+".yaml")); err != nil {
+			continue
+		}
+
+		if _, err := tx.Exec(`UPDATE cluster SET metallb_version = ? WHERE name = ?`, LegacyMetalLBVersion, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createIPAMTables(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS ip_pool (
+	name TEXT PRIMARY KEY,
+	cidr TEXT NOT NULL,
+	prefix_len INTEGER NOT NULL
+);`); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS ip_allocation (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	pool TEXT NOT NULL,
+	cidr TEXT UNIQUE NOT NULL,
+	owner TEXT NOT NULL
+);`); err != nil {
+		return err
+	}
+
+	// The pools are frozen as they were when the migration was released,
+	// pools added later have their own migrations
+	_, err := tx.Exec(`
+INSERT OR IGNORE INTO ip_pool (name, cidr, prefix_len) VALUES
+	('service-ipv4', '10.96.0.0/12', 20),
+	('pod-ipv4', '10.128.0.0/9', 18),
+	('loadbalancer-ipv4', '', 24),
+	('service-ipv6', 'fd00:10::/32', 112),
+	('pod-ipv6', 'fd00:11::/32', 56),
+	('loadbalancer-ipv6', '', 120);`)
+
+	return err
+}
+
+// backfillIPAllocations records the subnets of the clusters created before
+// the IP allocations were tracked so that new clusters don't reuse them.
+//
+// The subnets are read from the persisted kind and metallb configs, clusters
+// whose configs are missing are skipped.
+func backfillIPAllocations(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT name, kind_config_path FROM cluster`)
+	if err != nil {
+		return err
+	}
+
+	clusters := []*Cluster{}
+	for rows.Next() {
+		cluster := &Cluster{}
+		if err := rows.Scan(&cluster.Name, &cluster.KindConfigPath); err != nil {
+			rows.Close()
+			return err
+		}
+
+		clusters = append(clusters, cluster)
+	}
+	rows.Close()
+
+	for _, cluster := range clusters {
+		subnets := map[string]string{}
+
+		if cfg, err := cluster.LoadConfigAsYAMLFromDisk(); err == nil {
+			for key, pools := range map[string][2]string{
+				"serviceSubnet": {IPPoolServiceIPv4, IPPoolServiceIPv6},
+				"podSubnet":     {IPPoolPodIPv4, IPPoolPodIPv6},
+			} {
+				val, _ := utils.MapGet(cfg, "networking", key)
+				str, _ := val.(string)
+				for _, subnet := range strings.Split(str, ",") {
+					subnets[strings.TrimSpace(subnet)] = familyPool(subnet, pools)
+				}
+			}
+		}
+
+		metallbCfgPath := filepath.Join(config.Dir(), "metallb", cluster.Name+".yaml")
+		if byt, err := os.ReadFile(metallbCfgPath); err == nil {
+			if cfg, err := utils.MapFromYAML(byt); err == nil {
+				val, _ := utils.MapGet(cfg, "spec", "addresses", "0")
+				str, _ := val.(string)
+				subnets[str] = familyPool(str, [2]string{IPPoolLoadBalancerIPv4, IPPoolLoadBalancerIPv6})
+			}
+		}
+
+		for subnet, pool := range subnets {
+			if pool == "" {
+				continue
+			}
+
+			if _, err := tx.Exec(
+				`INSERT OR IGNORE INTO ip_allocation (pool, cidr, owner) VALUES (?, ?, ?)`,
+				pool,
+				subnet,
+				cluster.IPOwner(),
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// familyPool returns the first pool for an IPv4 subnet and the second one
+// for an IPv6 subnet, empty string is returned if subnet is invalid
+func familyPool(subnet string, pools [2]string) string {
+	ip, _, err := net.ParseCIDR(strings.TrimSpace(subnet))
+	if err != nil {
+		return ""
+	}
+
+	if ip.To4() != nil {
+		return pools[0]
+	}
+
+	return pools[1]
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/utkarsh-pro/kindli/pkg/db"
+)
+
+// Registry is the local registry running in the docker daemon of a VM
+type Registry struct {
+	VM string `json:"vm" yaml:"vm"`
+	// Port is the port the registry is published on by the VM
+	Port  int    `json:"port" yaml:"port"`
+	Image string `json:"image" yaml:"image"`
+}
+
+func NewRegistry(vm string) *Registry {
+	return &Registry{VM: vm}
+}
+
+func (r *Registry) Save() error {
+	_, err := db.Instance().Exec(
+		`INSERT INTO registry (vm, port, image) VALUES (?, ?, ?)`,
+		r.VM,
+		r.Port,
+		r.Image,
+	)
+
+	return err
+}
+
+func (r *Registry) Delete() error {
+	_, err := db.Instance().Exec(`DELETE FROM registry WHERE vm = ?`, r.VM)
+
+	return err
+}
+
+func (r *Registry) GetByVM() error {
+	return db.Instance().
+		QueryRow(`SELECT port, image FROM registry WHERE vm = ?`, r.VM).
+		Scan(&r.Port, &r.Image)
+}
+
+func ListRegistry() ([]*Registry, error) {
+	rows, err := db.Instance().Query(`SELECT vm, port, image FROM registry ORDER BY vm`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	registries := []*Registry{}
+	for rows.Next() {
+		r := &Registry{}
+		if err := rows.Scan(&r.VM, &r.Port, &r.Image); err != nil {
+			return nil, err
+		}
+
+		registries = append(registries, r)
+	}
+
+	return registries, rows.Err()
+}
+
+// GetMaxRegistryPort returns the highest port assigned to a registry, 0 if
+// there are no registries
+func GetMaxRegistryPort() (int, error) {
+	var maxPort sql.NullInt64
+	if err := db.Instance().QueryRow(`SELECT MAX(port) FROM registry`).Scan(&maxPort); err != nil {
+		return 0, err
+	}
+
+	return int(maxPort.Int64), nil
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/utkarsh-pro/kindli/pkg/db"
+)
+
+// Snapshot is a point in time copy of a cluster
+type Snapshot struct {
+	Cluster string `json:"cluster" yaml:"cluster"`
+	Name    string `json:"name" yaml:"name"`
+	VM      string `json:"vm" yaml:"vm"`
+	// Path is the directory holding the persisted configs and the kindli
+	// state of the cluster
+	Path string `json:"path" yaml:"path"`
+	// Nodes are the names of the kind node containers
+	Nodes     []string `json:"nodes" yaml:"nodes"`
+	CreatedAt string   `json:"createdAt" yaml:"createdAt"`
+}
+
+func NewSnapshot(cluster, name string) *Snapshot {
+	return &Snapshot{
+		Cluster: cluster,
+		Name:    name,
+	}
+}
+
+func (s *Snapshot) Save() error {
+	_, err := db.Instance().Exec(
+		`INSERT INTO snapshot (cluster, name, vm, path, nodes, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		s.Cluster,
+		s.Name,
+		s.VM,
+		s.Path,
+		strings.Join(s.Nodes, ","),
+		s.CreatedAt,
+	)
+
+	return err
+}
+
+func (s *Snapshot) Delete() error {
+	_, err := db.Instance().Exec(`DELETE FROM snapshot WHERE cluster = ? AND name = ?`, s.Cluster, s.Name)
+
+	return err
+}
+
+func (s *Snapshot) GetByName() error {
+	var nodes string
+	err := db.Instance().
+		QueryRow(`SELECT vm, path, nodes, created_at FROM snapshot WHERE cluster = ? AND name = ?`, s.Cluster, s.Name).
+		Scan(&s.VM, &s.Path, &nodes, &s.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	s.Nodes = splitNodes(nodes)
+	return nil
+}
+
+// ListSnapshot returns the snapshots of the cluster, or of all the clusters
+// if cluster is empty
+func ListSnapshot(cluster string) ([]*Snapshot, error) {
+	rows, err := db.Instance().Query(
+		`SELECT cluster, name, vm, path, nodes, created_at FROM snapshot WHERE ? = '' OR cluster = ? ORDER BY cluster, created_at`,
+		cluster,
+		cluster,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []*Snapshot{}
+	for rows.Next() {
+		s := &Snapshot{}
+		var nodes string
+		if err := rows.Scan(&s.Cluster, &s.Name, &s.VM, &s.Path, &nodes, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		s.Nodes = splitNodes(nodes)
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, rows.Err()
+}
+
+func splitNodes(nodes string) []string {
+	if nodes == "" {
+		return nil
+	}
+
+	return strings.Split(nodes, ",")
+}
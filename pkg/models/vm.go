@@ -7,21 +7,24 @@ import (
 	"github.com/utkarsh-pro/kindli/pkg/db"
 )
 
+// DefaultVMProvider is the provider used by VMs created before
+// providers were introduced
+const DefaultVMProvider = "lima"
+
+// Addresses of the network shared by the host and the VMs
+const (
+	VMNetworkIPv4 = "192.168.105.0/24"
+	VMNetworkIPv6 = "fd00:105::/64"
+	HostIPv4      = "192.168.105.1"
+	HostIPv6      = "fd00:105::1"
+)
+
 type VM struct {
 	ID             uint
 	Name           string
 	LimaConfigPath string
 	DockerPort     int
-}
-
-func VMPreload() {
-	db.RegisterPreload(`
-CREATE TABLE IF NOT EXISTS vm (
-	id INTEGER PRIMARY KEY AUTOINCREMENT,
-	name TEXT UNIQUE,
-	lima_config_path TEXT UNIQUE,
-	docker_port INTEGER UNIQUE
-);`)
+	Provider       string
 }
 
 func NewVM(name, limaConfigPath string, dockerPort int) *VM {
@@ -29,26 +32,60 @@ func NewVM(name, limaConfigPath string, dockerPort int) *VM {
 		Name:           name,
 		LimaConfigPath: limaConfigPath,
 		DockerPort:     dockerPort,
+		Provider:       DefaultVMProvider,
 	}
 }
 
+// Save saves the VM, if the VM has an ID then the VM is saved with the
+// same ID otherwise the next ID is assigned to the VM
 func (vm *VM) Save() error {
+	if vm.ID != 0 {
+		_, err := db.Instance().Exec(
+			`INSERT INTO vm (id, name, lima_config_path, docker_port, provider) VALUES (?, ?, ?, ?, ?)`,
+			vm.ID,
+			vm.Name,
+			vm.LimaConfigPath,
+			vm.DockerPort,
+			vm.Provider,
+		)
+
+		return err
+	}
+
 	_, err := db.Instance().Exec(
-		`INSERT INTO vm (name, lima_config_path, docker_port) VALUES (?, ?, ?)`,
+		`INSERT INTO vm (name, lima_config_path, docker_port, provider) VALUES (?, ?, ?, ?)`,
 		vm.Name,
 		vm.LimaConfigPath,
 		vm.DockerPort,
+		vm.Provider,
 	)
 
 	return err
 }
 
+// Delete deletes the VM along with its registry and caches, which live in
+// the VM, and releases the subnets of its kind network
 func (vm *VM) Delete() error {
+	for _, table := range []string{"registry", "cache"} {
+		if _, err := db.Instance().Exec(fmt.Sprintf(`DELETE FROM %s WHERE vm = ?`, table), vm.Name); err != nil {
+			return err
+		}
+	}
+
+	if err := DeleteIPAllocationsByOwner(vm.IPOwner()); err != nil {
+		return err
+	}
+
 	_, err := db.Instance().Exec(`DELETE FROM vm WHERE name = ?`, vm.Name)
 
 	return err
 }
 
+// IPOwner returns the owner of the IP allocations of the VM
+func (vm *VM) IPOwner() string {
+	return "vm/" + vm.Name
+}
+
 func (vm *VM) Exists() (bool, error) {
 	var count int
 	err := db.Instance().QueryRow(`SELECT COUNT(*) FROM vm WHERE name = ?`, vm.Name).Scan(&count)
@@ -57,7 +94,10 @@ func (vm *VM) Exists() (bool, error) {
 }
 
 func (vm *VM) GetByName() error {
-	err := db.Instance().QueryRow(`SELECT * FROM vm WHERE name = ?`, vm.Name).Scan(&vm.ID, &vm.Name, &vm.LimaConfigPath, &vm.DockerPort)
+	err := db.Instance().QueryRow(
+		`SELECT id, name, lima_config_path, docker_port, provider FROM vm WHERE name = ?`,
+		vm.Name,
+	).Scan(&vm.ID, &vm.Name, &vm.LimaConfigPath, &vm.DockerPort, &vm.Provider)
 
 	if err != nil {
 		return err
@@ -93,8 +133,10 @@ func GetVMIPv4(id uint) string {
 	return fmt.Sprintf("192.168.105.%d", id+10)
 }
 
+// GetVMIPv6 returns the IPv6 address of the VM, its last group has the same
+// digits as the last octet of the IPv4 address of the VM
 func GetVMIPv6(id uint) string {
-	return fmt.Sprintf("::ffff:%s", GetVMIPv4(id))
+	return fmt.Sprintf("fd00:105::%d", id+10)
 }
 
 func GetNextVMID() (uint, error) {
@@ -115,14 +157,14 @@ func GetNextVMID() (uint, error) {
 func ListVM() ([]*VM, error) {
 	var vms []*VM
 
-	rows, err := db.Instance().Query(`SELECT * FROM vm`)
+	rows, err := db.Instance().Query(`SELECT id, name, lima_config_path, docker_port, provider FROM vm`)
 	if err != nil {
 		return nil, err
 	}
 
 	for rows.Next() {
 		var vm VM
-		err := rows.Scan(&vm.ID, &vm.Name, &vm.LimaConfigPath, &vm.DockerPort)
+		err := rows.Scan(&vm.ID, &vm.Name, &vm.LimaConfigPath, &vm.DockerPort, &vm.Provider)
 		if err != nil {
 			return nil, err
 		}
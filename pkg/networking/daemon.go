@@ -0,0 +1,325 @@
+package networking
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
+)
+
+// DefaultSyncInterval is the interval at which the daemon syncs the routes
+const DefaultSyncInterval = 10 * time.Second
+
+// Rule is a route on the host or an iptables rule in a VM managed by kindli
+type Rule struct {
+	VM        string `json:"vm" yaml:"vm"`
+	Type      string `json:"type" yaml:"type"`
+	Rule      string `json:"rule" yaml:"rule"`
+	Installed bool   `json:"installed" yaml:"installed"`
+}
+
+// RuleList is a list of Rule
+type RuleList []Rule
+
+func (l RuleList) Header(wide bool) []string {
+	return []string{"VM", "TYPE", "RULE", "INSTALLED"}
+}
+
+func (l RuleList) Rows(wide bool) [][]string {
+	rows := [][]string{}
+	for _, r := range l {
+		rows = append(rows, []string{r.VM, r.Type, r.Rule, strconv.FormatBool(r.Installed)})
+	}
+
+	return rows
+}
+
+// Daemon keeps the routes to the kind networks of all the running VMs in
+// sync until ctx is done, the routes it installed are removed afterwards
+//
+// The VMs and their kind networks are checked every interval, routes are
+// added for the VMs which are started or whose kind network appears or
+// changes, and removed for the VMs which are stopped or deleted.
+func Daemon(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+
+	logrus.Infof("Syncing routes every %s", interval)
+
+	synced := map[string][]route{}
+	collided := map[string]bool{}
+	for {
+		sync(synced, collided)
+
+		select {
+		case <-ctx.Done():
+			logrus.Info("Removing routes...")
+			for vmName, routes := range synced {
+				deleteRoutes(vmName, routes)
+			}
+
+			logrus.Info("✅ Completed cleanup")
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Status returns the routes on the host and the iptables rules of the VM,
+// or of all the running VMs if vmName is empty
+func Status(vmName string) (RuleList, error) {
+	vms, err := routedVMs(vmName)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := RuleList{}
+	for _, v := range vms {
+		routes, err := vmRoutes(v)
+		if err != nil {
+			logrus.Debugf("VM %s has no kind network: %s", v, err)
+			continue
+		}
+
+		hostIf, err := hostInterface(v)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range routes {
+			rules = append(rules, Rule{VM: v, Type: "route", Rule: r.String(), Installed: r.installed()})
+		}
+
+		fwRules, err := forwardRules(v, hostIf, routes)
+		if err != nil {
+			return nil, err
+		}
+
+		installed, err := installedRules(NewIPTables(v))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range fwRules {
+			rules = append(rules, Rule{
+				VM:        v,
+				Type:      command(r.IPv6),
+				Rule:      fmt.Sprintf("-A %s %s", Chain, r),
+				Installed: ruleInstalled(installed, r),
+			})
+		}
+	}
+
+	return rules, nil
+}
+
+// sync installs the routes of the running VMs and removes the routes which
+// are no longer needed, synced holds the routes installed by the previous
+// sync and is updated in place
+//
+// VMs whose kind networks collide with the one of a VM which is already
+// routed are skipped, collided holds the VMs which were reported so that
+// they are reported only once.
+func sync(synced map[string][]route, collided map[string]bool) {
+	vms, err := routedVMs("")
+	if err != nil {
+		logrus.Warnf("failed to list VMs: %s", err)
+		return
+	}
+
+	// The VMs routed by the previous sync keep their routes
+	sort.SliceStable(vms, func(i, j int) bool {
+		return synced[vms[i]] != nil && synced[vms[j]] == nil
+	})
+
+	desired := map[string][]route{}
+	all := []route{}
+	for _, v := range vms {
+		routes, err := vmRoutes(v)
+		if err != nil {
+			logrus.Debugf("VM %s has no kind network: %s", v, err)
+			continue
+		}
+
+		if r, other, ok := firstCollision(all, routes); ok {
+			if !collided[v] {
+				logrus.Warn(collisionError(v, other, r))
+				collided[v] = true
+			}
+
+			continue
+		}
+
+		delete(collided, v)
+		desired[v] = routes
+		all = append(all, routes...)
+	}
+
+	for vmName, routes := range synced {
+		stale := []route{}
+		for _, r := range routes {
+			if !contains(desired[vmName], r) {
+				stale = append(stale, r)
+			}
+		}
+
+		deleteRoutes(vmName, stale)
+		delete(synced, vmName)
+	}
+
+	for vmName, routes := range desired {
+		synced[vmName] = routes
+
+		// The rules of the VM are lost when the VM restarts, check them on
+		// every sync
+		if err := setupPacketRoutingInsideVM(vmName, routes); err != nil {
+			logrus.Warnf("failed to setup packet routing inside VM %s: %s", vmName, err)
+			continue
+		}
+
+		for _, r := range routes {
+			if r.installed() {
+				continue
+			}
+
+			if err := r.add(); err != nil {
+				logrus.Warnf("failed to add route %s of VM %s: %s", r, vmName, err)
+				continue
+			}
+
+			logrus.Infof("✅ Added route %s of VM %s", r, vmName)
+		}
+	}
+}
+
+// routedVMs returns the running VMs whose kind networks are routed from the
+// host, only vmName is considered if it is not empty
+func routedVMs(vmName string) ([]string, error) {
+	vms, err := models.ListVM()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VMs: %w", err)
+	}
+
+	names := []string{}
+	for _, v := range vms {
+		if vmName != "" && v.Name != vmName {
+			continue
+		}
+
+		provider, err := vm.GetProvider(v.Provider)
+		if err != nil {
+			return nil, err
+		}
+
+		if !provider.Routed() {
+			continue
+		}
+
+		running, err := provider.Running(v.Name)
+		if err != nil {
+			logrus.Debugf("failed to check if VM %s is running: %s", v.Name, err)
+			continue
+		}
+
+		if running {
+			names = append(names, v.Name)
+		}
+	}
+
+	return names, nil
+}
+
+func deleteRoutes(vmName string, routes []route) {
+	for _, r := range routes {
+		if !r.installed() {
+			continue
+		}
+
+		if err := r.delete(); err != nil {
+			logrus.Warnf("failed to remove route %s of VM %s: %s", r, vmName, err)
+			continue
+		}
+
+		logrus.Infof("✅ Removed route %s of VM %s", r, vmName)
+	}
+}
+
+// firstCollision returns the first of the routes which collides with the
+// routed ones, along with the VM of the routed one
+func firstCollision(routed, routes []route) (route, string, bool) {
+	for _, r := range routes {
+		for _, x := range routed {
+			if collides([]route{x}, r) {
+				return r, x.vm, true
+			}
+		}
+	}
+
+	return route{}, "", false
+}
+
+func contains(routes []route, r route) bool {
+	for _, x := range routes {
+		if x == r {
+			return true
+		}
+	}
+
+	return false
+}
+
+// InstallService installs and starts the user service running the daemon,
+// a systemd user unit on Linux and a launchd agent on MacOS, and returns
+// the path of the service file. The daemon serves DNS on dnsAddress, see
+// dns.Serve.
+//
+// The daemon manages the routes with sudo, which must not prompt for a
+// password when run by the service.
+func InstallService(interval time.Duration, dnsAddress string) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to find kindli executable: %w", err)
+	}
+
+	path := serviceFile()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create service directory: %w", err)
+	}
+
+	args := []string{exe, "network", "daemon", "--interval", interval.String(), "--dns", dnsAddress}
+	if err := os.WriteFile(path, []byte(serviceDefinition(args)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write service file: %w", err)
+	}
+
+	if err := enableService(path); err != nil {
+		return "", fmt.Errorf("failed to start service: %w", err)
+	}
+
+	return path, nil
+}
+
+// UninstallService stops and removes the user service running the daemon
+func UninstallService() error {
+	path := serviceFile()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("service is not installed")
+	}
+
+	if err := disableService(path); err != nil {
+		return fmt.Errorf("failed to stop service: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove service file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package networking
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+const serviceLabel = "dev.kindli.network"
+
+const launchdAgent = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+  <key>Label</key>
+  <string>%s</string>
+  <key>ProgramArguments</key>
+  <array>
+%s
+  </array>
+  <key>EnvironmentVariables</key>
+  <dict>
+    <key>PATH</key>
+    <string>%s</string>
+  </dict>
+  <key>RunAtLoad</key>
+  <true/>
+  <key>KeepAlive</key>
+  <true/>
+  <key>StandardOutPath</key>
+  <string>%s</string>
+  <key>StandardErrorPath</key>
+  <string>%s</string>
+</dict>
+</plist>
+`
+
+// serviceFile returns the path of the launchd agent of the daemon
+func serviceFile() string {
+	return filepath.Join(config.Home(), "Library", "LaunchAgents", serviceLabel+".plist")
+}
+
+func serviceDefinition(args []string) string {
+	lines := []string{}
+	for _, arg := range args {
+		lines = append(lines, fmt.Sprintf("    <string>%s</string>", html.EscapeString(arg)))
+	}
+
+	log := html.EscapeString(filepath.Join(config.Dir(), "network-daemon.log"))
+	return fmt.Sprintf(launchdAgent, serviceLabel, strings.Join(lines, "\n"), html.EscapeString(os.Getenv("PATH")), log, log)
+}
+
+func enableService(path string) error {
+	return sh.NewCommand("launchctl", "load", "-w", path).RunSilent()
+}
+
+func disableService(path string) error {
+	return sh.NewCommand("launchctl", "unload", "-w", path).RunSilent()
+}
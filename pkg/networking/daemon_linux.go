@@ -0,0 +1,54 @@
+package networking
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+const serviceName = "kindli-network.service"
+
+const systemdUnit = `[Unit]
+Description=kindli network daemon
+After=network-online.target
+
+[Service]
+Environment=%s
+ExecStart=%s
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=default.target
+`
+
+// serviceFile returns the path of the systemd user unit of the daemon
+func serviceFile() string {
+	return filepath.Join(config.Home(), ".config", "systemd", "user", serviceName)
+}
+
+func serviceDefinition(args []string) string {
+	quoted := []string{}
+	for _, arg := range args {
+		quoted = append(quoted, strconv.Quote(arg))
+	}
+
+	return fmt.Sprintf(systemdUnit, strconv.Quote("PATH="+os.Getenv("PATH")), strings.Join(quoted, " "))
+}
+
+func enableService(path string) error {
+	if err := sh.NewCommand("systemctl", "--user", "daemon-reload").RunSilent(); err != nil {
+		return err
+	}
+
+	return sh.NewCommand("systemctl", "--user", "enable", "--now", serviceName).RunSilent()
+}
+
+func disableService(path string) error {
+	return sh.NewCommand("systemctl", "--user", "disable", "--now", serviceName).RunSilent()
+}
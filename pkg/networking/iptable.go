@@ -1,56 +1,182 @@
 package networking
 
-import "strings"
+import (
+	"fmt"
+	"strings"
 
-// IPTable is a wrapper around iptables command
-type IPTable struct {
-	sudo          bool
-	table         string
-	cmd           string
-	specification string
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
+)
+
+// Chain is the chain of the filter table holding all the rules of kindli,
+// it is jumped to from the FORWARD chain. Rules outside of it are never
+// touched by kindli.
+const Chain = "KINDLI"
+
+// Protocol is the protocol matched by an IPTableRule
+type Protocol string
+
+const (
+	TCP  Protocol = "tcp"
+	UDP  Protocol = "udp"
+	ICMP Protocol = "icmp"
+)
+
+// IPTableRule is a rule of the kindli chain
+type IPTableRule struct {
+	IPv6         bool
+	Protocol     Protocol
+	Source       string
+	Destination  string
+	InInterface  string
+	OutInterface string
+	// Target is the target of the rule, ACCEPT if empty
+	Target string
 }
 
-func NewIPTable() *IPTable {
-	return &IPTable{}
+// String returns the specification of the rule in the form iptables -S
+// prints it, so that the installed rules can be compared with it
+func (r IPTableRule) String() string {
+	parts := []string{}
+	if r.Source != "" {
+		parts = append(parts, "-s", r.cidr(r.Source))
+	}
+	if r.Destination != "" {
+		parts = append(parts, "-d", r.cidr(r.Destination))
+	}
+	if r.InInterface != "" {
+		parts = append(parts, "-i", r.InInterface)
+	}
+	if r.OutInterface != "" {
+		parts = append(parts, "-o", r.OutInterface)
+	}
+	if r.Protocol != "" {
+		protocol := string(r.Protocol)
+		if r.IPv6 && r.Protocol == ICMP {
+			protocol = "ipv6-icmp"
+		}
+
+		parts = append(parts, "-p", protocol)
+	}
+
+	target := r.Target
+	if target == "" {
+		target = "ACCEPT"
+	}
+
+	return strings.Join(append(parts, "-j", target), " ")
 }
 
-func (ipt *IPTable) Sudo() *IPTable {
-	ipt.sudo = true
-	return ipt
+// cidr returns the address as a CIDR, single addresses are printed with the
+// full mask by iptables
+func (r IPTableRule) cidr(addr string) string {
+	if strings.Contains(addr, "/") {
+		return addr
+	}
+
+	if r.IPv6 {
+		return addr + "/128"
+	}
+
+	return addr + "/32"
 }
 
-func (ipt *IPTable) Table(table string) *IPTable {
-	ipt.table = table
-	return ipt
+// IPTables manages the rules of the kindli chain in a VM
+type IPTables struct {
+	vmName string
 }
 
-func (ipt *IPTable) Command(cmd string) *IPTable {
-	ipt.cmd = cmd
-	return ipt
+func NewIPTables(vmName string) *IPTables {
+	return &IPTables{vmName: vmName}
 }
 
-func (ipt *IPTable) Specification(specification string) *IPTable {
-	ipt.specification = specification
-	return ipt
+// List returns the rules of the kindli chain of the IP family, in the form
+// of IPTableRule.String
+func (ipt *IPTables) List(ipv6 bool) ([]string, error) {
+	out, err := ipt.exec(fmt.Sprintf("sudo %s -S %s 2>/dev/null || true", command(ipv6), Chain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s rules: %w", command(ipv6), err)
+	}
+
+	rules := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "-A "+Chain+" ") {
+			rules = append(rules, strings.TrimPrefix(line, "-A "+Chain+" "))
+		}
+	}
+
+	return rules, nil
 }
 
-func (ipt *IPTable) String() string {
+// Restore returns the iptables-restore input which replaces the rules of
+// the kindli chain with the given rules
+func Restore(rules []IPTableRule) string {
 	var sb strings.Builder
-	if ipt.sudo {
-		sb.WriteString("sudo ")
+	sb.WriteString("*filter\n")
+	// Declaring the chain creates it, or flushes it if it exists
+	sb.WriteString(fmt.Sprintf(":%s - [0:0]\n", Chain))
+	for _, r := range rules {
+		sb.WriteString(fmt.Sprintf("-A %s %s\n", Chain, r))
 	}
+	sb.WriteString("COMMIT\n")
 
-	sb.WriteString("iptables ")
+	return sb.String()
+}
+
+// Apply replaces the rules of the kindli chain with the given rules, the
+// rules of every IP family are replaced at once by iptables-restore so the
+// chain is never seen half updated. The chain is jumped to from the
+// FORWARD chain.
+func (ipt *IPTables) Apply(rules []IPTableRule) error {
+	for _, ipv6 := range []bool{false, true} {
+		family := []IPTableRule{}
+		for _, r := range rules {
+			if r.IPv6 == ipv6 {
+				family = append(family, r)
+			}
+		}
 
-	if ipt.table != "" {
-		sb.WriteString("-t ")
-		sb.WriteString(ipt.table)
-		sb.WriteString(" ")
+		script := fmt.Sprintf(
+			"printf '%%s' %s | sudo %s-restore --noflush && (sudo %s -C FORWARD -j %s 2>/dev/null || sudo %s -I FORWARD 1 -j %s)",
+			sh.Quote(Restore(family)),
+			command(ipv6),
+			command(ipv6), Chain,
+			command(ipv6), Chain,
+		)
+		if _, err := ipt.exec(script); err != nil {
+			return fmt.Errorf("failed to apply %s rules: %w", command(ipv6), err)
+		}
 	}
 
-	sb.WriteString(ipt.cmd)
-	sb.WriteString(" ")
-	sb.WriteString(ipt.specification)
+	return nil
+}
 
-	return sb.String()
+// Flush removes the kindli chain along with all its rules
+func (ipt *IPTables) Flush() error {
+	for _, ipv6 := range []bool{false, true} {
+		cmd := command(ipv6)
+		script := fmt.Sprintf(
+			"(sudo %s -D FORWARD -j %s 2>/dev/null || true) && (sudo %s -F %s 2>/dev/null || true) && (sudo %s -X %s 2>/dev/null || true)",
+			cmd, Chain,
+			cmd, Chain,
+			cmd, Chain,
+		)
+		if _, err := ipt.exec(script); err != nil {
+			return fmt.Errorf("failed to remove %s chain: %w", cmd, err)
+		}
+	}
+
+	return nil
+}
+
+func (ipt *IPTables) exec(script string) ([]byte, error) {
+	return vm.Exec(ipt.vmName, "sh", "-c", script)
+}
+
+func command(ipv6 bool) string {
+	if ipv6 {
+		return "ip6tables"
+	}
+
+	return "iptables"
 }
@@ -0,0 +1,114 @@
+package networking
+
+import "testing"
+
+func TestIPTableRuleString(t *testing.T) {
+	tests := []struct {
+		name string
+		rule IPTableRule
+		want string
+	}{
+		{
+			name: "ipv4 tcp with single source address",
+			rule: IPTableRule{
+				Protocol:     TCP,
+				Source:       "192.168.105.1",
+				Destination:  "172.16.0.0/16",
+				InInterface:  "lima0",
+				OutInterface: "br-5e0c",
+			},
+			want: "-s 192.168.105.1/32 -d 172.16.0.0/16 -i lima0 -o br-5e0c -p tcp -j ACCEPT",
+		},
+		{
+			name: "ipv4 icmp",
+			rule: IPTableRule{Protocol: ICMP, Source: "192.168.105.1", Destination: "172.16.0.0/16"},
+			want: "-s 192.168.105.1/32 -d 172.16.0.0/16 -p icmp -j ACCEPT",
+		},
+		{
+			name: "ipv6 udp with single source address",
+			rule: IPTableRule{
+				IPv6:         true,
+				Protocol:     UDP,
+				Source:       "fd00:105::1",
+				Destination:  "fc00:f853:ccd::/64",
+				InInterface:  "lima0",
+				OutInterface: "br-5e0c",
+			},
+			want: "-s fd00:105::1/128 -d fc00:f853:ccd::/64 -i lima0 -o br-5e0c -p udp -j ACCEPT",
+		},
+		{
+			name: "ipv6 icmp",
+			rule: IPTableRule{IPv6: true, Protocol: ICMP, Source: "fd00:105::1", Destination: "fc00:f853:ccd::/64"},
+			want: "-s fd00:105::1/128 -d fc00:f853:ccd::/64 -p ipv6-icmp -j ACCEPT",
+		},
+		{
+			name: "source cidr is kept",
+			rule: IPTableRule{Source: "192.168.105.0/24"},
+			want: "-s 192.168.105.0/24 -j ACCEPT",
+		},
+		{
+			name: "default target",
+			rule: IPTableRule{},
+			want: "-j ACCEPT",
+		},
+		{
+			name: "explicit target",
+			rule: IPTableRule{Protocol: TCP, Destination: "172.16.0.0/16", Target: "DROP"},
+			want: "-d 172.16.0.0/16 -p tcp -j DROP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []IPTableRule
+		want  string
+	}{
+		{
+			name:  "no rules flushes the chain",
+			rules: nil,
+			want:  "*filter\n:KINDLI - [0:0]\nCOMMIT\n",
+		},
+		{
+			name: "ipv4 rules",
+			rules: []IPTableRule{
+				{Protocol: TCP, Source: "192.168.105.1", Destination: "172.16.0.0/16", InInterface: "lima0", OutInterface: "br-5e0c"},
+				{Protocol: ICMP, Source: "192.168.105.1", Destination: "172.16.0.0/16", InInterface: "lima0", OutInterface: "br-5e0c"},
+			},
+			want: "*filter\n" +
+				":KINDLI - [0:0]\n" +
+				"-A KINDLI -s 192.168.105.1/32 -d 172.16.0.0/16 -i lima0 -o br-5e0c -p tcp -j ACCEPT\n" +
+				"-A KINDLI -s 192.168.105.1/32 -d 172.16.0.0/16 -i lima0 -o br-5e0c -p icmp -j ACCEPT\n" +
+				"COMMIT\n",
+		},
+		{
+			name: "ipv6 rules",
+			rules: []IPTableRule{
+				{IPv6: true, Protocol: UDP, Source: "fd00:105::1", Destination: "fc00:f853:ccd::/64"},
+				{IPv6: true, Protocol: ICMP, Source: "fd00:105::1", Destination: "fc00:f853:ccd::/64"},
+			},
+			want: "*filter\n" +
+				":KINDLI - [0:0]\n" +
+				"-A KINDLI -s fd00:105::1/128 -d fc00:f853:ccd::/64 -p udp -j ACCEPT\n" +
+				"-A KINDLI -s fd00:105::1/128 -d fc00:f853:ccd::/64 -p ipv6-icmp -j ACCEPT\n" +
+				"COMMIT\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Restore(tt.rules); got != tt.want {
+				t.Errorf("Restore() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
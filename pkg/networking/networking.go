@@ -0,0 +1,399 @@
+package networking
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/docker"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
+)
+
+// Router manages the routes on the host machine, it is implemented
+// separately for every host platform that kindli supports
+type Router interface {
+	// Add routes the IPv4 or IPv6 subnet via gateway
+	Add(subnet, gateway string) error
+	// Delete removes the route added by Add
+	Delete(subnet, gateway string) error
+	// Has returns true if the route added by Add is installed
+	Has(subnet, gateway string) bool
+}
+
+var router = hostRouter()
+
+// Setup installs the packet forwarding inside the VM and the routes on the
+// host to the kind network of the VM
+//
+// The routes don't survive restarts of the host and the VM, and are not
+// updated if the kind network changes, use `kindli network daemon` to keep
+// them in sync.
+func Setup(vmName string) error {
+	provider, err := vm.ProviderFor(vmName)
+	if err != nil {
+		return err
+	}
+
+	if !provider.Routed() {
+		logrus.Infof("VM provider \"%s\" does not require any networking setup", provider.Name())
+		return nil
+	}
+
+	routes, err := vmRoutes(vmName)
+	if err != nil {
+		return err
+	}
+
+	if err := checkCollisions(vmName, routes); err != nil {
+		return err
+	}
+
+	logrus.Info("Setting up inside the VM...")
+	if err := setupPacketRoutingInsideVM(vmName, routes); err != nil {
+		return fmt.Errorf("failed to setup packet routing inside VM: %s", err)
+	}
+	logrus.Info("✅ Completed setup inside the VM")
+
+	logrus.Info("Setting up on the host...")
+	if err := setupPacketRoutingOnHost(routes); err != nil {
+		return fmt.Errorf("failed to setup packet routing on host: %s", err)
+	}
+	logrus.Info("✅ Completed setup on the host")
+
+	return nil
+}
+
+func Cleanup(vmName string) error {
+	provider, err := vm.ProviderFor(vmName)
+	if err != nil {
+		return err
+	}
+
+	if !provider.Routed() {
+		return nil
+	}
+
+	routes, err := vmRoutes(vmName)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range routes {
+		if err := r.delete(); err != nil {
+			return fmt.Errorf("failed to cleanup route from system to VM: %s", err)
+		}
+	}
+
+	if err := NewIPTables(vmName).Flush(); err != nil {
+		return fmt.Errorf("failed to cleanup packet forwarding inside VM: %s", err)
+	}
+
+	logrus.Info("✅ Completed cleanup")
+	return nil
+}
+
+// route is a route on the host to the kind network of a VM
+type route struct {
+	vm      string
+	ipv6    bool
+	subnet  string
+	gateway string
+}
+
+func (r route) String() string {
+	return fmt.Sprintf("%s via %s", r.subnet, r.gateway)
+}
+
+// add installs the route, the IPv6 address of the host on the VM network
+// is set up first for IPv6 routes
+func (r route) add() error {
+	if r.ipv6 {
+		v := models.NewVM(r.vm, "", 0)
+		if err := v.GetByName(); err != nil {
+			return fmt.Errorf("failed to get VM by name: %w", err)
+		}
+
+		if err := ensureHostIPv6(v.GetVMIPv4()); err != nil {
+			return fmt.Errorf("failed to setup IPv6 address of the host: %w", err)
+		}
+	}
+
+	return router.Add(r.subnet, r.gateway)
+}
+
+func (r route) delete() error {
+	return router.Delete(r.subnet, r.gateway)
+}
+
+func (r route) installed() bool {
+	return router.Has(r.subnet, r.gateway)
+}
+
+// vmRoutes returns the routes on the host to the kind network of the VM,
+// the IPv6 route is left out if the kind network has no IPv6 subnet
+func vmRoutes(vmName string) ([]route, error) {
+	v := models.NewVM(vmName, "", 0)
+	if err := v.GetByName(); err != nil {
+		return nil, fmt.Errorf("failed to get VM by name: %w", err)
+	}
+
+	subnets, err := getVMSubnets(vmName, "kind")
+	if err != nil {
+		return nil, err
+	}
+
+	routes := []route{}
+	for _, subnet := range subnets {
+		if subnet.IP.To4() != nil {
+			routes = append(routes, route{vm: vmName, subnet: subnet.String(), gateway: v.GetVMIPv4()})
+		}
+	}
+
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("kind network of VM %s has no IPv4 subnet", vmName)
+	}
+
+	for _, subnet := range subnets {
+		if subnet.IP.To4() == nil {
+			routes = append(routes, route{vm: vmName, ipv6: true, subnet: subnet.String(), gateway: v.GetVMIPv6()})
+		}
+	}
+
+	return routes, nil
+}
+
+// checkCollisions returns an error if the kind network of another running VM
+// has the same subnet as one of the routes, only one of them can be routed
+func checkCollisions(vmName string, routes []route) error {
+	vms, err := routedVMs("")
+	if err != nil {
+		return err
+	}
+
+	for _, other := range vms {
+		if other == vmName {
+			continue
+		}
+
+		otherRoutes, err := vmRoutes(other)
+		if err != nil {
+			continue
+		}
+
+		for _, r := range routes {
+			if collides(otherRoutes, r) {
+				return collisionError(vmName, other, r)
+			}
+		}
+	}
+
+	return nil
+}
+
+// collides returns true if one of the routes is to the same subnet as r
+func collides(routes []route, r route) bool {
+	for _, x := range routes {
+		if x.ipv6 == r.ipv6 && x.subnet == r.subnet {
+			return true
+		}
+	}
+
+	return false
+}
+
+func collisionError(vmName, other string, r route) error {
+	return fmt.Errorf(
+		"kind network of VM %s (%s) collides with the kind network of VM %s, "+
+			"delete the clusters of VM %s and its \"kind\" docker network and create them again to get a distinct network",
+		vmName,
+		r,
+		other,
+		vmName,
+	)
+}
+
+// forwardRules returns the rules of the VM which forward the packets coming
+// from the host to the kind network of the routes
+func forwardRules(vmName, hostIf string, routes []route) ([]IPTableRule, error) {
+	kindIf, err := kindBridge(vmName)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []IPTableRule{}
+	for _, r := range routes {
+		source := models.HostIPv4
+		if r.ipv6 {
+			source = models.HostIPv6
+		}
+
+		// Forward all the packets that are coming from the host interface to the kind network interface
+		for _, protocol := range []Protocol{TCP, UDP, ICMP} {
+			rules = append(rules, IPTableRule{
+				IPv6:         r.ipv6,
+				Protocol:     protocol,
+				Source:       source,
+				Destination:  r.subnet,
+				InInterface:  hostIf,
+				OutInterface: kindIf,
+			})
+		}
+	}
+
+	return rules, nil
+}
+
+// kindBridge returns the name of the bridge interface of the kind network
+// of the VM, the bridge name set on the network is preferred over the one
+// docker derives from the network ID
+func kindBridge(vmName string) (string, error) {
+	out, err := docker.NetworkInspectContext(vmName, "kind", `{{.Id}} {{index .Options "com.docker.network.bridge.name"}}`)
+	if err != nil {
+		return "", fmt.Errorf("failed to get kind network interface name: %w", err)
+	}
+
+	fields := strings.Fields(out)
+	if len(fields) == 0 || len(fields[0]) < 12 {
+		return "", fmt.Errorf("failed to get kind network interface name: unexpected network ID \"%s\"", out)
+	}
+
+	if len(fields) > 1 && fields[1] != "<no value>" {
+		return fields[1], nil
+	}
+
+	return "br-" + fields[0][:12], nil
+}
+
+// installedRules returns the rules of the kindli chain of the VM of both the
+// IP families
+func installedRules(ipt *IPTables) (map[string]bool, error) {
+	installed := map[string]bool{}
+	for _, ipv6 := range []bool{false, true} {
+		rules, err := ipt.List(ipv6)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range rules {
+			installed[command(ipv6)+" "+r] = true
+		}
+	}
+
+	return installed, nil
+}
+
+func ruleInstalled(installed map[string]bool, r IPTableRule) bool {
+	return installed[command(r.IPv6)+" "+r.String()]
+}
+
+func setupPacketRoutingInsideVM(vmName string, routes []route) error {
+	// Interface which holds the VM IP is the one connected to the host
+	hostIf, err := hostInterface(vmName)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range routes {
+		if r.ipv6 {
+			if err := setupIPv6InsideVM(vmName, hostIf, r.gateway); err != nil {
+				return err
+			}
+		}
+	}
+
+	rules, err := forwardRules(vmName, hostIf, routes)
+	if err != nil {
+		return err
+	}
+
+	ipt := NewIPTables(vmName)
+	installed, err := installedRules(ipt)
+	if err != nil {
+		return err
+	}
+
+	// The whole rule set is applied again if it differs in any way
+	upToDate := len(installed) == len(rules)
+	for _, r := range rules {
+		upToDate = upToDate && ruleInstalled(installed, r)
+	}
+
+	if upToDate {
+		return nil
+	}
+
+	if err := ipt.Apply(rules); err != nil {
+		return fmt.Errorf("failed to setup route from VM network interface to kind network interface: %w", err)
+	}
+
+	return nil
+}
+
+// setupIPv6InsideVM adds the IPv6 address of the VM to the interface
+// connected to the host and enables IPv6 forwarding, VMs created before
+// the VMs had IPv6 addresses get them this way
+func setupIPv6InsideVM(vmName, hostIf, vmIPv6 string) error {
+	script := fmt.Sprintf(
+		"sudo ip -6 addr replace %s/64 dev %s && sudo sysctl -q -w net.ipv6.conf.all.forwarding=1",
+		vmIPv6,
+		hostIf,
+	)
+	if _, err := vm.Exec(vmName, "sh", "-c", script); err != nil {
+		return fmt.Errorf("failed to setup IPv6 inside VM: %w", err)
+	}
+
+	return nil
+}
+
+func setupPacketRoutingOnHost(routes []route) error {
+	for _, r := range routes {
+		if r.installed() {
+			continue
+		}
+
+		if err := r.add(); err != nil {
+			return fmt.Errorf("failed to setup route from system to VM: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// hostInterface returns the name of the interface of the VM connected
+// to the host
+func hostInterface(vmName string) (string, error) {
+	v := models.NewVM(vmName, "", 0)
+	if err := v.GetByName(); err != nil {
+		return "", fmt.Errorf("failed to get VM by name: %w", err)
+	}
+
+	out, err := vm.Exec(vmName, "sh", "-c", fmt.Sprintf("ip -o -4 addr show | awk '$4 ~ /^%s\\// {print $2}'", v.GetVMIPv4()))
+	if err != nil {
+		return "", fmt.Errorf("failed to get host network interface name: %s", err)
+	}
+
+	hostIf := trim(out)
+	if hostIf == "" {
+		return "", fmt.Errorf("no interface found with IP %s in the VM", v.GetVMIPv4())
+	}
+
+	return hostIf, nil
+}
+
+// fieldAfter returns the field which follows the first occurrence of the
+// field key in out, empty string if there is none
+func fieldAfter(out, key string) string {
+	fields := strings.Fields(out)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == key {
+			return fields[i+1]
+		}
+	}
+
+	return ""
+}
+
+func trim(data []byte) string {
+	return strings.Trim(string(data), " \n")
+}
@@ -4,127 +4,55 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/sirupsen/logrus"
 	"github.com/utkarsh-pro/kindli/pkg/models"
 	"github.com/utkarsh-pro/kindli/pkg/sh"
-	"github.com/utkarsh-pro/kindli/pkg/utils"
 )
 
-const disableIPv6 = true
+// darwinRouter manages routes using BSD route command
+type darwinRouter struct{}
 
-func Setup(vmName string) error {
-	logrus.Info("Setting up inside the VM...")
-	if err := setupPacketRoutingInsideVM(vmName); err != nil {
-		return fmt.Errorf("failed to setup packet routing inside VM: %s", err)
-	}
-	logrus.Info("✅ Completed setup inside the VM")
-
-	logrus.Info("Setting up on the host...")
-	if err := setupPacketRoutingOnHost(vmName); err != nil {
-		return fmt.Errorf("failed to setup packet routing on host: %s", err)
-	}
-	logrus.Info("✅ Completed setup on the host")
-
-	logrus.Info("Waiting for SIGINT (Ctr + C) to cleanup...")
-
-	utils.SigIntHandler(func() {
-		if err := Cleanup(vmName); err != nil {
-			logrus.Error("failed to cleanup networking: ", err)
-			logrus.Warn("please cleanup networking manually - `kindli network cleanup --vm-name <vm-name>`")
-		}
-	})
-
-	return nil
+func hostRouter() Router {
+	return darwinRouter{}
 }
 
-func Cleanup(vmName string) error {
-	vm := models.NewVM(vmName, "", 0)
-	if err := vm.GetByName(); err != nil {
-		return fmt.Errorf("failed to get VM by name: %w", err)
-	}
-
-	limaVMIPv4 := vm.GetVMIPv4()
-	ipv4Subnetprefix, err := GetIPv4SubnetPrefix("kind")
-	if err != nil {
-		return fmt.Errorf("failed to get IPv4 subnet prefix: %w", err)
-	}
-	if err := sh.RunSilent(fmt.Sprintf("sudo route -nv delete -net %s %s", ipv4Subnetprefix, limaVMIPv4)); err != nil {
-		return fmt.Errorf("failed to cleanup route from system to VM: %s", err)
-	}
+func (darwinRouter) Add(subnet, gateway string) error {
+	return sh.NewCommand("sudo", "route", "-nv", "add", ipFamily(subnet), "-net", subnet, gateway).RunSilent()
+}
 
-	if !disableIPv6 {
-		limaVMIPv6 := vm.GetVMIPv6()
-		ipv6Subnetprefix, err := GetIPv6SubnetPrefix("kind")
-		if err != nil {
-			return fmt.Errorf("failed to get IPv6 subnet prefix: %w", err)
-		}
-		if err := sh.RunSilent(fmt.Sprintf("sudo route -nv delete -inet6 %s:: %s", ipv6Subnetprefix, limaVMIPv6)); err != nil {
-			return fmt.Errorf("failed to cleanup route from system to VM: %s", err)
-		}
-	}
+func (darwinRouter) Delete(subnet, gateway string) error {
+	return sh.NewCommand("sudo", "route", "-nv", "delete", ipFamily(subnet), "-net", subnet, gateway).RunSilent()
+}
 
-	logrus.Info("✅ Completed cleanup")
-	return nil
+func (darwinRouter) Has(subnet, gateway string) bool {
+	out, err := sh.NewCommand("route", "-n", "get", ipFamily(subnet), "-net", subnet).Query().Output()
+	return err == nil && strings.Contains(string(out), "gateway: "+gateway+"\n")
 }
 
-func setupPacketRoutingInsideVM(vmName string) error {
-	kindIf, err := sh.RunIO(fmt.Sprintf("limactl shell %s -- ip -o link show | awk -F': ' '{print $2}' | grep 'br-'", vmName))
+// ensureHostIPv6 adds the IPv6 address of the host on the VM network to the
+// bridge of the lima shared network
+func ensureHostIPv6(vmIPv4 string) error {
+	out, err := sh.NewCommand("route", "-n", "get", vmIPv4).Query().Output()
 	if err != nil {
-		return fmt.Errorf("failed to get kind network interface name: %s", err)
+		return fmt.Errorf("failed to find interface of the VM network: %w", err)
 	}
 
-	hostIf := "lima0"
-
-	// Forward all the packets that are coming from the host interface to the kind network interface
-	rule := NewIPTable().
-		Sudo().
-		Table("filter").
-		Specification(fmt.Sprintf(
-			"-4 -p tcp -s 192.168.105.1 -d 172.18.0.0/16 -j ACCEPT -i %s -o %s",
-			hostIf,
-			trim(kindIf),
-		))
-
-	if err := sh.Run(fmt.Sprintf("limactl shell %s -- %s", vmName, rule.Command("-C FORWARD").String())); err != nil {
-		if err := sh.Run(fmt.Sprintf("limactl shell %s -- %s", vmName, rule.Command("-A FORWARD").String())); err != nil {
-			return fmt.Errorf("failed to setup route from VM network interface to kind network interface: %w", err)
-		}
+	iface := fieldAfter(strings.ReplaceAll(string(out), ":", ""), "interface")
+	if iface == "" {
+		return fmt.Errorf("no interface of the host reaches %s", vmIPv4)
 	}
 
-	return nil
-}
-
-func setupPacketRoutingOnHost(vmName string) error {
-	vm := models.NewVM(vmName, "", 0)
-	if err := vm.GetByName(); err != nil {
-		return fmt.Errorf("failed to get VM by name: %w", err)
+	out, err = sh.NewCommand("ifconfig", iface, "inet6").Query().Output()
+	if err == nil && strings.Contains(string(out), " "+models.HostIPv6+" ") {
+		return nil
 	}
 
-	// IPv4 Routing
-	limaVMIPv4 := vm.GetVMIPv4()
-	ipv4Subnetprefix, err := GetIPv4SubnetPrefix("kind")
-	if err != nil {
-		return fmt.Errorf("failed to get IPv4 subnet prefix: %w", err)
-	}
-	if err := sh.RunSilent(fmt.Sprintf("sudo route -nv add -net %s %s", ipv4Subnetprefix, limaVMIPv4)); err != nil {
-		return fmt.Errorf("failed to setup route from system to VM: %s", err)
-	}
+	return sh.NewCommand("sudo", "ifconfig", iface, "inet6", models.HostIPv6, "prefixlen", "64", "alias").RunSilent()
+}
 
-	// IPv6 Routing
-	if !disableIPv6 {
-		limaVMIPv6 := vm.GetVMIPv6()
-		ipv6Subnetprefix, err := GetIPv6SubnetPrefix("kind")
-		if err != nil {
-			return fmt.Errorf("failed to get IPv6 subnet prefix: %w", err)
-		}
-		if err := sh.RunSilent(fmt.Sprintf("sudo route -nv add -inet6 %s:: %s", ipv6Subnetprefix, limaVMIPv6)); err != nil {
-			return fmt.Errorf("failed to setup route from system to VM: %s", err)
-		}
+func ipFamily(subnet string) string {
+	if strings.Contains(subnet, ":") {
+		return "-inet6"
 	}
 
-	return nil
-}
-
-func trim(data []byte) string {
-	return strings.Trim(string(data), " \n")
+	return "-inet"
 }
@@ -0,0 +1,59 @@
+package networking
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+// linuxRouter manages routes using iproute2
+type linuxRouter struct{}
+
+func hostRouter() Router {
+	return linuxRouter{}
+}
+
+func (linuxRouter) Add(subnet, gateway string) error {
+	return sh.NewCommand("sudo", "ip", ipFamily(subnet), "route", "replace", subnet, "via", gateway).RunSilent()
+}
+
+func (linuxRouter) Delete(subnet, gateway string) error {
+	return sh.NewCommand("sudo", "ip", ipFamily(subnet), "route", "delete", subnet, "via", gateway).RunSilent()
+}
+
+func (linuxRouter) Has(subnet, gateway string) bool {
+	out, err := sh.NewCommand("ip", ipFamily(subnet), "route", "show", subnet).Query().Output()
+	return err == nil && strings.Contains(string(out), "via "+gateway+" ")
+}
+
+// ensureHostIPv6 adds the IPv6 address of the host on the VM network to the
+// interface of the host which reaches the VM
+func ensureHostIPv6(vmIPv4 string) error {
+	out, err := sh.NewCommand("ip", "-o", "-4", "route", "get", vmIPv4).Query().Output()
+	if err != nil {
+		return fmt.Errorf("failed to find interface of the VM network: %w", err)
+	}
+
+	iface := fieldAfter(string(out), "dev")
+	if iface == "" {
+		return fmt.Errorf("no interface of the host reaches %s", vmIPv4)
+	}
+
+	addr := models.HostIPv6 + "/64"
+	out, err = sh.NewCommand("ip", "-o", "-6", "addr", "show", "dev", iface).Query().Output()
+	if err == nil && strings.Contains(string(out), " "+addr+" ") {
+		return nil
+	}
+
+	return sh.NewCommand("sudo", "ip", "-6", "addr", "replace", addr, "dev", iface).RunSilent()
+}
+
+func ipFamily(subnet string) string {
+	if strings.Contains(subnet, ":") {
+		return "-6"
+	}
+
+	return "-4"
+}
@@ -8,42 +8,63 @@ import (
 	"github.com/utkarsh-pro/kindli/pkg/docker"
 )
 
-// GetIPv4SubnetPrefix returns the subnet prefix for the given docker network
-func GetIPv4SubnetPrefix(network string) (string, error) {
-	subnet, err := docker.NetworkInspect(network, "{{ index .IPAM.Config 0 \"Subnet\"}}")
+// subnetsFormat is the format of docker network inspect which prints the
+// subnets of the network
+const subnetsFormat = "{{range .IPAM.Config}}{{.Subnet}} {{end}}"
+
+// GetIPv4Subnet returns the IPv4 subnet of the given docker network of the
+// VM, regardless of the current docker context
+func GetIPv4Subnet(vmName, network string) (*net.IPNet, error) {
+	return getSubnet(vmName, network, false)
+}
+
+// GetIPv6Subnet returns the IPv6 subnet of the given docker network of the
+// VM, regardless of the current docker context
+func GetIPv6Subnet(vmName, network string) (*net.IPNet, error) {
+	return getSubnet(vmName, network, true)
+}
+
+func getSubnet(vmName, network string, ipv6 bool) (*net.IPNet, error) {
+	subnets, err := getVMSubnets(vmName, network)
 	if err != nil {
-		return "", fmt.Errorf("failed to get subnet prefix: %w", err)
+		return nil, err
 	}
 
-	ip, sub, err := net.ParseCIDR(subnet)
-	if err != nil {
-		return "", fmt.Errorf("failed to get subnet prefix: %w", err)
+	for _, subnet := range subnets {
+		if (subnet.IP.To4() == nil) == ipv6 {
+			return subnet, nil
+		}
 	}
 
-	size, _ := sub.Mask.Size()
-	if size != 16 {
-		return "", fmt.Errorf("ipv4 subnet of only size 16 is supported")
+	family := "IPv4"
+	if ipv6 {
+		family = "IPv6"
 	}
 
-	return strings.Join(strings.Split(ip.String(), ".")[:2], "."), nil
+	return nil, fmt.Errorf("docker network %s of VM %s has no %s subnet", network, vmName, family)
 }
 
-// GetIPv6SubnetPrefix returns the subnet prefix for the given docker network
-func GetIPv6SubnetPrefix(network string) (string, error) {
-	subnet, err := docker.NetworkInspect(network, "{{ index .IPAM.Config 1 \"Subnet\"}}")
+// getVMSubnets returns the subnets of the given docker network of the VM,
+// regardless of the current docker context
+func getVMSubnets(vmName, network string) ([]*net.IPNet, error) {
+	out, err := docker.NetworkInspectContext(vmName, network, subnetsFormat)
 	if err != nil {
-		return "", fmt.Errorf("failed to get subnet prefix: %w", err)
+		return nil, fmt.Errorf("failed to get subnets: %w", err)
 	}
 
-	ip, sub, err := net.ParseCIDR(subnet)
-	if err != nil {
-		return "", fmt.Errorf("failed to get subnet prefix: %w", err)
-	}
+	return parseSubnets(out)
+}
+
+func parseSubnets(out string) ([]*net.IPNet, error) {
+	subnets := []*net.IPNet{}
+	for _, field := range strings.Fields(out) {
+		_, subnet, err := net.ParseCIDR(field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse subnet: %w", err)
+		}
 
-	size, _ := sub.Mask.Size()
-	if size != 64 {
-		return "", fmt.Errorf("ipv6 subnet of only size 64 is supported")
+		subnets = append(subnets, subnet)
 	}
 
-	return strings.TrimSuffix(ip.String(), "::"), nil
+	return subnets, nil
 }
@@ -0,0 +1,89 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// Table prints the value as a table, this is the default
+	Table = ""
+	// Wide prints the value as a table with additional columns
+	Wide = "wide"
+	// JSON prints the value as JSON
+	JSON = "json"
+	// YAML prints the value as YAML
+	YAML = "yaml"
+	// Template prints the value using a Go template
+	Template = "template"
+)
+
+// Usage is the help text for the output flag
+const Usage = "Output format, one of: json|yaml|wide|template=<go-template>"
+
+// Format is the output format requested by the user
+type Format struct {
+	Name     string
+	Template string
+}
+
+// Tabular is implemented by the values which can be printed as a table
+type Tabular interface {
+	// Header returns the column names of the table
+	Header(wide bool) []string
+	// Rows returns the rows of the table, each row must have as many
+	// cells as the header
+	Rows(wide bool) [][]string
+}
+
+// ParseFormat parses the value of the output flag
+func ParseFormat(format string) (Format, error) {
+	switch {
+	case format == Table, format == Wide, format == JSON, format == YAML:
+		return Format{Name: format}, nil
+	case strings.HasPrefix(format, Template+"="):
+		tmpl := strings.TrimPrefix(format, Template+"=")
+		if _, err := template.New("output").Parse(tmpl); err != nil {
+			return Format{}, fmt.Errorf("invalid output template: %w", err)
+		}
+
+		return Format{Name: Template, Template: tmpl}, nil
+	}
+
+	return Format{}, fmt.Errorf("invalid output format \"%s\" - %s", format, Usage)
+}
+
+// Print prints the value to w in the given format
+func Print(w io.Writer, format Format, value Tabular) error {
+	switch format.Name {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	case YAML:
+		return yaml.NewEncoder(w).Encode(value)
+	case Template:
+		parsed, err := template.New("output").Parse(format.Template)
+		if err != nil {
+			return fmt.Errorf("invalid output template: %w", err)
+		}
+
+		return parsed.Execute(w, value)
+	}
+
+	wide := format.Name == Wide
+
+	tw := tabwriter.NewWriter(w, 4, 8, 4, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(value.Header(wide), "\t"))
+	for _, row := range value.Rows(wide) {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
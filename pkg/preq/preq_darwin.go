@@ -51,24 +51,48 @@ func Missing() []string {
 	return missing
 }
 
+// Install installs the given missing prerequisite
+func Install(missing string) error {
+	switch missing {
+	case "brew":
+		return InstallBrew()
+	case "git":
+		return InstallGit()
+	case "make":
+		return InstallMake()
+	case "automake":
+		return InstallAutoMake()
+	case "autoconf":
+		return InstallAutoConf()
+	case "limactl":
+		return InstallLima()
+	case "vde_switch":
+		return InstallSwitch()
+	case "vde_vmnet":
+		return InstallVMNet()
+	}
+
+	return nil
+}
+
 func InstallBrew() error {
 	return sh.Run("/bin/bash -c '$(curl -fsSL https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh)'")
 }
 
 func InstallGit() error {
-	return sh.Run("brew install git")
+	return sh.NewCommand("brew", "install", "git").Run()
 }
 
 func InstallMake() error {
-	return sh.Run("brew install make")
+	return sh.NewCommand("brew", "install", "make").Run()
 }
 
 func InstallAutoMake() error {
-	return sh.Run("brew install automake")
+	return sh.NewCommand("brew", "install", "automake").Run()
 }
 
 func InstallAutoConf() error {
-	return sh.Run("brew install autoconf")
+	return sh.NewCommand("brew", "install", "autoconf").Run()
 }
 
 func InstallLima() error {
@@ -0,0 +1,89 @@
+package preq
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+var (
+	limaVersion = "0.12.0"
+
+	preqs = []string{
+		"limactl",
+		"qemu-img",
+		qemuSystem(),
+		"vde_switch",
+		"ip",
+	}
+
+	// packages maps the prerequisites to the name of the distribution
+	// package which provides them
+	packages = map[string]string{
+		"qemu-img":   "qemu-utils",
+		qemuSystem(): "qemu-system",
+		"vde_switch": "vde2",
+		"ip":         "iproute2",
+	}
+)
+
+func Missing() []string {
+	missing := []string{}
+
+	for _, preq := range preqs {
+		if _, err := exec.LookPath(preq); err != nil {
+			missing = append(missing, preq)
+		}
+	}
+
+	return missing
+}
+
+// Install installs the given missing prerequisite
+func Install(missing string) error {
+	if missing == "limactl" {
+		return InstallLima()
+	}
+
+	pkg, ok := packages[missing]
+	if !ok {
+		return fmt.Errorf("don't know how to install %s", missing)
+	}
+
+	return installPackage(pkg)
+}
+
+func InstallLima() error {
+	return sh.Run(fmt.Sprintf(
+		"curl -fsSL https://github.com/lima-vm/lima/releases/download/v%s/lima-%s-Linux-%s.tar.gz | sudo tar Cxzvm /usr/local",
+		limaVersion,
+		limaVersion,
+		limaArch(),
+	))
+}
+
+func installPackage(pkg string) error {
+	if _, err := exec.LookPath("apt-get"); err == nil {
+		return sh.NewCommand("sudo", "apt-get", "install", "-y", pkg).Run()
+	}
+
+	if _, err := exec.LookPath("dnf"); err == nil {
+		return sh.NewCommand("sudo", "dnf", "install", "-y", pkg).Run()
+	}
+
+	return fmt.Errorf("no supported package manager found, please install %s manually", pkg)
+}
+
+func limaArch() string {
+	if runtime.GOARCH == "arm64" {
+		return "aarch64"
+	}
+
+	return "x86_64"
+}
+
+func qemuSystem() string {
+	return "qemu-system-" + limaArch()
+}
@@ -0,0 +1,228 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+)
+
+// DefaultUpstreams are the upstream registries cached when none are given
+var DefaultUpstreams = []string{"docker.io", "ghcr.io", "quay.io"}
+
+// remotes are the URLs of the upstream registries whose registry API isn't
+// served at the host used in the image references
+var remotes = map[string]string{
+	"docker.io": "https://registry-1.docker.io",
+}
+
+var upstreamRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)+(:[0-9]+)?$`)
+
+// CacheInfo is the information of a pull-through cache of a VM
+type CacheInfo struct {
+	VM       string `json:"vm" yaml:"vm"`
+	Upstream string `json:"upstream" yaml:"upstream"`
+	Remote   string `json:"remote" yaml:"remote"`
+	// Endpoint is the address the kind nodes pull from
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+	Image    string `json:"image" yaml:"image"`
+	Running  bool   `json:"running" yaml:"running"`
+}
+
+// CacheInfoList is a list of CacheInfo
+type CacheInfoList []CacheInfo
+
+func (l CacheInfoList) Header(wide bool) []string {
+	header := []string{"VM", "UPSTREAM", "ENDPOINT", "RUNNING"}
+	if wide {
+		header = append(header, "REMOTE", "IMAGE")
+	}
+
+	return header
+}
+
+func (l CacheInfoList) Rows(wide bool) [][]string {
+	rows := [][]string{}
+	for _, i := range l {
+		row := []string{i.VM, i.Upstream, i.Endpoint, strconv.FormatBool(i.Running)}
+		if wide {
+			row = append(row, i.Remote, i.Image)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// EnableCache runs pull-through caches of the upstream registries in the
+// VM, the clusters created in the VM afterwards pull the images of the
+// upstreams through the caches
+//
+// The cached images are stored in docker volumes of the VM and survive
+// restarts of the VM and disabling the caches.
+func EnableCache(vmName string, upstreams []string, image string) error {
+	if len(upstreams) == 0 {
+		upstreams = DefaultUpstreams
+	}
+
+	if image == "" {
+		image = DefaultImage
+	}
+
+	for _, upstream := range upstreams {
+		if !upstreamRegex.MatchString(upstream) {
+			return fmt.Errorf("invalid upstream registry \"%s\", must be a registry host such as docker.io", upstream)
+		}
+	}
+
+	for _, upstream := range upstreams {
+		c := models.NewCache(vmName, upstream)
+		exists, err := c.Exists()
+		if err != nil {
+			return fmt.Errorf("failed to check if cache exists: %w", err)
+		}
+
+		if exists {
+			logrus.Infof("cache of %s is already enabled in VM %s", upstream, vmName)
+			continue
+		}
+
+		c.Remote = remote(upstream)
+		c.Image = image
+		if err := runCache(c); err != nil {
+			return err
+		}
+
+		if err := c.Save(); err != nil {
+			return fmt.Errorf("failed to save cache: %w", err)
+		}
+
+		logrus.Infof("✅ Cache of %s enabled in VM %s", upstream, vmName)
+	}
+
+	clusters, err := models.ListCluster()
+	if err != nil {
+		return fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	for _, c := range clusters {
+		if c.VM == vmName {
+			logrus.Warnf("existing cluster \"%s\" does not use the caches, only clusters created from now on do", c.Name)
+		}
+	}
+
+	return nil
+}
+
+// DisableCache removes the pull-through caches of the upstreams, or all
+// the caches if no upstreams are given, from the VM. The cached images are
+// kept unless purge is true.
+func DisableCache(vmName string, upstreams []string, purge bool) error {
+	caches, err := models.ListCache(vmName)
+	if err != nil {
+		return fmt.Errorf("failed to list caches: %w", err)
+	}
+
+	enabled := map[string]*models.Cache{}
+	for _, c := range caches {
+		enabled[c.Upstream] = c
+	}
+
+	if len(upstreams) == 0 {
+		for _, c := range caches {
+			upstreams = append(upstreams, c.Upstream)
+		}
+	}
+
+	for _, upstream := range upstreams {
+		c, ok := enabled[upstream]
+		if !ok {
+			return fmt.Errorf("cache of %s is not enabled in VM %s", upstream, vmName)
+		}
+
+		name := cacheContainerName(upstream)
+		if err := docker(vmName, "rm", "-f", name).RunSilent(); err != nil {
+			return fmt.Errorf("failed to remove cache container: %w", err)
+		}
+
+		if purge {
+			if err := docker(vmName, "volume", "rm", name).RunSilent(); err != nil {
+				return fmt.Errorf("failed to remove cache volume: %w", err)
+			}
+		}
+
+		if err := c.Delete(); err != nil {
+			return fmt.Errorf("failed to delete cache: %w", err)
+		}
+
+		logrus.Infof("✅ Cache of %s disabled in VM %s", upstream, vmName)
+	}
+
+	return nil
+}
+
+// CacheStatus returns the caches of the VM, or of all the VMs if vmName is
+// empty
+func CacheStatus(vmName string) (CacheInfoList, error) {
+	caches, err := models.ListCache(vmName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list caches: %w", err)
+	}
+
+	infos := CacheInfoList{}
+	for _, c := range caches {
+		name := cacheContainerName(c.Upstream)
+		infos = append(infos, CacheInfo{
+			VM:       c.VM,
+			Upstream: c.Upstream,
+			Remote:   c.Remote,
+			Endpoint: internalHost(name),
+			Image:    c.Image,
+			Running:  running(c.VM, name),
+		})
+	}
+
+	return infos, nil
+}
+
+// runCache creates the cache container in the VM
+func runCache(c *models.Cache) error {
+	name := cacheContainerName(c.Upstream)
+
+	logrus.Infof("Starting cache of %s in VM %s", c.Upstream, c.VM)
+	return runContainer(
+		c.VM, name,
+		"--env", "REGISTRY_PROXY_REMOTEURL="+c.Remote,
+		"--volume", name+":/var/lib/registry",
+		c.Image,
+	)
+}
+
+// startCache starts the cache container, it is recreated if it is missing
+func startCache(c *models.Cache) error {
+	name := cacheContainerName(c.Upstream)
+	if !exists(c.VM, "container", name) {
+		return runCache(c)
+	}
+
+	return startContainer(c.VM, name)
+}
+
+// cacheContainerName returns the name of the container and of the volume
+// of the cache of the upstream
+func cacheContainerName(upstream string) string {
+	return "kindli-cache-" + strings.NewReplacer(".", "-", ":", "-").Replace(upstream)
+}
+
+// remote returns the URL of the upstream registry
+func remote(upstream string) string {
+	if url, ok := remotes[upstream]; ok {
+		return url
+	}
+
+	return "https://" + upstream
+}
@@ -0,0 +1,411 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/k8s"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+const (
+	// ContainerName is the name of the registry container in the VM, the
+	// kind nodes reach the registry by this name over the kind network
+	ContainerName = "kindli-registry"
+	// DefaultImage is the image of the registry container
+	DefaultImage = "registry:2"
+	// DefaultPort is the port of the registry of the first VM, the next VMs
+	// get the next ports
+	DefaultPort = 5000
+
+	// containerPort is the port the registry listens on in its container
+	containerPort = 5000
+	// volumeName is the volume holding the images of the registry
+	volumeName = "kindli-registry"
+	// kindNetwork is the docker network of the kind nodes
+	kindNetwork = "kind"
+
+	applyTimeout = 1 * time.Minute
+)
+
+// hostingConfigMap is the ConfigMap documenting the local registry,
+// see KEP-1755
+const hostingConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: local-registry-hosting
+  namespace: kube-public
+data:
+  localRegistryHosting.v1: |
+    host: "%s"
+    hostFromContainerRuntime: "%s"
+    hostFromClusterNetwork: "%s"
+    help: "https://kind.sigs.k8s.io/docs/user/local-registry/"
+`
+
+// Info is the information of the registry of a VM
+type Info struct {
+	VM      string `json:"vm" yaml:"vm"`
+	Host    string `json:"host" yaml:"host"`
+	Image   string `json:"image" yaml:"image"`
+	Running bool   `json:"running" yaml:"running"`
+}
+
+// InfoList is a list of Info
+type InfoList []Info
+
+func (l InfoList) Header(wide bool) []string {
+	header := []string{"VM", "HOST", "RUNNING"}
+	if wide {
+		header = append(header, "IMAGE")
+	}
+
+	return header
+}
+
+func (l InfoList) Rows(wide bool) [][]string {
+	rows := [][]string{}
+	for _, i := range l {
+		row := []string{i.VM, i.Host, strconv.FormatBool(i.Running)}
+		if wide {
+			row = append(row, i.Image)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// Host returns the address the registry is reachable at from the host,
+// the port of the registry is forwarded to localhost of the host
+func Host(r *models.Registry) string {
+	return fmt.Sprintf("localhost:%d", r.Port)
+}
+
+// forward makes the port of the registry reachable on localhost of the
+// host for the providers which don't forward it on their own
+func forward(r *models.Registry) error {
+	if err := vm.Forward(r.VM, r.Port); err != nil {
+		return fmt.Errorf("failed to forward registry port: %w", err)
+	}
+
+	return nil
+}
+
+// internalHost returns the address the registry container is reachable at
+// from the kind nodes
+func internalHost(container string) string {
+	return fmt.Sprintf("%s:%d", container, containerPort)
+}
+
+// Get returns the registry of the VM, nil if the VM has no registry
+func Get(vmName string) (*models.Registry, error) {
+	r := models.NewRegistry(vmName)
+	if err := r.GetByVM(); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get registry of VM %s: %w", vmName, err)
+	}
+
+	return r, nil
+}
+
+// Enable runs the registry in the VM, the clusters created in the VM
+// afterwards use the registry
+//
+// A port of 0 assigns the next free registry port.
+func Enable(vmName string, port int, image string) error {
+	r, err := Get(vmName)
+	if err != nil {
+		return err
+	}
+
+	if r != nil {
+		logrus.Infof("registry is already enabled in VM %s at %s", vmName, Host(r))
+		if err := start(r); err != nil {
+			return err
+		}
+
+		return forward(r)
+	}
+
+	if image == "" {
+		image = DefaultImage
+	}
+
+	if port == 0 {
+		if port, err = nextPort(); err != nil {
+			return err
+		}
+	}
+
+	r = &models.Registry{VM: vmName, Port: port, Image: image}
+	if err := run(r); err != nil {
+		return err
+	}
+
+	if err := r.Save(); err != nil {
+		return fmt.Errorf("failed to save registry: %w", err)
+	}
+
+	if err := forward(r); err != nil {
+		return err
+	}
+
+	clusters, err := models.ListCluster()
+	if err != nil {
+		return fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	for _, c := range clusters {
+		if c.VM == vmName {
+			logrus.Warnf("existing cluster \"%s\" does not use the registry, only clusters created from now on do", c.Name)
+		}
+	}
+
+	logrus.Infof("✅ Registry of VM %s is available at %s", vmName, Host(r))
+	return nil
+}
+
+// Disable removes the registry container from the VM, the images of the
+// registry are kept unless purge is true
+func Disable(vmName string, purge bool) error {
+	r, err := Get(vmName)
+	if err != nil {
+		return err
+	}
+
+	if r == nil {
+		return fmt.Errorf("registry is not enabled in VM %s", vmName)
+	}
+
+	if err := docker(vmName, "rm", "-f", ContainerName).RunSilent(); err != nil {
+		return fmt.Errorf("failed to remove registry container: %w", err)
+	}
+
+	if purge {
+		if err := docker(vmName, "volume", "rm", volumeName).RunSilent(); err != nil {
+			return fmt.Errorf("failed to remove registry volume: %w", err)
+		}
+	}
+
+	if err := r.Delete(); err != nil {
+		return fmt.Errorf("failed to delete registry: %w", err)
+	}
+
+	logrus.Infof("✅ Registry of VM %s disabled", vmName)
+	return nil
+}
+
+// Status returns the registries of the VM, or of all the VMs if vmName is
+// empty
+func Status(vmName string) (InfoList, error) {
+	registries, err := models.ListRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registries: %w", err)
+	}
+
+	infos := InfoList{}
+	for _, r := range registries {
+		if vmName != "" && r.VM != vmName {
+			continue
+		}
+
+		infos = append(infos, Info{VM: r.VM, Host: Host(r), Image: r.Image, Running: running(r.VM, ContainerName)})
+	}
+
+	return infos, nil
+}
+
+// Patch makes the containerd of the nodes of the cluster mirror the local
+// registry and the pull-through caches of the VM
+//
+// The images pushed to the local registry from the host are pulled by the
+// same name in the cluster. Patches which the config already has, like the
+// ones of the persisted config of a snapshot, are not added again.
+func Patch(cfg *v1alpha4.Cluster, vmName string) error {
+	r, err := Get(vmName)
+	if err != nil {
+		return err
+	}
+
+	if r != nil {
+		addPatch(cfg, mirror(Host(r), internalHost(ContainerName)))
+	}
+
+	caches, err := models.ListCache(vmName)
+	if err != nil {
+		return fmt.Errorf("failed to list caches: %w", err)
+	}
+
+	for _, c := range caches {
+		addPatch(cfg, mirror(c.Upstream, internalHost(cacheContainerName(c.Upstream))))
+	}
+
+	return nil
+}
+
+// Wire attaches the local registry and the pull-through caches of the VM to
+// the kind network and publishes the local-registry-hosting ConfigMap in
+// the cluster
+func Wire(clusterName, vmName string) error {
+	caches, err := models.ListCache(vmName)
+	if err != nil {
+		return fmt.Errorf("failed to list caches: %w", err)
+	}
+
+	for _, c := range caches {
+		if err := startCache(c); err != nil {
+			return err
+		}
+	}
+
+	r, err := Get(vmName)
+	if err != nil || r == nil {
+		return err
+	}
+
+	if err := start(r); err != nil {
+		return err
+	}
+
+	client, err := k8s.New("kind-" + clusterName)
+	if err != nil {
+		return err
+	}
+
+	cm := fmt.Sprintf(hostingConfigMap, Host(r), Host(r), internalHost(ContainerName))
+	if err := client.Apply(context.Background(), []byte(cm), applyTimeout); err != nil {
+		return fmt.Errorf("failed to publish local registry hosting: %w", err)
+	}
+
+	return nil
+}
+
+// mirror returns the containerd config patch which mirrors host by the
+// endpoint, containerd falls back to host if the endpoint fails
+// addPatch adds the containerd config patch to the kind config unless it
+// is already there
+func addPatch(cfg *v1alpha4.Cluster, patch string) {
+	for _, p := range cfg.ContainerdConfigPatches {
+		if p == patch {
+			return
+		}
+	}
+
+	cfg.ContainerdConfigPatches = append(cfg.ContainerdConfigPatches, patch)
+}
+
+func mirror(host, endpoint string) string {
+	return fmt.Sprintf(
+		"[plugins.\"io.containerd.grpc.v1.cri\".registry.mirrors.\"%s\"]\n  endpoint = [\"http://%s\"]",
+		host,
+		endpoint,
+	)
+}
+
+// run creates the registry container in the VM
+func run(r *models.Registry) error {
+	logrus.Infof("Starting registry in VM %s", r.VM)
+	return runContainer(
+		r.VM, ContainerName,
+		"--publish", fmt.Sprintf("0.0.0.0:%d:%d", r.Port, containerPort),
+		"--volume", volumeName+":/var/lib/registry",
+		r.Image,
+	)
+}
+
+// start starts the registry container, it is recreated if it is missing
+func start(r *models.Registry) error {
+	if !exists(r.VM, "container", ContainerName) {
+		return run(r)
+	}
+
+	return startContainer(r.VM, ContainerName)
+}
+
+// runContainer creates the registry container in the VM and attaches it to
+// the kind network
+func runContainer(vmName, container string, args ...string) error {
+	args = append([]string{"run", "-d", "--name", container, "--restart", "always"}, args...)
+	if err := docker(vmName, args...).RunSilent(); err != nil {
+		return fmt.Errorf("failed to start container %s: %w", container, err)
+	}
+
+	// The kind network only exists once the first cluster is created
+	if exists(vmName, "network", kindNetwork) {
+		return connect(vmName, container)
+	}
+
+	return nil
+}
+
+// startContainer starts the container if it isn't running and attaches it
+// to the kind network
+func startContainer(vmName, container string) error {
+	if !running(vmName, container) {
+		if err := docker(vmName, "start", container).RunSilent(); err != nil {
+			return fmt.Errorf("failed to start container %s: %w", container, err)
+		}
+	}
+
+	return connect(vmName, container)
+}
+
+// connect attaches the container to the kind network if it isn't attached
+func connect(vmName, container string) error {
+	out, err := docker(vmName, "inspect", "-f", "{{json .NetworkSettings.Networks}}", container).Query().Output()
+	if err != nil {
+		return fmt.Errorf("failed to inspect container %s: %w", container, err)
+	}
+
+	if strings.Contains(string(out), fmt.Sprintf("\"%s\"", kindNetwork)) {
+		return nil
+	}
+
+	if err := docker(vmName, "network", "connect", kindNetwork, container).RunSilent(); err != nil {
+		return fmt.Errorf("failed to connect %s to the kind network: %w", container, err)
+	}
+
+	return nil
+}
+
+func running(vmName, container string) bool {
+	out, err := docker(vmName, "inspect", "-f", "{{.State.Running}}", container).Query().Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+func exists(vmName, object, name string) bool {
+	return docker(vmName, object, "inspect", name).Query().RunSilent() == nil
+}
+
+// nextPort returns the port that should be assigned to a new registry
+func nextPort() (int, error) {
+	port, err := models.GetMaxRegistryPort()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get max registry port: %w", err)
+	}
+
+	if port == 0 {
+		return DefaultPort, nil
+	}
+
+	return port + 1, nil
+}
+
+// docker returns the docker command run against the daemon of the VM
+func docker(vmName string, args ...string) *sh.Command {
+	return sh.NewCommand("docker", args...).WithEnv("DOCKER_CONTEXT", vmName)
+}
@@ -0,0 +1,91 @@
+package sh
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Response is the canned response returned by the Recorder
+type Response struct {
+	Output []byte
+	Err    error
+}
+
+// Recorder is a fake Runner which records the commands instead of executing
+// them. It is meant to be used in tests via Record:
+//
+//	rec := sh.Record(t)
+//	rec.On("limactl ls", sh.Response{Output: []byte("kindli=Running")})
+type Recorder struct {
+	mu        sync.Mutex
+	commands  []string
+	responses map[string]Response
+}
+
+// NewRecorder returns a new Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		responses: map[string]Response{},
+	}
+}
+
+// On registers the response for the commands which start with the given
+// prefix, the longest matching prefix wins
+func (r *Recorder) On(prefix string, resp Response) *Recorder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.responses[prefix] = resp
+	return r
+}
+
+// Commands returns all the recorded commands in the order of execution
+func (r *Recorder) Commands() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string{}, r.commands...)
+}
+
+// Reset clears the recorded commands
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.commands = nil
+}
+
+func (r *Recorder) Run(ctx context.Context, c *Command) error {
+	resp, ok := r.record(c)
+	if !ok {
+		return nil
+	}
+
+	// The output is written without holding the lock, the command may be
+	// piped into another command run by the Recorder
+	if c.Stdout != nil {
+		c.Stdout.Write(resp.Output)
+	}
+
+	return resp.Err
+}
+
+// record records the command and returns the response registered for it
+func (r *Recorder) record(c *Command) (Response, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cmdline := c.String()
+	r.commands = append(r.commands, cmdline)
+
+	match := ""
+	for prefix := range r.responses {
+		if strings.HasPrefix(cmdline, prefix) && len(prefix) >= len(match) {
+			match = prefix
+		}
+	}
+
+	resp, ok := r.responses[match]
+	return resp, ok
+}
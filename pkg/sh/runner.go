@@ -0,0 +1,77 @@
+package sh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Runner executes commands
+type Runner interface {
+	Run(ctx context.Context, cmd *Command) error
+}
+
+var runner Runner = &ExecRunner{}
+
+// SetRunner replaces the runner used for executing all the commands
+func SetRunner(r Runner) {
+	runner = r
+}
+
+// GetRunner returns the runner used for executing the commands
+func GetRunner() Runner {
+	return runner
+}
+
+// ExecRunner executes the commands on the host
+type ExecRunner struct{}
+
+func (er *ExecRunner) Run(ctx context.Context, c *Command) error {
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+
+	if c.Interactive {
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+
+	if c.Stdin != nil {
+		cmd.Stdin = c.Stdin
+	}
+
+	if c.Stdout != nil {
+		cmd.Stdout = c.Stdout
+	}
+
+	return cmd.Run()
+}
+
+// DryRunner prints the commands instead of executing them. Read only
+// commands are still executed so that kindli can make the same decisions
+// as it would without the dry-run mode.
+type DryRunner struct {
+	Out  io.Writer
+	Exec Runner
+}
+
+// NewDryRunner returns a dry runner which prints the commands to out
+func NewDryRunner(out io.Writer) *DryRunner {
+	return &DryRunner{
+		Out:  out,
+		Exec: &ExecRunner{},
+	}
+}
+
+func (dr *DryRunner) Run(ctx context.Context, c *Command) error {
+	if c.ReadOnly {
+		return dr.Exec.Run(ctx, c)
+	}
+
+	_, err := fmt.Fprintln(dr.Out, c.String())
+	return err
+}
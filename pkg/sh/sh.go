@@ -1,41 +1,181 @@
 package sh
 
 import (
-	"os"
-	"os/exec"
+	"bytes"
+	"context"
+	"io"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
-func RunSilent(scmd string) error {
-	logrus.Debug("Silently running: ", scmd)
-	cmds := []string{"-c", scmd}
+// Command is a command along with its arguments which is executed without
+// any shell interpretation via the configured Runner
+type Command struct {
+	Name string
+	Args []string
+
+	Stdin io.Reader
+	// Stdout receives the standard output of the command, if set
+	Stdout io.Writer
+	// Env is appended to the environment of kindli for the command
+	Env []string
+	// Interactive attaches the command to the terminal
+	Interactive bool
+	// ReadOnly marks the command as one which doesn't mutate anything,
+	// read only commands are executed even in the dry-run mode
+	ReadOnly bool
 
-	cmd := exec.Command("bash", cmds...)
-	return cmd.Run()
+	ctx     context.Context
+	timeout time.Duration
 }
 
-func Run(scmd string) error {
-	logrus.Debug("Running: ", scmd)
-	cmds := []string{"-c", scmd}
+// NewCommand returns a new command for the given argv
+func NewCommand(name string, args ...string) *Command {
+	return &Command{
+		Name: name,
+		Args: args,
+		ctx:  context.Background(),
+	}
+}
 
-	cmd := exec.Command("bash", cmds...)
+// WithEnv adds the environment variable to the environment of the command
+func (c *Command) WithEnv(key, value string) *Command {
+	c.Env = append(c.Env, key+"="+value)
+	return c
+}
 
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// Context sets the context of the command, the command is killed if the
+// context is done before the command completes
+func (c *Command) Context(ctx context.Context) *Command {
+	c.ctx = ctx
+	return c
+}
 
-	return cmd.Run()
+// Timeout sets the timeout for the command
+func (c *Command) Timeout(timeout time.Duration) *Command {
+	c.timeout = timeout
+	return c
 }
 
-func RunMany(scmds []string) error {
-	return Run(strings.Join(scmds, ";"))
+// Query marks the command as read only
+func (c *Command) Query() *Command {
+	c.ReadOnly = true
+	return c
+}
+
+// Run runs the command attached to the terminal
+func (c *Command) Run() error {
+	logrus.Debug("Running: ", c)
+	c.Interactive = true
+
+	return c.run()
+}
+
+// RunSilent runs the command discarding its output
+func (c *Command) RunSilent() error {
+	logrus.Debug("Silently running: ", c)
+
+	return c.run()
+}
+
+// Output runs the command and returns its standard output
+func (c *Command) Output() ([]byte, error) {
+	logrus.Debug("Running: ", c)
+	out := &bytes.Buffer{}
+	c.Stdout = out
+
+	err := c.run()
+	return out.Bytes(), err
+}
+
+// Pipe runs src with its standard output connected to the standard input
+// of dst, like `src | dst` with pipefail in a shell
+//
+// The error of dst takes precedence over the one of src as a failing dst
+// makes src fail as well once the pipe is closed.
+func Pipe(src, dst *Command) error {
+	logrus.Debugf("Silently running: %s | %s", src, dst)
+
+	pr, pw := io.Pipe()
+	src.Stdout = pw
+	dst.Stdin = pr
+
+	srcErr := make(chan error, 1)
+	go func() {
+		err := src.run()
+		pw.CloseWithError(err)
+		srcErr <- err
+	}()
+
+	dstErr := dst.run()
+	// Unblocks src if dst exits without reading all of its output
+	pr.Close()
+
+	err := <-srcErr
+	if dstErr != nil {
+		return dstErr
+	}
+
+	return err
 }
 
-func RunIO(scmd string) ([]byte, error) {
-	logrus.Debug("Running: ", scmd)
-	cmds := []string{"-c", scmd}
+// String returns the command as it can be typed in a POSIX shell
+func (c *Command) String() string {
+	parts := []string{}
+	for _, env := range c.Env {
+		parts = append(parts, Quote(env))
+	}
+
+	parts = append(parts, Quote(c.Name))
+	for _, arg := range c.Args {
+		parts = append(parts, Quote(arg))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (c *Command) run() error {
+	ctx := c.ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	return runner.Run(ctx, c)
+}
+
+var safeArg = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Quote quotes the argument for a POSIX shell if required
+func Quote(arg string) string {
+	if safeArg.MatchString(arg) {
+		return arg
+	}
 
-	return exec.Command("bash", cmds...).Output()
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// RunSilent runs the shell script via bash discarding its output
+//
+// Prefer NewCommand unless shell features (pipes, redirections, etc.) are
+// required.
+func RunSilent(scmd string) error {
+	return NewCommand("bash", "-c", scmd).RunSilent()
+}
+
+// Run runs the shell script via bash attached to the terminal
+//
+// Prefer NewCommand unless shell features (pipes, redirections, etc.) are
+// required.
+func Run(scmd string) error {
+	return NewCommand("bash", "-c", scmd).Run()
+}
+
+// RunMany runs the shell scripts one after another
+func RunMany(scmds []string) error {
+	return Run(strings.Join(scmds, ";"))
 }
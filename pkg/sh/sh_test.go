@@ -0,0 +1,67 @@
+package sh
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPipe(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     *Command
+		dst     *Command
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "output of src is the input of dst",
+			src:  NewCommand("printf", "a\nb\n"),
+			dst:  NewCommand("tr", "a-z", "A-Z"),
+			want: "A\nB\n",
+		},
+		{
+			name:    "failing src",
+			src:     NewCommand("false"),
+			dst:     NewCommand("cat"),
+			wantErr: true,
+		},
+		{
+			name:    "failing dst",
+			src:     NewCommand("printf", "a\n"),
+			dst:     NewCommand("sh", "-c", "exit 3"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			tt.dst.Stdout = out
+
+			if err := Pipe(tt.src, tt.dst); (err != nil) != tt.wantErr {
+				t.Fatalf("Pipe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeRecorder(t *testing.T) {
+	rec := Record(t)
+	rec.On("docker save", Response{Output: []byte("archive")})
+	rec.On("docker load", Response{Err: errors.New("no space left on device")})
+
+	err := Pipe(NewCommand("docker", "save", "nginx"), NewCommand("docker", "load"))
+	if err == nil || err.Error() != "no space left on device" {
+		t.Errorf("Pipe() error = %v, want the error of docker load", err)
+	}
+
+	commands := rec.Commands()
+	if len(commands) != 2 {
+		t.Fatalf("commands = %q, want docker save and docker load", commands)
+	}
+}
@@ -0,0 +1,13 @@
+package sh
+
+import "testing"
+
+// Record makes a new Recorder the Runner for the duration of the test, the
+// ExecRunner is restored once the test completes
+func Record(t testing.TB) *Recorder {
+	rec := NewRecorder()
+	SetRunner(rec)
+	t.Cleanup(func() { SetRunner(&ExecRunner{}) })
+
+	return rec
+}
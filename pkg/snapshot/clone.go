@@ -0,0 +1,184 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/kind"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+// cloneDockerfile builds the image the nodes of a clone are created from,
+// the kubeadm state of the committed node is bound to the name and the
+// subnets of the snapshotted cluster hence it is dropped
+const cloneDockerfile = `FROM %s
+RUN rm -rf /etc/kubernetes && mkdir -p /etc/kubernetes/manifests
+`
+
+// Clone creates the cluster cloneName in the VM of the snapshot
+//
+// The clone is created by kind from the kind config of the snapshot with
+// fresh subnets and the committed images of the nodes, and gets the
+// metallb version and the add-ons of the snapshot. The etcd, kubelet and
+// containerd state in /var is bound to the snapshotted cluster and is not
+// carried over, hence the workloads of the snapshot are not part of the
+// clone.
+func Clone(clusterName, name, cloneName string) error {
+	snap := models.NewSnapshot(clusterName, name)
+	if err := snap.GetByName(); err != nil {
+		return fmt.Errorf("failed to find snapshot %s of cluster \"%s\": %w", name, clusterName, err)
+	}
+
+	exists, err := models.NewCluster(cloneName, "", "").Exists()
+	if err != nil {
+		return fmt.Errorf("failed to check if cluster \"%s\" exists: %w", cloneName, err)
+	}
+
+	if exists {
+		return fmt.Errorf("cluster \"%s\" already exists", cloneName)
+	}
+
+	st, _, err := loadFiles(snap)
+	if err != nil {
+		return fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	cfg, err := cloneConfig(snap)
+	if err != nil {
+		return fmt.Errorf("failed to load kind config of snapshot: %w", err)
+	}
+
+	if err := useVM(snap.VM); err != nil {
+		return err
+	}
+
+	for i, role := range nodeRoles(cfg) {
+		node := clusterName + "-" + role
+		if !contains(snap.Nodes, node) {
+			return fmt.Errorf("snapshot %s has no node %s", name, node)
+		}
+
+		image, err := buildCloneImage(snap, node)
+		if err != nil {
+			return fmt.Errorf("failed to build image of node %s: %w", node, err)
+		}
+
+		cfg.Nodes[i].Image = image
+	}
+
+	addons := []string{}
+	for _, ca := range st.Addons {
+		addons = append(addons, ca.Addon)
+	}
+
+	err = kind.Create("", kind.CreateConfig{
+		Name:           cloneName,
+		VMName:         snap.VM,
+		SkipMetalLB:    st.Cluster.MetalLBVersion == "",
+		MetalLBVersion: st.Cluster.MetalLBVersion,
+		Addons:         addons,
+		KindConfig:     cfg,
+	})
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("✅ Cloned snapshot %s of cluster \"%s\" to cluster \"%s\"", name, clusterName, cloneName)
+	return nil
+}
+
+// cloneConfig returns the kind config of the snapshot without the subnets
+// allocated to the snapshotted cluster, fresh ones are allocated for the
+// clone
+func cloneConfig(snap *models.Snapshot) (*v1alpha4.Cluster, error) {
+	byt, err := os.ReadFile(filepath.Join(snap.Path, kindConfigFile))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &v1alpha4.Cluster{}
+	if err := yaml.Unmarshal(byt, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", kindConfigFile, err)
+	}
+
+	cfg.Name = ""
+	cfg.Networking.PodSubnet = ""
+	cfg.Networking.ServiceSubnet = ""
+
+	// kind creates a single control plane if the config has no nodes
+	if len(cfg.Nodes) == 0 {
+		cfg.Nodes = []v1alpha4.Node{{Role: v1alpha4.ControlPlaneRole}}
+	}
+
+	return cfg, nil
+}
+
+// nodeRoles returns the names of the nodes of the config without the
+// cluster prefix, in the order of the config, e.g. "control-plane" or
+// "worker2", the same way kind names the node containers
+func nodeRoles(cfg *v1alpha4.Cluster) []string {
+	count := map[v1alpha4.NodeRole]int{}
+
+	roles := []string{}
+	for _, n := range cfg.Nodes {
+		role := n.Role
+		if role == "" {
+			role = v1alpha4.ControlPlaneRole
+		}
+
+		count[role]++
+
+		suffix := ""
+		if count[role] > 1 {
+			suffix = strconv.Itoa(count[role])
+		}
+
+		roles = append(roles, string(role)+suffix)
+	}
+
+	return roles
+}
+
+// buildCloneImage builds the image the clones of the node are created
+// from, the image is built once per snapshot
+func buildCloneImage(snap *models.Snapshot, node string) (string, error) {
+	image := cloneImageName(snap, node)
+	if imageExists(image) {
+		return image, nil
+	}
+
+	cmd := sh.NewCommand("docker", "build", "--tag", image, "--label", label+"="+snap.Cluster+"."+snap.Name, "-")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf(cloneDockerfile, imageName(snap, node)))
+
+	if err := cmd.RunSilent(); err != nil {
+		return "", err
+	}
+
+	return image, nil
+}
+
+// cloneImageName returns the image the clones of the node are created from
+func cloneImageName(snap *models.Snapshot, node string) string {
+	return imageName(snap, node) + "-clone"
+}
+
+func imageExists(image string) bool {
+	return sh.NewCommand("docker", "image", "inspect", image).Query().RunSilent() == nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,85 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+func TestCloneConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		wantRoles  []string
+		wantSubnet string
+	}{
+		{
+			name: "nodes are named the way kind names them",
+			config: `kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+name: kindli-dev
+networking:
+  podSubnet: 10.128.0.0/18
+  serviceSubnet: 10.96.0.0/20
+nodes:
+- role: control-plane
+- role: worker
+- role: control-plane
+- role: worker
+- role: worker
+`,
+			wantRoles: []string{"control-plane", "worker", "control-plane2", "worker2", "worker3"},
+		},
+		{
+			name: "config without nodes has a single control plane",
+			config: `kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+networking:
+  ipFamily: ipv6
+  podSubnet: fd00:10:128::/56
+  serviceSubnet: fd00:10:96::/112
+`,
+			wantRoles: []string{"control-plane"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snap := &models.Snapshot{Path: t.TempDir()}
+			if err := os.WriteFile(filepath.Join(snap.Path, kindConfigFile), []byte(tt.config), 0666); err != nil {
+				t.Fatalf("failed to write kind config: %v", err)
+			}
+
+			cfg, err := cloneConfig(snap)
+			if err != nil {
+				t.Fatalf("cloneConfig() error = %v", err)
+			}
+
+			if cfg.Kind != "Cluster" || cfg.Name != "" {
+				t.Errorf("kind = %q, name = %q, want Cluster and no name", cfg.Kind, cfg.Name)
+			}
+
+			// Fresh subnets are allocated for the clone
+			if cfg.Networking.PodSubnet != "" || cfg.Networking.ServiceSubnet != "" {
+				t.Errorf("subnets = %q, %q, want none", cfg.Networking.PodSubnet, cfg.Networking.ServiceSubnet)
+			}
+
+			if got := nodeRoles(cfg); !reflect.DeepEqual(got, tt.wantRoles) {
+				t.Errorf("nodeRoles() = %q, want %q", got, tt.wantRoles)
+			}
+		})
+	}
+}
+
+func TestNodeRolesDefaultRole(t *testing.T) {
+	cfg := &v1alpha4.Cluster{Nodes: []v1alpha4.Node{{}, {Role: v1alpha4.WorkerRole}, {}}}
+
+	want := []string{"control-plane", "worker", "control-plane2"}
+	if got := nodeRoles(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("nodeRoles() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,239 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+// varDir is the anonymous volume of the kind nodes holding containerd,
+// kubelet and etcd state, docker commit does not capture volumes hence it
+// is copied separately
+const varDir = "/var"
+
+// node is the subset of `docker inspect` of a kind node container needed
+// to recreate the container
+type node struct {
+	Name   string `json:"Name"`
+	Config struct {
+		Hostname string            `json:"Hostname"`
+		Labels   map[string]string `json:"Labels"`
+		Tty      bool              `json:"Tty"`
+	} `json:"Config"`
+	HostConfig struct {
+		Privileged   bool              `json:"Privileged"`
+		SecurityOpt  []string          `json:"SecurityOpt"`
+		Tmpfs        map[string]string `json:"Tmpfs"`
+		CgroupnsMode string            `json:"CgroupnsMode"`
+		Init         *bool             `json:"Init"`
+		PortBindings map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"PortBindings"`
+		RestartPolicy struct {
+			Name              string `json:"Name"`
+			MaximumRetryCount int    `json:"MaximumRetryCount"`
+		} `json:"RestartPolicy"`
+		Devices []struct {
+			PathOnHost        string `json:"PathOnHost"`
+			PathInContainer   string `json:"PathInContainer"`
+			CgroupPermissions string `json:"CgroupPermissions"`
+		} `json:"Devices"`
+	} `json:"HostConfig"`
+	Mounts []struct {
+		Type        string `json:"Type"`
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+		RW          bool   `json:"RW"`
+	} `json:"Mounts"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress         string `json:"IPAddress"`
+			GlobalIPv6Address string `json:"GlobalIPv6Address"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// containerName returns the name of the node container
+func (n *node) containerName() string {
+	return strings.TrimPrefix(n.Name, "/")
+}
+
+// createArgs returns the arguments of `docker create` which recreate the
+// node from the image, the node keeps its addresses so that its
+// certificates and the kubeconfig stay valid
+func (n *node) createArgs(image string) []string {
+	args := []string{
+		"create",
+		"--name", n.containerName(),
+		"--hostname", n.Config.Hostname,
+	}
+
+	if n.Config.Tty {
+		args = append(args, "--tty")
+	}
+
+	if n.HostConfig.Privileged {
+		args = append(args, "--privileged")
+	}
+
+	for _, opt := range n.HostConfig.SecurityOpt {
+		args = append(args, "--security-opt", opt)
+	}
+
+	for _, path := range sortedKeys(n.HostConfig.Tmpfs) {
+		tmpfs := path
+		if opts := n.HostConfig.Tmpfs[path]; opts != "" {
+			tmpfs += ":" + opts
+		}
+
+		args = append(args, "--tmpfs", tmpfs)
+	}
+
+	if n.HostConfig.CgroupnsMode != "" {
+		args = append(args, "--cgroupns", n.HostConfig.CgroupnsMode)
+	}
+
+	if n.HostConfig.Init != nil {
+		args = append(args, "--init="+strconv.FormatBool(*n.HostConfig.Init))
+	}
+
+	if policy := n.HostConfig.RestartPolicy; policy.Name != "" && policy.Name != "no" {
+		restart := policy.Name
+		if policy.MaximumRetryCount > 0 {
+			restart += ":" + strconv.Itoa(policy.MaximumRetryCount)
+		}
+
+		args = append(args, "--restart", restart)
+	}
+
+	for _, dev := range n.HostConfig.Devices {
+		device := dev.PathOnHost + ":" + dev.PathInContainer
+		if dev.CgroupPermissions != "" {
+			device += ":" + dev.CgroupPermissions
+		}
+
+		args = append(args, "--device", device)
+	}
+
+	for _, mount := range n.Mounts {
+		switch mount.Type {
+		case "volume":
+			// Anonymous volumes are created afresh, the content of /var is
+			// restored after the container is created
+			args = append(args, "--volume", mount.Destination)
+		case "bind":
+			volume := mount.Source + ":" + mount.Destination
+			if !mount.RW {
+				volume += ":ro"
+			}
+
+			args = append(args, "--volume", volume)
+		}
+	}
+
+	ports := []string{}
+	for port := range n.HostConfig.PortBindings {
+		ports = append(ports, port)
+	}
+	sort.Strings(ports)
+
+	for _, port := range ports {
+		for _, binding := range n.HostConfig.PortBindings[port] {
+			publish := binding.HostPort + ":" + port
+			if binding.HostIP != "" {
+				publish = binding.HostIP + ":" + publish
+			}
+
+			args = append(args, "--publish", publish)
+		}
+	}
+
+	for _, key := range sortedKeys(n.Config.Labels) {
+		args = append(args, "--label", key+"="+n.Config.Labels[key])
+	}
+
+	networks := []string{}
+	for network := range n.NetworkSettings.Networks {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+
+	if len(networks) > 0 {
+		if len(networks) > 1 {
+			logrus.Warnf("node %s is attached to networks %v, only %s is restored", n.containerName(), networks, networks[0])
+		}
+
+		network := n.NetworkSettings.Networks[networks[0]]
+		args = append(args, "--network", networks[0])
+		if network.IPAddress != "" {
+			args = append(args, "--ip", network.IPAddress)
+		}
+
+		if network.GlobalIPv6Address != "" {
+			args = append(args, "--ip6", network.GlobalIPv6Address)
+		}
+	}
+
+	return append(args, image)
+}
+
+// inspectNodes returns the docker inspect of the node containers
+func inspectNodes(names []string) ([]byte, error) {
+	out, err := sh.NewCommand("docker", append([]string{"inspect"}, names...)...).Query().Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect kind nodes: %w", err)
+	}
+
+	return out, nil
+}
+
+// parseNodes parses the docker inspect of the node containers
+func parseNodes(byt []byte) ([]*node, error) {
+	nodes := []*node{}
+	if err := json.Unmarshal(byt, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to parse inspect of kind nodes: %w", err)
+	}
+
+	return nodes, nil
+}
+
+// copyVar copies the /var volume of the node container to the volume
+func copyVar(container, image, volume string) error {
+	return sh.NewCommand(
+		"docker", "run", "--rm",
+		"--volumes-from", container,
+		"--volume", volume+":/snapshot",
+		"--entrypoint", "cp",
+		image,
+		"-a", varDir+"/.", "/snapshot/",
+	).RunSilent()
+}
+
+// restoreVar replaces the content of the /var volume of the node container
+// with the content of the volume
+func restoreVar(container, image, volume string) error {
+	return sh.NewCommand(
+		"docker", "run", "--rm",
+		"--volumes-from", container,
+		"--volume", volume+":/snapshot:ro",
+		"--entrypoint", "sh",
+		image,
+		"-c", fmt.Sprintf("find %s -mindepth 1 -delete && cp -a /snapshot/. %s/", varDir, varDir),
+	).RunSilent()
+}
+
+func sortedKeys(mp map[string]string) []string {
+	keys := []string{}
+	for key := range mp {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,120 @@
+package snapshot
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func loadNodes(t *testing.T) []*node {
+	t.Helper()
+
+	byt, err := os.ReadFile("testdata/inspect.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	nodes, err := parseNodes(byt)
+	if err != nil {
+		t.Fatalf("parseNodes() error = %v", err)
+	}
+
+	return nodes
+}
+
+func TestParseNodes(t *testing.T) {
+	nodes := loadNodes(t)
+
+	names := []string{}
+	for _, n := range nodes {
+		names = append(names, n.containerName())
+	}
+
+	want := []string{"kindli-dev-control-plane", "kindli-dev-worker"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("nodes = %q, want %q", names, want)
+	}
+
+	if _, err := parseNodes([]byte(`{"Name": "/kindli-dev-worker"}`)); err == nil {
+		t.Error("parseNodes() of an object, want error")
+	}
+}
+
+func TestCreateArgs(t *testing.T) {
+	nodes := loadNodes(t)
+
+	tests := []struct {
+		name  string
+		node  *node
+		image string
+		want  []string
+	}{
+		{
+			name:  "control plane",
+			node:  nodes[0],
+			image: "kindli-snapshot/kindli-dev:seeded.control-plane",
+			want: []string{
+				"create",
+				"--name", "kindli-dev-control-plane",
+				"--hostname", "kindli-dev-control-plane",
+				"--tty",
+				"--privileged",
+				"--security-opt", "seccomp=unconfined",
+				"--security-opt", "apparmor=unconfined",
+				"--security-opt", "label=disable",
+				"--tmpfs", "/run",
+				"--tmpfs", "/tmp",
+				"--cgroupns", "private",
+				"--init=false",
+				"--restart", "on-failure:1",
+				"--device", "/dev/fuse:/dev/fuse:rwm",
+				"--volume", "/var",
+				"--volume", "/lib/modules:/lib/modules:ro",
+				"--volume", "/Users/dev/src:/src",
+				"--publish", "127.0.0.1:40865:6443/tcp",
+				"--publish", "8080:80/tcp",
+				"--label", "io.x-k8s.kind.cluster=kindli-dev",
+				"--label", "io.x-k8s.kind.role=control-plane",
+				"--network", "kind",
+				"--ip", "172.18.0.2",
+				"--ip6", "fc00:f853:ccd:e793::2",
+				"kindli-snapshot/kindli-dev:seeded.control-plane",
+			},
+		},
+		{
+			name:  "worker",
+			node:  nodes[1],
+			image: "kindli-snapshot/kindli-dev:seeded.worker",
+			want: []string{
+				"create",
+				"--name", "kindli-dev-worker",
+				"--hostname", "kindli-dev-worker",
+				"--tty",
+				"--privileged",
+				"--security-opt", "seccomp=unconfined",
+				"--security-opt", "apparmor=unconfined",
+				"--security-opt", "label=disable",
+				"--tmpfs", "/run",
+				"--tmpfs", "/tmp",
+				"--cgroupns", "private",
+				"--init=false",
+				"--restart", "on-failure:1",
+				"--volume", "/lib/modules:/lib/modules:ro",
+				"--volume", "/var",
+				"--label", "io.x-k8s.kind.cluster=kindli-dev",
+				"--label", "io.x-k8s.kind.role=worker",
+				"--network", "kind",
+				"--ip", "172.18.0.3",
+				"kindli-snapshot/kindli-dev:seeded.worker",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.node.createArgs(tt.image); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("createArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
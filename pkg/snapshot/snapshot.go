@@ -0,0 +1,522 @@
+package snapshot
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/ipam"
+	"github.com/utkarsh-pro/kindli/pkg/kind"
+	"github.com/utkarsh-pro/kindli/pkg/metallb"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
+)
+
+const (
+	kindConfigFile    = "kind.yaml"
+	metalLBConfigFile = "metallb.yaml"
+	nodesFile         = "nodes.json"
+	stateFile         = "state.json"
+
+	// label is set on the volumes of the snapshots
+	label = "io.x-k8s.kindli.snapshot"
+)
+
+var nameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// state is the kindli state of a cluster captured by a snapshot
+type state struct {
+	Cluster     models.Cluster
+	Addons      []*models.ClusterAddon
+	Allocations []*models.IPAllocation
+}
+
+// List is a list of snapshots
+type List []*models.Snapshot
+
+func (l List) Header(wide bool) []string {
+	header := []string{"CLUSTER", "NAME", "VM", "NODES", "CREATED"}
+	if wide {
+		header = append(header, "PATH")
+	}
+
+	return header
+}
+
+func (l List) Rows(wide bool) [][]string {
+	rows := [][]string{}
+	for _, s := range l {
+		row := []string{s.Cluster, s.Name, s.VM, strconv.Itoa(len(s.Nodes)), s.CreatedAt}
+		if wide {
+			row = append(row, s.Path)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// Dir returns the directory where the snapshots are stored
+func Dir() string {
+	return filepath.Join(config.Dir(), "snapshots")
+}
+
+// Snapshots returns the snapshots of the cluster, or of all the clusters
+// if clusterName is empty
+func Snapshots(clusterName string) (List, error) {
+	snapshots, err := models.ListSnapshot(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// Create snapshots the cluster
+//
+// The nodes of the cluster are stopped while the snapshot is taken so that
+// the snapshot is consistent, they are started again afterwards. Every node
+// container is committed to an image and its /var volume is copied to a
+// docker volume, both live in the docker daemon of the VM. The persisted
+// kind and metallb configs and the kindli state of the cluster are stored
+// in the snapshot directory.
+func Create(clusterName, name string) error {
+	if !nameRegex.MatchString(name) {
+		return fmt.Errorf("invalid snapshot name \"%s\", must match %s", name, nameRegex)
+	}
+
+	c := models.NewCluster(clusterName, "", "")
+	if err := c.GetByName(); err != nil {
+		return fmt.Errorf("failed to find cluster with name \"%s\": %w", clusterName, err)
+	}
+
+	snap := models.NewSnapshot(clusterName, name)
+	if err := snap.GetByName(); err == nil {
+		return fmt.Errorf("snapshot %s of cluster \"%s\" already exists", name, clusterName)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	if c.Status == models.ClusterPaused {
+		return fmt.Errorf("cluster \"%s\" is paused, start it before taking a snapshot", clusterName)
+	}
+
+	if err := useVM(c.VM); err != nil {
+		return err
+	}
+
+	nodes, err := kind.Nodes(clusterName, c.VM)
+	if err != nil {
+		return err
+	}
+
+	if len(nodes) == 0 {
+		return fmt.Errorf("kind cluster \"%s\" does not exist in VM %s", clusterName, c.VM)
+	}
+
+	snap.VM = c.VM
+	snap.Nodes = nodes
+	snap.Path = filepath.Join(Dir(), clusterName, name)
+	snap.CreatedAt = time.Now().Format(time.RFC3339)
+
+	if err := saveFiles(c, snap); err != nil {
+		os.RemoveAll(snap.Path)
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+
+	if err := commitNodes(snap, c.Status == models.ClusterRunning); err != nil {
+		removeArtifacts(snap)
+		return fmt.Errorf("failed to snapshot nodes: %w", err)
+	}
+
+	if err := snap.Save(); err != nil {
+		removeArtifacts(snap)
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+
+	logrus.Infof("✅ Created snapshot %s of cluster \"%s\"", name, clusterName)
+	return nil
+}
+
+// Restore rolls the cluster back to the snapshot
+//
+// The current nodes of the cluster, if any, are replaced by nodes created
+// from the snapshot with the same names and addresses, hence a deleted
+// cluster can be restored as well. The kindli state and the persisted
+// configs of the cluster are replaced by the ones of the snapshot.
+func Restore(clusterName, name string) error {
+	snap := models.NewSnapshot(clusterName, name)
+	if err := snap.GetByName(); err != nil {
+		return fmt.Errorf("failed to find snapshot %s of cluster \"%s\": %w", name, clusterName, err)
+	}
+
+	st, nodes, err := loadFiles(snap)
+	if err != nil {
+		return fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	if err := checkAllocations(st); err != nil {
+		return fmt.Errorf("cannot restore snapshot: %w", err)
+	}
+
+	if err := useVM(snap.VM); err != nil {
+		return err
+	}
+
+	current, err := kind.Nodes(clusterName, snap.VM)
+	if err != nil {
+		return err
+	}
+
+	if len(current) > 0 {
+		logrus.Infof("Removing the current nodes of cluster \"%s\"", clusterName)
+		if err := sh.NewCommand("docker", append([]string{"rm", "-f", "-v"}, current...)...).RunSilent(); err != nil {
+			return fmt.Errorf("failed to remove nodes: %w", err)
+		}
+	}
+
+	if err := restoreState(st); err != nil {
+		return fmt.Errorf("failed to restore kindli state of the cluster: %w", err)
+	}
+
+	if err := restoreConfigs(snap, st); err != nil {
+		return fmt.Errorf("failed to restore configs of the cluster: %w", err)
+	}
+
+	names := []string{}
+	for _, n := range nodes {
+		container := n.containerName()
+		image := imageName(snap, container)
+
+		logrus.Infof("Restoring node %s", container)
+		if err := sh.NewCommand("docker", n.createArgs(image)...).RunSilent(); err != nil {
+			return fmt.Errorf("failed to create node %s: %w", container, err)
+		}
+
+		if err := restoreVar(container, image, volumeName(snap, container)); err != nil {
+			return fmt.Errorf("failed to restore %s of node %s: %w", varDir, container, err)
+		}
+
+		names = append(names, container)
+	}
+
+	if err := sh.NewCommand("docker", append([]string{"start"}, names...)...).RunSilent(); err != nil {
+		return fmt.Errorf("failed to start nodes: %w", err)
+	}
+
+	if err := sh.NewCommand("kind", "export", "kubeconfig", "--name", clusterName).Run(); err != nil {
+		return fmt.Errorf("failed to export kubeconfig: %w", err)
+	}
+
+	logrus.Infof("✅ Restored cluster \"%s\" to snapshot %s", clusterName, name)
+	return nil
+}
+
+// Delete deletes the snapshot along with its images and volumes
+func Delete(clusterName, name string) error {
+	snap := models.NewSnapshot(clusterName, name)
+	if err := snap.GetByName(); err != nil {
+		return fmt.Errorf("failed to find snapshot %s of cluster \"%s\": %w", name, clusterName, err)
+	}
+
+	// The images and the volumes of VMs which don't exist anymore are gone
+	// with the VM
+	exists, err := models.NewVM(snap.VM, "", 0).Exists()
+	if err != nil {
+		return fmt.Errorf("failed to check if VM %s exists: %w", snap.VM, err)
+	}
+
+	if exists {
+		if err := useVM(snap.VM); err != nil {
+			return err
+		}
+
+		removeArtifacts(snap)
+	} else if err := os.RemoveAll(snap.Path); err != nil {
+		return fmt.Errorf("failed to remove snapshot: %w", err)
+	}
+
+	if err := snap.Delete(); err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+
+	logrus.Infof("✅ Deleted snapshot %s of cluster \"%s\"", name, clusterName)
+	return nil
+}
+
+// useVM makes the docker daemon of the running VM the target of the
+// docker commands
+func useVM(vmName string) error {
+	running, err := vm.Running(vmName)
+	if err != nil {
+		return fmt.Errorf("failed to check if VM %s is running: %w", vmName, err)
+	}
+
+	if !running {
+		return fmt.Errorf("VM %s is not running", vmName)
+	}
+
+	return vm.UseDockerContext(vmName)
+}
+
+// commitNodes stops the nodes, commits them and copies their /var volumes,
+// the nodes are started again afterwards if they were running
+func commitNodes(snap *models.Snapshot, running bool) error {
+	if running {
+		logrus.Infof("Stopping the nodes of cluster \"%s\"", snap.Cluster)
+		if err := sh.NewCommand("docker", append([]string{"stop"}, snap.Nodes...)...).RunSilent(); err != nil {
+			return fmt.Errorf("failed to stop nodes: %w", err)
+		}
+
+		defer func() {
+			logrus.Infof("Starting the nodes of cluster \"%s\"", snap.Cluster)
+			if err := sh.NewCommand("docker", append([]string{"start"}, snap.Nodes...)...).RunSilent(); err != nil {
+				logrus.Errorf("failed to start nodes of cluster \"%s\": %s", snap.Cluster, err)
+			}
+		}()
+	}
+
+	for _, node := range snap.Nodes {
+		image := imageName(snap, node)
+		volume := volumeName(snap, node)
+
+		logrus.Infof("Snapshotting node %s", node)
+		if err := sh.NewCommand("docker", "commit", node, image).RunSilent(); err != nil {
+			return fmt.Errorf("failed to commit node %s: %w", node, err)
+		}
+
+		if err := sh.NewCommand("docker", "volume", "create", "--label", label+"="+snap.Cluster+"."+snap.Name, volume).RunSilent(); err != nil {
+			return fmt.Errorf("failed to create volume for node %s: %w", node, err)
+		}
+
+		if err := copyVar(node, image, volume); err != nil {
+			return fmt.Errorf("failed to copy %s of node %s: %w", varDir, node, err)
+		}
+	}
+
+	return nil
+}
+
+// removeArtifacts removes the images, the volumes and the directory of the
+// snapshot, failures are only logged
+func removeArtifacts(snap *models.Snapshot) {
+	for _, node := range snap.Nodes {
+		if err := sh.NewCommand("docker", "rmi", imageName(snap, node)).RunSilent(); err != nil {
+			logrus.Warnf("failed to remove image of node %s: %s", node, err)
+		}
+
+		if err := sh.NewCommand("docker", "volume", "rm", volumeName(snap, node)).RunSilent(); err != nil {
+			logrus.Warnf("failed to remove volume of node %s: %s", node, err)
+		}
+
+		// The clone image only exists if the snapshot has been cloned
+		if image := cloneImageName(snap, node); imageExists(image) {
+			if err := sh.NewCommand("docker", "rmi", image).RunSilent(); err != nil {
+				logrus.Warnf("failed to remove clone image of node %s: %s", node, err)
+			}
+		}
+	}
+
+	if err := os.RemoveAll(snap.Path); err != nil {
+		logrus.Warnf("failed to remove %s: %s", snap.Path, err)
+	}
+}
+
+// saveFiles stores the persisted configs, the nodes and the kindli state
+// of the cluster in the snapshot directory
+func saveFiles(c *models.Cluster, snap *models.Snapshot) error {
+	if err := os.MkdirAll(snap.Path, 0777); err != nil {
+		return err
+	}
+
+	inspect, err := inspectNodes(snap.Nodes)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(snap.Path, nodesFile), inspect, 0666); err != nil {
+		return err
+	}
+
+	st := state{Cluster: *c}
+
+	st.Addons, err = models.ListClusterAddon(c.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list addons: %w", err)
+	}
+
+	allocs, err := models.ListIPAllocation()
+	if err != nil {
+		return fmt.Errorf("failed to list IP allocations: %w", err)
+	}
+
+	for _, alloc := range allocs {
+		if alloc.Owner == c.IPOwner() {
+			st.Allocations = append(st.Allocations, alloc)
+		}
+	}
+
+	byt, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(snap.Path, stateFile), byt, 0666); err != nil {
+		return err
+	}
+
+	if err := copyFile(c.KindConfigPath, filepath.Join(snap.Path, kindConfigFile)); err != nil {
+		return fmt.Errorf("failed to copy kind config: %w", err)
+	}
+
+	err = copyFile(metallb.ConfigPath(c.Name), filepath.Join(snap.Path, metalLBConfigFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to copy metallb config: %w", err)
+	}
+
+	return nil
+}
+
+func loadFiles(snap *models.Snapshot) (*state, []*node, error) {
+	byt, err := os.ReadFile(filepath.Join(snap.Path, stateFile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	st := &state{}
+	if err := json.Unmarshal(byt, st); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", stateFile, err)
+	}
+
+	byt, err = os.ReadFile(filepath.Join(snap.Path, nodesFile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nodes, err := parseNodes(byt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return st, nodes, nil
+}
+
+// checkAllocations returns an error if the subnets of the snapshot have
+// been allocated to other owners since the snapshot was taken
+func checkAllocations(st *state) error {
+	allocs, err := models.ListIPAllocation()
+	if err != nil {
+		return fmt.Errorf("failed to list IP allocations: %w", err)
+	}
+
+	for _, want := range st.Allocations {
+		_, wanted, err := net.ParseCIDR(want.CIDR)
+		if err != nil {
+			return err
+		}
+
+		for _, alloc := range allocs {
+			if alloc.Owner == st.Cluster.IPOwner() {
+				continue
+			}
+
+			_, used, err := net.ParseCIDR(alloc.CIDR)
+			if err == nil && ipam.Overlaps(wanted, used) {
+				return fmt.Errorf("subnet %s of the snapshot is now allocated to %s", want.CIDR, alloc.Owner)
+			}
+		}
+	}
+
+	return nil
+}
+
+// restoreState replaces the kindli state of the cluster with the one of
+// the snapshot
+func restoreState(st *state) error {
+	c := st.Cluster
+	c.Status = models.ClusterRunning
+
+	if err := ipam.Release(c.IPOwner()); err != nil {
+		return err
+	}
+
+	if err := models.DeleteClusterAddons(c.Name); err != nil {
+		return err
+	}
+
+	if err := c.Delete(); err != nil {
+		return err
+	}
+
+	if err := c.Save(); err != nil {
+		return err
+	}
+
+	for _, ca := range st.Addons {
+		if err := ca.Save(); err != nil {
+			return err
+		}
+	}
+
+	for _, alloc := range st.Allocations {
+		if err := ipam.Reserve(alloc.Pool, alloc.CIDR, alloc.Owner); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func restoreConfigs(snap *models.Snapshot, st *state) error {
+	if err := copyFile(filepath.Join(snap.Path, kindConfigFile), st.Cluster.KindConfigPath); err != nil {
+		return err
+	}
+
+	err := copyFile(filepath.Join(snap.Path, metalLBConfigFile), metallb.ConfigPath(st.Cluster.Name))
+	if errors.Is(err, os.ErrNotExist) {
+		return metallb.RemoveConfigFromDisk(st.Cluster.Name)
+	}
+
+	return err
+}
+
+// imageName returns the image the node is committed to
+func imageName(snap *models.Snapshot, node string) string {
+	return fmt.Sprintf("kindli-snapshot/%s:%s.%s", snap.Cluster, snap.Name, nodeRole(snap.Cluster, node))
+}
+
+// volumeName returns the volume the /var of the node is copied to
+func volumeName(snap *models.Snapshot, node string) string {
+	return fmt.Sprintf("kindli-snapshot.%s.%s.%s", snap.Cluster, snap.Name, nodeRole(snap.Cluster, node))
+}
+
+// nodeRole returns the name of the node without the cluster prefix, e.g.
+// "control-plane" or "worker2"
+func nodeRole(clusterName, node string) string {
+	return strings.TrimPrefix(node, clusterName+"-")
+}
+
+func copyFile(src, dst string) error {
+	byt, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return err
+	}
+
+	return os.WriteFile(dst, byt, 0666)
+}
@@ -0,0 +1,238 @@
+package topology
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/addon"
+	"github.com/utkarsh-pro/kindli/pkg/kind"
+	"github.com/utkarsh-pro/kindli/pkg/metallb"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
+)
+
+// Apply converges the kindli store to the topology, see Compute
+//
+// Applying the same topology again is a no-op.
+func Apply(t *Topology, prune bool) error {
+	plan, err := Compute(t, prune)
+	if err != nil {
+		return err
+	}
+
+	if !plan.Changes() {
+		logrus.Info("✅ Topology is up to date")
+		return nil
+	}
+
+	for _, a := range plan {
+		if err := t.execute(a); err != nil {
+			return fmt.Errorf("failed to %s %s %s: %w", a.Action, a.Resource, a.Name, err)
+		}
+	}
+
+	logrus.Info("✅ Topology applied")
+	return nil
+}
+
+func (t *Topology) execute(a Action) error {
+	switch a.Resource {
+	case ResourceVM:
+		if a.Action == ActionNone {
+			return nil
+		}
+
+		overrides, err := a.vm.spec().Overrides()
+		if err != nil {
+			return err
+		}
+
+		return vm.Start(a.vm.Provider, overrides, true, a.vm.Name)
+	case ResourceCluster:
+		if a.Action == ActionNone {
+			return nil
+		}
+
+		if err := vm.EnsureDockerContext(a.VM); err != nil {
+			return err
+		}
+
+		switch a.Action {
+		case ActionCreate:
+			logrus.Infof("Creating cluster %s in VM %s", a.Name, a.VM)
+			return kind.Create(t.configPath(a.cluster), kind.CreateConfig{
+				Name:           a.Name,
+				VMName:         a.VM,
+				SkipMetalLB:    a.cluster.SkipMetalLB,
+				MetalLBVersion: a.cluster.MetalLBVersion,
+				Addons:         a.cluster.Addons,
+				KindConfig:     a.cluster.KindConfig,
+			})
+		case ActionDelete:
+			logrus.Infof("Deleting cluster %s from VM %s", a.Name, a.VM)
+			return kind.Delete(a.Name)
+		case ActionUpdate:
+			logrus.Infof("Updating cluster %s in VM %s", a.Name, a.VM)
+			return update(a)
+		}
+	}
+
+	return fmt.Errorf("unknown action")
+}
+
+func update(a Action) error {
+	if a.start {
+		if err := kind.Start(a.Name); err != nil {
+			return err
+		}
+	}
+
+	switch a.metallb {
+	case ActionCreate:
+		if err := metallb.Install(a.Name, a.cluster.MetalLBVersion); err != nil {
+			return err
+		}
+	case ActionUpdate:
+		if err := metallb.Upgrade(a.Name, a.cluster.MetalLBVersion); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range a.enable {
+		if err := addon.Enable(a.Name, name); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range a.disable {
+		if err := addon.Disable(a.Name, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Destroy deletes the VMs of the topology along with all of their clusters
+//
+// If keepVMs is true only the clusters of the topology are deleted and the
+// VMs are left running.
+func Destroy(t *Topology, keepVMs bool) error {
+	for i := len(t.VMs) - 1; i >= 0; i-- {
+		v := &t.VMs[i]
+
+		exists, err := models.NewVM(v.Name, "", 0).Exists()
+		if err != nil {
+			return fmt.Errorf("failed to check if VM %s exists: %w", v.Name, err)
+		}
+
+		if !exists {
+			logrus.Infof("VM %s does not exist - skipping", v.Name)
+			continue
+		}
+
+		running, err := vm.Running(v.Name)
+		if err != nil {
+			return fmt.Errorf("failed to check if VM %s is running: %w", v.Name, err)
+		}
+
+		if keepVMs {
+			if err := destroyClusters(v, running); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := destroyVM(v, running); err != nil {
+			return err
+		}
+	}
+
+	logrus.Info("✅ Topology destroyed")
+	return nil
+}
+
+// destroyClusters deletes the clusters of the topology from the VM
+func destroyClusters(v *VM, running bool) error {
+	if !running {
+		return fmt.Errorf("VM %s is not running, start it to delete its clusters", v.Name)
+	}
+
+	if err := vm.EnsureDockerContext(v.Name); err != nil {
+		return err
+	}
+
+	for j := len(v.Clusters) - 1; j >= 0; j-- {
+		name := v.Clusters[j].ClusterName(v.Name)
+		if !kind.Exists(name, v.Name) {
+			continue
+		}
+
+		logrus.Infof("Deleting cluster %s from VM %s", name, v.Name)
+		if err := kind.Delete(name); err != nil {
+			return fmt.Errorf("failed to delete cluster %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// destroyVM deletes the VM, the clusters of the VM go along with it hence
+// they are only removed from the store once the VM is deleted
+//
+// VMs of the host provider stay up, their clusters are deleted from the
+// docker daemon of the host instead.
+func destroyVM(v *VM, running bool) error {
+	provider, err := vm.ProviderFor(v.Name)
+	if err != nil {
+		return err
+	}
+
+	clusters, err := models.ListCluster()
+	if err != nil {
+		return fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	names := []string{}
+	for _, c := range clusters {
+		if c.VM == v.Name {
+			names = append(names, c.Name)
+		}
+	}
+
+	if provider.Name() == vm.HostProvider {
+		if err := vm.EnsureDockerContext(v.Name); err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			logrus.Infof("Deleting cluster %s from VM %s", name, v.Name)
+			if err := kind.Delete(name); err != nil {
+				return fmt.Errorf("failed to delete cluster %s: %w", name, err)
+			}
+		}
+
+		logrus.Infof("VM %s uses the docker daemon of the host - keeping it", v.Name)
+		return nil
+	}
+
+	if running {
+		if err := vm.Stop(v.Name); err != nil {
+			return fmt.Errorf("failed to stop VM %s: %w", v.Name, err)
+		}
+	}
+
+	logrus.Infof("Deleting VM %s", v.Name)
+	if err := vm.Delete(v.Name); err != nil {
+		return fmt.Errorf("failed to delete VM %s: %w", v.Name, err)
+	}
+
+	for _, name := range names {
+		if err := kind.Forget(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,279 @@
+package topology
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/addon"
+	"github.com/utkarsh-pro/kindli/pkg/metallb"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
+)
+
+const (
+	ResourceVM      = "vm"
+	ResourceCluster = "cluster"
+
+	ActionCreate = "create"
+	ActionStart  = "start"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionNone   = "none"
+)
+
+// Action is a step needed to converge the kindli store to the topology
+type Action struct {
+	Resource string   `json:"resource" yaml:"resource"`
+	Name     string   `json:"name" yaml:"name"`
+	VM       string   `json:"vm" yaml:"vm"`
+	Action   string   `json:"action" yaml:"action"`
+	Details  []string `json:"details" yaml:"details"`
+
+	vm      *VM
+	cluster *Cluster
+	// start is true if the nodes of an existing cluster have to be started
+	start bool
+	// metallb is either ActionCreate or ActionUpdate if metallb has to be
+	// installed or upgraded in an existing cluster
+	metallb string
+	enable  []string
+	disable []string
+}
+
+// Plan is the list of actions, in the order they are executed
+type Plan []Action
+
+func (p Plan) Header(wide bool) []string {
+	return []string{"RESOURCE", "NAME", "VM", "ACTION", "DETAILS"}
+}
+
+func (p Plan) Rows(wide bool) [][]string {
+	rows := [][]string{}
+	for _, a := range p {
+		rows = append(rows, []string{a.Resource, a.Name, a.VM, a.Action, strings.Join(a.Details, "; ")})
+	}
+
+	return rows
+}
+
+// Changes returns true if the plan changes anything
+func (p Plan) Changes() bool {
+	for _, a := range p {
+		if a.Action != ActionNone {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Compute diffs the topology against the kindli store and returns the
+// actions needed to converge to the topology
+//
+// If prune is true clusters of the VMs of the topology which are not part
+// of the topology are deleted.
+func Compute(t *Topology, prune bool) (Plan, error) {
+	stored, err := models.ListCluster()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	storedByName := map[string]models.Cluster{}
+	for _, c := range stored {
+		storedByName[c.Name] = c
+	}
+
+	plan := Plan{}
+	for i := range t.VMs {
+		v := &t.VMs[i]
+
+		exists, err := models.NewVM(v.Name, "", 0).Exists()
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if VM %s exists: %w", v.Name, err)
+		}
+
+		if !exists {
+			spec := v.spec()
+			plan = append(plan, Action{
+				Resource: ResourceVM,
+				Name:     v.Name,
+				Action:   ActionCreate,
+				Details:  []string{fmt.Sprintf("cpu=%d mem=%s disk=%s", spec.CPU, spec.Memory, spec.Disk)},
+				vm:       v,
+			})
+
+			for j := range v.Clusters {
+				plan = append(plan, createAction(v, &v.Clusters[j]))
+			}
+
+			continue
+		}
+
+		running, err := vm.Running(v.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if VM %s is running: %w", v.Name, err)
+		}
+
+		action := Action{Resource: ResourceVM, Name: v.Name, Action: ActionNone, vm: v}
+		if !running {
+			action.Action = ActionStart
+		}
+		plan = append(plan, action)
+
+		wanted := map[string]bool{}
+		for j := range v.Clusters {
+			wanted[v.Clusters[j].ClusterName(v.Name)] = true
+		}
+
+		if prune {
+			for _, c := range stored {
+				if c.VM != v.Name || wanted[c.Name] {
+					continue
+				}
+
+				plan = append(plan, Action{
+					Resource: ResourceCluster,
+					Name:     c.Name,
+					VM:       v.Name,
+					Action:   ActionDelete,
+					Details:  []string{"not part of the topology"},
+					vm:       v,
+				})
+			}
+		}
+
+		for j := range v.Clusters {
+			c := &v.Clusters[j]
+
+			sc, ok := storedByName[c.ClusterName(v.Name)]
+			if !ok {
+				plan = append(plan, createAction(v, c))
+				continue
+			}
+
+			if sc.VM != v.Name {
+				return nil, fmt.Errorf("cluster %s already exists in VM %s", sc.Name, sc.VM)
+			}
+
+			action, err := updateAction(v, c, &sc)
+			if err != nil {
+				return nil, err
+			}
+
+			plan = append(plan, action)
+		}
+	}
+
+	return plan, nil
+}
+
+func createAction(v *VM, c *Cluster) Action {
+	details := []string{}
+	if !c.SkipMetalLB {
+		version := c.MetalLBVersion
+		if version == "" {
+			version = metallb.DefaultVersion()
+		}
+
+		details = append(details, "metallb "+version)
+	}
+
+	if len(c.Addons) > 0 {
+		details = append(details, "addons "+strings.Join(c.Addons, ","))
+	}
+
+	return Action{
+		Resource: ResourceCluster,
+		Name:     c.ClusterName(v.Name),
+		VM:       v.Name,
+		Action:   ActionCreate,
+		Details:  details,
+		vm:       v,
+		cluster:  c,
+	}
+}
+
+// updateAction diffs the metallb version and the add-ons of the existing
+// cluster against the topology, the kind config of an existing cluster is
+// never changed
+func updateAction(v *VM, c *Cluster, sc *models.Cluster) (Action, error) {
+	action := Action{
+		Resource: ResourceCluster,
+		Name:     sc.Name,
+		VM:       v.Name,
+		Action:   ActionNone,
+		vm:       v,
+		cluster:  c,
+	}
+
+	if sc.Status != models.ClusterRunning {
+		action.start = true
+		action.Details = append(action.Details, "start")
+	}
+
+	switch {
+	case sc.MetalLBVersion == "" && !c.SkipMetalLB:
+		action.metallb = ActionCreate
+		action.Details = append(action.Details, "install metallb")
+	case sc.MetalLBVersion != "" && c.SkipMetalLB:
+		logrus.Warnf("cluster %s has metallb %s installed which is not removed", sc.Name, sc.MetalLBVersion)
+	case sc.MetalLBVersion != "" && c.MetalLBVersion != "" && sc.MetalLBVersion != c.MetalLBVersion:
+		if metallb.CompareVersions(c.MetalLBVersion, sc.MetalLBVersion) < 0 {
+			return action, fmt.Errorf("cluster %s: metallb cannot be downgraded from %s to %s", sc.Name, sc.MetalLBVersion, c.MetalLBVersion)
+		}
+
+		action.metallb = ActionUpdate
+		action.Details = append(action.Details, fmt.Sprintf("upgrade metallb %s -> %s", sc.MetalLBVersion, c.MetalLBVersion))
+	}
+
+	enabled, err := addon.Enabled(sc.Name)
+	if err != nil {
+		return action, err
+	}
+
+	isEnabled := map[string]bool{}
+	for _, ca := range enabled {
+		isEnabled[ca.Addon] = true
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range c.Addons {
+		wanted[name] = true
+		if isEnabled[name] {
+			continue
+		}
+
+		if a, _ := addon.Get(name); a != nil && a.CNI {
+			return action, fmt.Errorf("cluster %s: CNI addon %s can only be enabled when the cluster is created", sc.Name, name)
+		}
+
+		action.enable = append(action.enable, name)
+	}
+
+	for _, ca := range enabled {
+		if wanted[ca.Addon] {
+			continue
+		}
+
+		if a, _ := addon.Get(ca.Addon); a != nil && a.CNI {
+			return action, fmt.Errorf("cluster %s: CNI addon %s cannot be disabled", sc.Name, ca.Addon)
+		}
+
+		action.disable = append(action.disable, ca.Addon)
+	}
+
+	if len(action.enable) > 0 {
+		action.Details = append(action.Details, "enable "+strings.Join(action.enable, ","))
+	}
+
+	if len(action.disable) > 0 {
+		action.Details = append(action.Details, "disable "+strings.Join(action.disable, ","))
+	}
+
+	if len(action.Details) > 0 {
+		action.Action = ActionUpdate
+	}
+
+	return action, nil
+}
@@ -0,0 +1,285 @@
+package topology
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/utkarsh-pro/kindli/pkg/db"
+	"github.com/utkarsh-pro/kindli/pkg/metallb"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "kindli-topology-test-*")
+	if err != nil {
+		panic(err)
+	}
+
+	models.RegisterMigrations()
+	db.Setup(filepath.Join(dir, "db.sqlite"))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// storedCluster is a cluster in the kindli store
+type storedCluster struct {
+	name    string
+	vm      string
+	status  string
+	metallb string
+	addons  []string
+}
+
+// saveVM saves the lima VM "test" with the given status, an empty status
+// means the VM does not exist
+func saveVM(t *testing.T, status string) {
+	t.Helper()
+
+	rec := sh.Record(t)
+	if status == "" {
+		return
+	}
+
+	rec.On("limactl ls", sh.Response{Output: []byte("other=Running\ntest=" + status + "\n")})
+
+	v := models.NewVM("test", "", 0)
+	if err := v.Save(); err != nil {
+		t.Fatalf("failed to save VM: %v", err)
+	}
+	t.Cleanup(func() { v.Delete() })
+}
+
+func saveClusters(t *testing.T, clusters []storedCluster) {
+	t.Helper()
+
+	for _, sc := range clusters {
+		c := models.NewCluster(sc.name, filepath.Join(t.TempDir(), sc.name+".yaml"), sc.vm)
+		c.Status = sc.status
+		c.MetalLBVersion = sc.metallb
+		if err := c.Save(); err != nil {
+			t.Fatalf("failed to save cluster: %v", err)
+		}
+
+		for _, name := range sc.addons {
+			if err := models.NewClusterAddon(sc.name, name, "v1").Save(); err != nil {
+				t.Fatalf("failed to save addon: %v", err)
+			}
+		}
+
+		t.Cleanup(func() {
+			models.DeleteClusterAddons(c.Name)
+			c.Delete()
+		})
+	}
+}
+
+// summary renders the actions as "<resource> <name> <action>: <details>"
+func summary(plan Plan) []string {
+	got := []string{}
+	for _, a := range plan {
+		got = append(got, fmt.Sprintf("%s %s %s: %s", a.Resource, a.Name, a.Action, strings.Join(a.Details, "; ")))
+	}
+
+	return got
+}
+
+func TestCompute(t *testing.T) {
+	defaultMetalLB := "metallb " + metallb.DefaultVersion()
+
+	tests := []struct {
+		name     string
+		vmStatus string
+		stored   []storedCluster
+		clusters []Cluster
+		prune    bool
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name: "new VM creates the VM and its clusters",
+			clusters: []Cluster{
+				{Name: "a"},
+				{Name: "b", MetalLBVersion: "v0.13.12", Addons: []string{"ingress-nginx", "calico"}},
+				{Name: "c", SkipMetalLB: true},
+			},
+			want: []string{
+				"vm test create: cpu=4 mem=16GiB disk=100GiB",
+				"cluster test-a create: " + defaultMetalLB,
+				"cluster test-b create: metallb v0.13.12; addons ingress-nginx,calico",
+				"cluster test-c create: ",
+			},
+		},
+		{
+			name:     "stopped VM and cluster are started",
+			vmStatus: "Stopped",
+			stored: []storedCluster{
+				{name: "test-a", vm: "test", status: models.ClusterStopped, metallb: "v0.14.9"},
+			},
+			clusters: []Cluster{{Name: "a"}},
+			want: []string{
+				"vm test start: ",
+				"cluster test-a update: start",
+			},
+		},
+		{
+			name:     "missing cluster of existing VM is created",
+			vmStatus: "Running",
+			clusters: []Cluster{{Name: "a", SkipMetalLB: true}},
+			want: []string{
+				"vm test none: ",
+				"cluster test-a create: ",
+			},
+		},
+		{
+			name:     "metallb is installed and add-ons are enabled and disabled",
+			vmStatus: "Running",
+			stored: []storedCluster{
+				{name: "test-a", vm: "test", status: models.ClusterRunning, addons: []string{"calico", "metrics-server"}},
+			},
+			clusters: []Cluster{{Name: "a", Addons: []string{"calico", "ingress-nginx", "cert-manager"}}},
+			want: []string{
+				"vm test none: ",
+				"cluster test-a update: install metallb; enable ingress-nginx,cert-manager; disable metrics-server",
+			},
+		},
+		{
+			name:     "metallb is upgraded",
+			vmStatus: "Running",
+			stored: []storedCluster{
+				{name: "test-a", vm: "test", status: models.ClusterRunning, metallb: "v0.13.12"},
+			},
+			clusters: []Cluster{{Name: "a", MetalLBVersion: "v0.14.9"}},
+			want: []string{
+				"vm test none: ",
+				"cluster test-a update: upgrade metallb v0.13.12 -> v0.14.9",
+			},
+		},
+		{
+			name:     "clusters not in the topology are deleted when pruning",
+			vmStatus: "Running",
+			stored: []storedCluster{
+				{name: "test-a", vm: "test", status: models.ClusterRunning, metallb: "v0.14.9"},
+				{name: "test-b", vm: "test", status: models.ClusterRunning},
+				{name: "other-b", vm: "other", status: models.ClusterRunning},
+			},
+			clusters: []Cluster{{Name: "a"}},
+			prune:    true,
+			want: []string{
+				"vm test none: ",
+				"cluster test-b delete: not part of the topology",
+				"cluster test-a none: ",
+			},
+		},
+		{
+			name:     "clusters not in the topology are kept without pruning",
+			vmStatus: "Running",
+			stored: []storedCluster{
+				{name: "test-a", vm: "test", status: models.ClusterRunning, metallb: "v0.14.9"},
+				{name: "test-b", vm: "test", status: models.ClusterRunning},
+			},
+			clusters: []Cluster{{Name: "a"}},
+			want: []string{
+				"vm test none: ",
+				"cluster test-a none: ",
+			},
+		},
+		{
+			name:     "converged topology is a no-op",
+			vmStatus: "Running",
+			stored: []storedCluster{
+				{name: "test-a", vm: "test", status: models.ClusterRunning, metallb: "v0.13.12", addons: []string{"ingress-nginx"}},
+				{name: "test-b", vm: "test", status: models.ClusterRunning},
+			},
+			clusters: []Cluster{
+				{Name: "a", Addons: []string{"ingress-nginx"}},
+				{Name: "b", SkipMetalLB: true},
+			},
+			prune: true,
+			want: []string{
+				"vm test none: ",
+				"cluster test-a none: ",
+				"cluster test-b none: ",
+			},
+		},
+		{
+			name:     "metallb is not downgraded",
+			vmStatus: "Running",
+			stored: []storedCluster{
+				{name: "test-a", vm: "test", status: models.ClusterRunning, metallb: "v0.14.9"},
+			},
+			clusters: []Cluster{{Name: "a", MetalLBVersion: "v0.13.12"}},
+			wantErr:  true,
+		},
+		{
+			name:     "CNI add-on is not enabled in an existing cluster",
+			vmStatus: "Running",
+			stored: []storedCluster{
+				{name: "test-a", vm: "test", status: models.ClusterRunning, metallb: "v0.14.9"},
+			},
+			clusters: []Cluster{{Name: "a", Addons: []string{"cilium"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "CNI add-on is not disabled",
+			vmStatus: "Running",
+			stored: []storedCluster{
+				{name: "test-a", vm: "test", status: models.ClusterRunning, metallb: "v0.14.9", addons: []string{"calico"}},
+			},
+			clusters: []Cluster{{Name: "a"}},
+			wantErr:  true,
+		},
+		{
+			name:     "cluster of another VM",
+			vmStatus: "Running",
+			stored: []storedCluster{
+				{name: "test-a", vm: "other", status: models.ClusterRunning},
+			},
+			clusters: []Cluster{{Name: "a"}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saveVM(t, tt.vmStatus)
+			saveClusters(t, tt.stored)
+
+			topology := &Topology{
+				Kind:       Kind,
+				APIVersion: APIVersion,
+				VMs:        []VM{{Name: "test", Clusters: tt.clusters}},
+			}
+
+			plan, err := Compute(topology, tt.prune)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Compute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got := summary(plan); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compute() = %q, want %q", got, tt.want)
+			}
+
+			wantChanges := false
+			for _, a := range tt.want {
+				if !strings.Contains(a, " none: ") {
+					wantChanges = true
+				}
+			}
+
+			if plan.Changes() != wantChanges {
+				t.Errorf("Changes() = %v, want %v", plan.Changes(), wantChanges)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package topology
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/utkarsh-pro/kindli/pkg/addon"
+	"github.com/utkarsh-pro/kindli/pkg/metallb"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+	"github.com/utkarsh-pro/kindli/pkg/vm"
+	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+const (
+	// Kind is the kind of the topology file
+	Kind = "Topology"
+	// APIVersion is the supported apiVersion of the topology file
+	APIVersion = "kindli/v1alpha1"
+)
+
+// Topology describes the VMs and the clusters running in them
+type Topology struct {
+	Kind       string `yaml:"kind"`
+	APIVersion string `yaml:"apiVersion"`
+	VMs        []VM   `yaml:"vms"`
+
+	// dir is the directory of the topology file, relative paths of the
+	// kind configs are resolved against it
+	dir string
+}
+
+// VM describes a VM of the topology
+//
+// The resources of a VM are only used when the VM is created, they are
+// not changed for existing VMs.
+type VM struct {
+	Name     string    `yaml:"name"`
+	Provider string    `yaml:"provider,omitempty"`
+	CPU      int       `yaml:"cpu,omitempty"`
+	Memory   string    `yaml:"mem,omitempty"`
+	Disk     string    `yaml:"disk,omitempty"`
+	Arch     string    `yaml:"arch,omitempty"`
+	Mounts   []string  `yaml:"mounts,omitempty"`
+	FIPS     bool      `yaml:"fips,omitempty"`
+	Clusters []Cluster `yaml:"clusters,omitempty"`
+}
+
+// Cluster describes a kind cluster of a VM
+type Cluster struct {
+	Name string `yaml:"name"`
+	// Config is the path to the kind config of the cluster, relative paths
+	// are relative to the topology file
+	Config string `yaml:"config,omitempty"`
+	// KindConfig is the kind config of the cluster given inline
+	KindConfig     *v1alpha4.Cluster `yaml:"kindConfig,omitempty"`
+	SkipMetalLB    bool              `yaml:"skipMetalLB,omitempty"`
+	MetalLBVersion string            `yaml:"metallbVersion,omitempty"`
+	Addons         []string          `yaml:"addons,omitempty"`
+}
+
+// Load reads and validates the topology file
+func Load(path string) (*Topology, error) {
+	byt, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read topology: %w", err)
+	}
+
+	t := &Topology{}
+	if err := yaml.UnmarshalStrict(byt, t); err != nil {
+		return nil, fmt.Errorf("failed to parse topology %s: %w", path, err)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	t.dir = filepath.Dir(abs)
+
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("invalid topology %s: %w", path, err)
+	}
+
+	return t, nil
+}
+
+// ClusterName returns the kindli name of the cluster of the VM
+func (c *Cluster) ClusterName(vmName string) string {
+	return utils.CreateClusterName(c.Name, vmName)
+}
+
+// spec returns the spec of the VM with the defaults filled in
+func (v *VM) spec() vm.Spec {
+	spec := vm.Spec{
+		CPU:    v.CPU,
+		Memory: v.Memory,
+		Disk:   v.Disk,
+		Arch:   v.Arch,
+		Mounts: v.Mounts,
+		FIPS:   v.FIPS,
+	}
+
+	if spec.CPU == 0 {
+		spec.CPU = vm.DefaultCPU
+	}
+
+	if spec.Memory == "" {
+		spec.Memory = vm.DefaultMemory
+	}
+
+	if spec.Disk == "" {
+		spec.Disk = vm.DefaultDisk
+	}
+
+	return spec
+}
+
+// configPath returns the path to the kind config of the cluster
+func (t *Topology) configPath(c *Cluster) string {
+	if c.Config == "" || filepath.IsAbs(c.Config) {
+		return c.Config
+	}
+
+	return filepath.Join(t.dir, c.Config)
+}
+
+func (t *Topology) validate() error {
+	if t.Kind != Kind {
+		return fmt.Errorf("unsupported kind \"%s\", expected \"%s\"", t.Kind, Kind)
+	}
+
+	if t.APIVersion != APIVersion {
+		return fmt.Errorf("unsupported apiVersion \"%s\", expected \"%s\"", t.APIVersion, APIVersion)
+	}
+
+	vms := map[string]bool{}
+	for i := range t.VMs {
+		v := &t.VMs[i]
+		if v.Name == "" {
+			return fmt.Errorf("vms[%d]: name is required", i)
+		}
+
+		if vms[v.Name] {
+			return fmt.Errorf("vm %s: defined more than once", v.Name)
+		}
+		vms[v.Name] = true
+
+		if v.Provider != "" {
+			if _, err := vm.GetProvider(v.Provider); err != nil {
+				return fmt.Errorf("vm %s: %w", v.Name, err)
+			}
+		}
+
+		if err := v.spec().Validate(); err != nil {
+			return fmt.Errorf("vm %s: %w", v.Name, err)
+		}
+
+		clusters := map[string]bool{}
+		for j := range v.Clusters {
+			c := &v.Clusters[j]
+			if c.Name == "" {
+				return fmt.Errorf("vm %s: clusters[%d]: name is required", v.Name, j)
+			}
+
+			if clusters[c.Name] {
+				return fmt.Errorf("vm %s: cluster %s: defined more than once", v.Name, c.Name)
+			}
+			clusters[c.Name] = true
+
+			if err := t.validateCluster(c); err != nil {
+				return fmt.Errorf("vm %s: cluster %s: %w", v.Name, c.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *Topology) validateCluster(c *Cluster) error {
+	if c.Config != "" && c.KindConfig != nil {
+		return fmt.Errorf("only one of config and kindConfig can be set")
+	}
+
+	if c.Config != "" {
+		if _, err := os.Stat(t.configPath(c)); err != nil {
+			return fmt.Errorf("kind config: %w", err)
+		}
+	}
+
+	if c.MetalLBVersion != "" {
+		if c.SkipMetalLB {
+			return fmt.Errorf("metallbVersion cannot be set along with skipMetalLB")
+		}
+
+		if err := metallb.ValidateVersion(c.MetalLBVersion); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range c.Addons {
+		if _, err := addon.Get(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -2,11 +2,8 @@ package utils
 
 import (
 	"fmt"
-	"os"
-	"os/signal"
 	"runtime/debug"
 	"strconv"
-	"syscall"
 
 	"github.com/sirupsen/logrus"
 	"github.com/utkarsh-pro/kindli/pkg/sh"
@@ -247,18 +244,9 @@ func ConvertMapInterfaceMapString(v interface{}) interface{} {
 	return v
 }
 
-// SigIntHandler takes a handler which will be called when SIGINT is received
-func SigIntHandler(handler func()) {
-	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
-	<-ch
-
-	handler()
-}
-
 // ForceLink forcefully hard links 2 files together
 func ForceLink(file1, file2 string) error {
-	if err := sh.Run(fmt.Sprintf("ln -f %s %s", file1, file2)); err != nil {
+	if err := sh.NewCommand("ln", "-f", file1, file2).Run(); err != nil {
 		return fmt.Errorf("failed to link %s to %s: %v", file1, file2, err)
 	}
 
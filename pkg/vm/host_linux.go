@@ -0,0 +1,116 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+// hostProvider runs the kind clusters directly on the docker daemon of the
+// host, no VM is created at all
+//
+// The docker networks are directly reachable from a Linux host hence no
+// routing is required either.
+type hostProvider struct{}
+
+func (hp *hostProvider) Name() string {
+	return HostProvider
+}
+
+func (hp *hostProvider) Start(overrides map[string]interface{}, skipIfExists bool, vmName string) error {
+	if err := sh.NewCommand("docker", "--context", "default", "info").Query().RunSilent(); err != nil {
+		return fmt.Errorf("docker daemon on the host is not reachable: %w", err)
+	}
+
+	vm := models.NewVM(vmName, "", 0)
+	exists, err := vm.Exists()
+	if err != nil {
+		return fmt.Errorf("failed to check if VM exists: %w", err)
+	}
+
+	if exists {
+		if skipIfExists {
+			return nil
+		}
+
+		return fmt.Errorf("vm is already running")
+	}
+
+	if overrides != nil && overrides["FIPS"] == true {
+		logrus.Warn("host provider cannot enable FIPS - FIPS mode of the host is used")
+	}
+
+	port, err := nextDockerPort()
+	if err != nil {
+		return err
+	}
+
+	// Config path has to be unique, host VMs don't have any config though
+	vm.LimaConfigPath = "host://" + vmName
+	vm.DockerPort = port
+	vm.Provider = hp.Name()
+	if err := vm.Save(); err != nil {
+		return fmt.Errorf("failed to save vm instance: %w", err)
+	}
+
+	logrus.Infof("VM %s will use the docker daemon of the host", vmName)
+	return nil
+}
+
+func (hp *hostProvider) Stop(vmName string) error {
+	return errors.New("host provider VMs cannot be stopped - stop the docker daemon of the host instead")
+}
+
+func (hp *hostProvider) Delete(vmName string) error {
+	vm := models.NewVM(vmName, "", 0)
+	if err := vm.GetByName(); err != nil {
+		return fmt.Errorf("failed to get VM by name: %w", err)
+	}
+
+	return vm.Delete()
+}
+
+func (hp *hostProvider) Restart(vmName string) error {
+	return nil
+}
+
+func (hp *hostProvider) Shell(vmName string, args ...string) error {
+	if len(args) == 0 {
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+
+		args = []string{shell}
+	}
+
+	return sh.NewCommand(args[0], args[1:]...).Run()
+}
+
+func (hp *hostProvider) Exec(vmName string, args ...string) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, errors.New("no command given")
+	}
+
+	return sh.NewCommand(args[0], args[1:]...).Output()
+}
+
+func (hp *hostProvider) Running(vmName string) (bool, error) {
+	return sh.NewCommand("docker", "--context", "default", "info").Query().RunSilent() == nil, nil
+}
+
+func (hp *hostProvider) Exists(vmName string) (bool, error) {
+	return models.NewVM(vmName, "", 0).Exists()
+}
+
+func (hp *hostProvider) DockerHost(vmName string) string {
+	return "unix:///var/run/docker.sock"
+}
+
+func (hp *hostProvider) Routed() bool {
+	return false
+}
@@ -0,0 +1,121 @@
+package vm
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/utkarsh-pro/kindli/pkg/models"
+)
+
+const (
+	// StatusRunning means that the VM is running
+	StatusRunning = "Running"
+	// StatusStopped means that the VM exists but is not running
+	StatusStopped = "Stopped"
+	// StatusMissing means that the VM is in the kindli store but does not
+	// exist in its provider
+	StatusMissing = "Missing"
+	// StatusUnknown means that the status of the VM couldn't be determined
+	StatusUnknown = "Unknown"
+)
+
+// VMInfo is the information of a kindli VM
+type VMInfo struct {
+	Name       string `json:"name" yaml:"name"`
+	Provider   string `json:"provider" yaml:"provider"`
+	Status     string `json:"status" yaml:"status"`
+	IPv4       string `json:"ipv4" yaml:"ipv4"`
+	DockerPort int    `json:"dockerPort" yaml:"dockerPort"`
+	DockerHost string `json:"dockerHost" yaml:"dockerHost"`
+	ConfigPath string `json:"configPath" yaml:"configPath"`
+}
+
+// VMInfoList is a list of VMInfo
+type VMInfoList []VMInfo
+
+func (l VMInfoList) Header(wide bool) []string {
+	header := []string{"NAME", "STATUS", "PROVIDER", "IPv4"}
+	if wide {
+		header = append(header, "DOCKER PORT", "DOCKER HOST", "CONFIG")
+	}
+
+	return header
+}
+
+func (l VMInfoList) Rows(wide bool) [][]string {
+	rows := [][]string{}
+	for _, v := range l {
+		row := []string{v.Name, v.Status, v.Provider, v.IPv4}
+		if wide {
+			row = append(row, strconv.Itoa(v.DockerPort), v.DockerHost, v.ConfigPath)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// Status returns the information of the given VM, if vmName is empty then
+// the information of all the VMs is returned
+func Status(vmName string) (VMInfoList, error) {
+	vms, err := models.ListVM()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VMs: %w", err)
+	}
+
+	infos := VMInfoList{}
+	for _, v := range vms {
+		if v.Name != vmName && vmName != "" {
+			continue
+		}
+
+		infos = append(infos, newVMInfo(v))
+	}
+
+	if vmName != "" && len(infos) == 0 {
+		return nil, fmt.Errorf("VM \"%s\" does not exist", vmName)
+	}
+
+	return infos, nil
+}
+
+func newVMInfo(v *models.VM) VMInfo {
+	info := VMInfo{
+		Name:       v.Name,
+		Provider:   v.Provider,
+		Status:     StatusUnknown,
+		IPv4:       v.GetVMIPv4(),
+		DockerPort: v.DockerPort,
+		ConfigPath: v.LimaConfigPath,
+	}
+
+	provider, err := GetProvider(v.Provider)
+	if err != nil {
+		return info
+	}
+
+	info.DockerHost = provider.DockerHost(v.Name)
+
+	exists, err := provider.Exists(v.Name)
+	if err != nil {
+		return info
+	}
+
+	if !exists {
+		info.Status = StatusMissing
+		return info
+	}
+
+	running, err := provider.Running(v.Name)
+	if err != nil {
+		return info
+	}
+
+	info.Status = StatusStopped
+	if running {
+		info.Status = StatusRunning
+	}
+
+	return info
+}
@@ -0,0 +1,255 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+	"github.com/utkarsh-pro/kindli/pkg/utils"
+)
+
+// limaProvider manages VMs using lima
+//
+// Lima runs on both macOS and Linux hosts, the platform specific
+// bits are injected via prepareHost.
+type limaProvider struct {
+	// prepareHost is invoked before the VM is started, it can be used
+	// to setup the host and to add platform specific template overrides.
+	//
+	// overrides will be nil if an existing VM is being started.
+	prepareHost func(overrides map[string]interface{}) error
+}
+
+func limaSourcePath(vmName string) string {
+	return filepath.Join(config.Home(), ".lima", vmName)
+}
+
+func (lp *limaProvider) Name() string {
+	return "lima"
+}
+
+func (lp *limaProvider) Start(overrides map[string]interface{}, skipIfExists bool, vmName string) error {
+	logrus.Infoln("Starting VM:", vmName)
+
+	exists, err := lp.Exists(vmName)
+	if err != nil {
+		return fmt.Errorf("failed to check if VM exists: %w", err)
+	}
+
+	isRunning, err := lp.Running(vmName)
+	if err != nil {
+		return fmt.Errorf("failed to check if VM is running: %w", err)
+	}
+
+	if isRunning {
+		if skipIfExists {
+			return nil
+		}
+
+		return fmt.Errorf("vm is already running")
+	}
+
+	if exists || overrides == nil {
+		if err := lp.prepare(nil); err != nil {
+			return err
+		}
+
+		err := sh.NewCommand("limactl", "start", "--tty=false", vmName).Run()
+		if err != nil {
+			return fmt.Errorf("failed to start VM: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := lp.prepare(overrides); err != nil {
+		return err
+	}
+
+	// Create a new VM
+	port, err := nextDockerPort()
+	if err != nil {
+		return err
+	}
+
+	vm := models.NewVM(vmName, vmFilePath(vmName), port)
+	vm.Provider = lp.Name()
+
+	if err := renderVMConfig(vmTemplate, overrides, vm); err != nil {
+		return fmt.Errorf("failed to create lima VM config: %w", err)
+	}
+	if err := vm.Save(); err != nil {
+		return fmt.Errorf("failed to save vm instance: %w", err)
+	}
+
+	if err := sh.NewCommand("limactl", "start", "--tty=false", vm.LimaConfigPath).Run(); err != nil {
+		return fmt.Errorf("failed to start VM: %w", err)
+	}
+
+	// Link the configs
+	if err := utils.ForceLink(filepath.Join(limaSourcePath(vm.Name), "lima.yaml"), vm.LimaConfigPath); err != nil {
+		logrus.Warn("failed to link lima config files")
+		logrus.Debug("failed to link error: ", err)
+	}
+
+	return nil
+}
+
+func (lp *limaProvider) Stop(vmName string) error {
+	isRunning, err := lp.Running(vmName)
+	if err != nil {
+		return err
+	}
+
+	if !isRunning {
+		return errors.New("VM is not in running state")
+	}
+
+	return sh.NewCommand("limactl", "stop", vmName).Run()
+}
+
+func (lp *limaProvider) Delete(vmName string) error {
+	exist, err := lp.Exists(vmName)
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		return errors.New("VM does not exists")
+	}
+
+	vm := models.NewVM(vmName, "", 0)
+	if err := vm.GetByName(); err != nil {
+		return fmt.Errorf("failed to get VM by name: %w", err)
+	}
+
+	if err := sh.NewCommand("limactl", "delete", vm.Name).Run(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(vm.LimaConfigPath); err != nil {
+		return err
+	}
+
+	return vm.Delete()
+}
+
+func (lp *limaProvider) Restart(vmName string) error {
+	if err := lp.Stop(vmName); err != nil {
+		return err
+	}
+
+	return lp.Start(nil, true, vmName)
+}
+
+func (lp *limaProvider) Shell(vmName string, args ...string) error {
+	if len(args) == 0 {
+		return sh.NewCommand("limactl", "shell", vmName).Run()
+	}
+
+	return sh.NewCommand("limactl", append([]string{"shell", vmName, "--"}, args...)...).Run()
+}
+
+func (lp *limaProvider) Exec(vmName string, args ...string) ([]byte, error) {
+	return sh.NewCommand("limactl", append([]string{"shell", vmName, "--"}, args...)...).Output()
+}
+
+func (lp *limaProvider) DockerHost(vmName string) string {
+	return fmt.Sprintf("unix://%s", filepath.Join(config.Dir(), vmName+".sock"))
+}
+
+func (lp *limaProvider) Routed() bool {
+	return true
+}
+
+func (lp *limaProvider) Running(vmName string) (bool, error) {
+	out, err := sh.NewCommand("limactl", "ls", "--format={{ .Name }}={{ .Status }}").Query().Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to get VM status: %s", err)
+	}
+
+	vms := strings.Split(string(out), "\n")
+	for _, vm := range vms {
+		prefix := fmt.Sprintf("%s=", vmName)
+
+		if strings.HasPrefix(vm, prefix) {
+			status := strings.TrimPrefix(vm, prefix)
+
+			if status != "Stopped" {
+				return true, nil
+			}
+
+			return false, nil
+		}
+	}
+
+	return false, nil
+}
+
+// List returns the lima VMs created by kindli, these are the VMs whose
+// config exists in kindli's config directory
+func (lp *limaProvider) List() ([]string, error) {
+	out, err := sh.NewCommand("limactl", "ls", "--format={{ .Name }}").Query().Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VMs: %s", err)
+	}
+
+	vms := []string{}
+	for _, name := range strings.Split(string(out), "\n") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if _, err := os.Stat(vmFilePath(name)); err == nil {
+			vms = append(vms, name)
+		}
+	}
+
+	return vms, nil
+}
+
+func (lp *limaProvider) Adopt(vmName string) error {
+	ip, err := findIPv4(vmFilePath(vmName))
+	if err != nil {
+		return fmt.Errorf("failed to find IP of VM %s: %w", vmName, err)
+	}
+
+	return adopt(lp.Name(), vmName, vmFilePath(vmName), ip)
+}
+
+func (lp *limaProvider) prepare(overrides map[string]interface{}) error {
+	if lp.prepareHost == nil {
+		return nil
+	}
+
+	if err := lp.prepareHost(overrides); err != nil {
+		return fmt.Errorf("failed to prepare host for the VM: %w", err)
+	}
+
+	return nil
+}
+
+func (lp *limaProvider) Exists(vmName string) (bool, error) {
+	out, err := sh.NewCommand("limactl", "ls", "--format={{ .Name }}={{ .Status }}").Query().Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to get VM status: %s", err)
+	}
+
+	vms := strings.Split(string(out), "\n")
+	for _, vm := range vms {
+		prefix := fmt.Sprintf("%s=", vmName)
+
+		if strings.HasPrefix(vm, prefix) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,116 @@
+package vm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+const limaList = "limactl ls '--format={{ .Name }}={{ .Status }}'"
+
+func TestLimaProviderStop(t *testing.T) {
+	tests := []struct {
+		name    string
+		ls      string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "running VM is stopped",
+			ls:   "other=Running\nkindli=Running\n",
+			want: []string{limaList, "limactl stop kindli"},
+		},
+		{
+			name:    "stopped VM is an error",
+			ls:      "kindli=Stopped\n",
+			want:    []string{limaList},
+			wantErr: true,
+		},
+		{
+			name:    "missing VM is an error",
+			ls:      "other=Running\n",
+			want:    []string{limaList},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := sh.Record(t)
+			rec.On("limactl ls", sh.Response{Output: []byte(tt.ls)})
+
+			err := (&limaProvider{}).Stop("kindli")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Stop() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := rec.Commands(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commands = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimaProviderRunning(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    sh.Response
+		want    bool
+		wantErr bool
+	}{
+		{name: "running", resp: sh.Response{Output: []byte("kindli=Running\n")}, want: true},
+		{name: "stopped", resp: sh.Response{Output: []byte("kindli=Stopped\n")}, want: false},
+		{name: "prefix of another VM", resp: sh.Response{Output: []byte("kindli2=Running\n")}, want: false},
+		{name: "limactl fails", resp: sh.Response{Err: errors.New("exit status 1")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := sh.Record(t)
+			rec.On("limactl ls", tt.resp)
+
+			got, err := (&limaProvider{}).Running("kindli")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Running() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Running() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimaProviderShellAndExec(t *testing.T) {
+	rec := sh.Record(t)
+	rec.On("limactl shell kindli -- sh", sh.Response{Output: []byte("lima0\n")})
+
+	lp := &limaProvider{}
+	if err := lp.Shell("kindli"); err != nil {
+		t.Fatalf("Shell() error = %v", err)
+	}
+
+	if err := lp.Shell("kindli", "uname", "-a"); err != nil {
+		t.Fatalf("Shell() error = %v", err)
+	}
+
+	out, err := lp.Exec("kindli", "sh", "-c", "ip -o link show")
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+
+	if string(out) != "lima0\n" {
+		t.Errorf("Exec() = %q, want %q", out, "lima0\n")
+	}
+
+	want := []string{
+		"limactl shell kindli",
+		"limactl shell kindli -- uname -a",
+		"limactl shell kindli -- sh -c 'ip -o link show'",
+	}
+	if got := rec.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,112 @@
+package vm
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/ipam"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+// kindNetwork is the docker network of the kind nodes
+const kindNetwork = "kind"
+
+// dockerBridgeIPv4 is the subnet of the default bridge of the docker daemon
+// of the VM, the kind network must not overlap with it
+var dockerBridgeIPv4 = &net.IPNet{IP: net.IPv4(172, 17, 0, 0).To4(), Mask: net.CIDRMask(16, 32)}
+
+// EnsureKindNetwork creates the kind docker network of the VM with subnets
+// allocated to the VM, so that the kind networks of the VMs don't collide
+// and can all be routed from the host at the same time
+//
+// A kind network which already exists is left as is and its subnets are
+// recorded as allocated to the VM. VMs whose kind networks are not routed
+// from the host are skipped.
+func EnsureKindNetwork(vmName string) error {
+	provider, err := ProviderFor(vmName)
+	if err != nil {
+		return err
+	}
+
+	if !provider.Routed() {
+		return nil
+	}
+
+	owner := models.NewVM(vmName, "", 0).IPOwner()
+	dockerHost := provider.DockerHost(vmName)
+
+	out, err := dockerCommand(dockerHost, "network", "inspect", kindNetwork, "-f", "{{range .IPAM.Config}}{{.Subnet}} {{end}}").Query().Output()
+	if err == nil {
+		return reserveKindNetwork(vmName, owner, strings.Fields(string(out)))
+	}
+
+	subnets := []string{}
+	for _, poolName := range []string{ipam.PoolKindNetworkIPv4, ipam.PoolKindNetworkIPv6} {
+		pool, err := ipam.GetPool(poolName)
+		if err != nil {
+			return err
+		}
+
+		subnet, err := ipam.Allocate(pool, owner, dockerBridgeIPv4)
+		if err != nil {
+			return fmt.Errorf("failed to allocate kind network of VM %s: %w", vmName, err)
+		}
+
+		subnets = append(subnets, subnet.String())
+	}
+
+	logrus.Infof("Creating kind network %s in VM %s", strings.Join(subnets, ","), vmName)
+
+	// Same options as kind uses when it creates the network itself
+	args := []string{
+		"network", "create",
+		"-d=bridge",
+		"-o", "com.docker.network.bridge.enable_ip_masquerade=true",
+		"--ipv6",
+	}
+	for _, subnet := range subnets {
+		args = append(args, "--subnet", subnet)
+	}
+
+	if err := dockerCommand(dockerHost, append(args, kindNetwork)...).RunSilent(); err != nil {
+		return fmt.Errorf("failed to create kind network in VM %s: %w", vmName, err)
+	}
+
+	return nil
+}
+
+// reserveKindNetwork records the subnets of the existing kind network of the
+// VM as allocated to the VM
+func reserveKindNetwork(vmName, owner string, subnets []string) error {
+	for _, subnet := range subnets {
+		ip, _, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return fmt.Errorf("invalid subnet %s of kind network of VM %s: %w", subnet, vmName, err)
+		}
+
+		pool := ipam.PoolKindNetworkIPv4
+		if ip.To4() == nil {
+			pool = ipam.PoolKindNetworkIPv6
+		}
+
+		if err := ipam.ReserveNetwork(pool, subnet, owner); err != nil {
+			logrus.Warnf(
+				"kind network of VM %s collides with the kind network of another VM and can't be routed along with it, "+
+					"delete the clusters of the VM and the \"%s\" docker network of the VM to get a distinct network: %s",
+				vmName,
+				kindNetwork,
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// dockerCommand returns the docker command run against the given daemon
+func dockerCommand(dockerHost string, args ...string) *sh.Command {
+	return sh.NewCommand("docker", args...).WithEnv("DOCKER_HOST", dockerHost).WithEnv("DOCKER_CONTEXT", "")
+}
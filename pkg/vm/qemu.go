@@ -0,0 +1,473 @@
+package vm
+
+import (
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+var (
+	//go:embed qemu.template
+	qemuTemplate string
+
+	// qemuNetwork is the libvirt network the VMs are attached to, it uses
+	// the same addressing as lima's shared network so that the VM IPs
+	// remain the same across the providers
+	qemuNetwork = "kindli"
+
+	qemuNetworkXML = `<network>
+  <name>kindli</name>
+  <forward mode='nat'/>
+  <bridge name='virbr-kindli' stp='on' delay='0'/>
+  <ip address='192.168.105.1' netmask='255.255.255.0'/>
+  <ip family='ipv6' address='fd00:105::1' prefix='64'/>
+</network>`
+
+	qemuNetworkConfig = `version: 2
+ethernets:
+  primary:
+    match:
+      name: "en*"
+    addresses:
+      - %s/24
+      - %s/64
+    gateway4: 192.168.105.1
+    nameservers:
+      addresses: [1.1.1.1, 1.0.0.1]
+`
+
+	qemuImages = map[string]string{
+		"x86_64":  "https://cloud.debian.org/images/cloud/bullseye/20220816-1109/debian-11-generic-amd64-20220816-1109.qcow2",
+		"aarch64": "https://cloud.debian.org/images/cloud/bullseye/20220816-1109/debian-11-generic-arm64-20220816-1109.qcow2",
+	}
+)
+
+// qemuProvider manages QEMU VMs via libvirt
+//
+// The VMs are attached to a dedicated libvirt network and are provisioned
+// using cloud-init. Docker daemon of the VM listens on localhost of the VM
+// only and is forwarded to localhost of the host over SSH.
+type qemuProvider struct{}
+
+func qemuDir(vmName string) string {
+	return filepath.Join(config.Dir(), "qemu", vmName)
+}
+
+func qemuSSHKeyPath() string {
+	return filepath.Join(config.Dir(), "qemu", "id_ed25519")
+}
+
+func (qp *qemuProvider) Name() string {
+	return "qemu"
+}
+
+func (qp *qemuProvider) Start(overrides map[string]interface{}, skipIfExists bool, vmName string) error {
+	if mounts, _ := overrides["Mounts"].([]map[string]interface{}); len(mounts) > 0 {
+		return errors.New("mounts are not supported by the qemu provider yet")
+	}
+
+	logrus.Infoln("Starting VM:", vmName)
+
+	exists, err := qp.Exists(vmName)
+	if err != nil {
+		return fmt.Errorf("failed to check if VM exists: %w", err)
+	}
+
+	isRunning, err := qp.Running(vmName)
+	if err != nil {
+		return fmt.Errorf("failed to check if VM is running: %w", err)
+	}
+
+	if isRunning {
+		if skipIfExists {
+			return qp.forwardPorts(vmName)
+		}
+
+		return fmt.Errorf("vm is already running")
+	}
+
+	if err := qp.setupNetwork(); err != nil {
+		return err
+	}
+
+	if exists || overrides == nil {
+		if err := sh.NewCommand("virsh", "start", vmName).Run(); err != nil {
+			return fmt.Errorf("failed to start VM: %w", err)
+		}
+
+		return qp.forwardPorts(vmName)
+	}
+
+	// Create a new VM
+	if err := os.MkdirAll(qemuDir(vmName), 0777); err != nil {
+		return fmt.Errorf("failed to create VM directory: %w", err)
+	}
+
+	port, err := nextDockerPort()
+	if err != nil {
+		return err
+	}
+
+	vm := models.NewVM(vmName, filepath.Join(qemuDir(vmName), "user-data"), port)
+	vm.Provider = qp.Name()
+
+	pubKey, err := qp.sshPublicKey()
+	if err != nil {
+		return err
+	}
+	overrides["sshPublicKey"] = pubKey
+
+	if err := renderVMConfig(qemuTemplate, overrides, vm); err != nil {
+		return fmt.Errorf("failed to create cloud-init config: %w", err)
+	}
+
+	networkConfig := filepath.Join(qemuDir(vmName), "network-config")
+	if err := os.WriteFile(networkConfig, []byte(fmt.Sprintf(qemuNetworkConfig, overrides["VMIPv4"], overrides["VMIPv6"])), 0644); err != nil {
+		return fmt.Errorf("failed to create cloud-init network config: %w", err)
+	}
+
+	arch, _ := overrides["Arch"].(string)
+	disk, err := qp.createDisk(vmName, arch, fmt.Sprint(overrides["Disk"]))
+	if err != nil {
+		return err
+	}
+
+	memory, err := toMiB(fmt.Sprint(overrides["Memory"]))
+	if err != nil {
+		return err
+	}
+
+	if err := vm.Save(); err != nil {
+		return fmt.Errorf("failed to save vm instance: %w", err)
+	}
+
+	args := []string{
+		"--name", vmName,
+		"--memory", fmt.Sprint(memory),
+		"--vcpus", fmt.Sprint(overrides["CPU"]),
+		"--import",
+		"--disk", fmt.Sprintf("path=%s,format=qcow2", disk),
+		"--cloud-init", fmt.Sprintf("user-data=%s,network-config=%s", vm.LimaConfigPath, networkConfig),
+		"--network", "network=" + qemuNetwork,
+		"--os-variant", "debian11",
+		"--graphics", "none",
+		"--noautoconsole",
+	}
+	if arch != "" {
+		args = append(args, "--arch", arch)
+	}
+
+	if err := sh.NewCommand("virt-install", args...).Run(); err != nil {
+		return fmt.Errorf("failed to start VM: %w", err)
+	}
+
+	return qp.forwardPorts(vmName)
+}
+
+func (qp *qemuProvider) Stop(vmName string) error {
+	isRunning, err := qp.Running(vmName)
+	if err != nil {
+		return err
+	}
+
+	if !isRunning {
+		return errors.New("VM is not in running state")
+	}
+
+	return sh.NewCommand("virsh", "shutdown", vmName).Run()
+}
+
+func (qp *qemuProvider) Delete(vmName string) error {
+	exist, err := qp.Exists(vmName)
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		return errors.New("VM does not exists")
+	}
+
+	vm := models.NewVM(vmName, "", 0)
+	if err := vm.GetByName(); err != nil {
+		return fmt.Errorf("failed to get VM by name: %w", err)
+	}
+
+	if err := sh.NewCommand("virsh", "undefine", "--remove-all-storage", vm.Name).Run(); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(qemuDir(vm.Name)); err != nil {
+		return err
+	}
+
+	return vm.Delete()
+}
+
+func (qp *qemuProvider) Restart(vmName string) error {
+	if err := sh.NewCommand("virsh", "reboot", vmName).Run(); err != nil {
+		return fmt.Errorf("failed to restart VM: %w", err)
+	}
+
+	return qp.forwardPorts(vmName)
+}
+
+func (qp *qemuProvider) Shell(vmName string, args ...string) error {
+	return sh.NewCommand("ssh", append(qp.sshArgs(vmName), args...)...).Run()
+}
+
+func (qp *qemuProvider) Exec(vmName string, args ...string) ([]byte, error) {
+	return sh.NewCommand("ssh", append(qp.sshArgs(vmName), args...)...).Output()
+}
+
+func (qp *qemuProvider) Running(vmName string) (bool, error) {
+	exists, err := qp.Exists(vmName)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	out, err := sh.NewCommand("virsh", "domstate", vmName).Query().Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to get VM status: %s", err)
+	}
+
+	return strings.TrimSpace(string(out)) == "running", nil
+}
+
+func (qp *qemuProvider) DockerHost(vmName string) string {
+	vm := models.NewVM(vmName, "", 0)
+	if err := vm.GetByName(); err != nil {
+		logrus.Debug("failed to get VM by name: ", err)
+	}
+
+	return fmt.Sprintf("tcp://127.0.0.1:%d", vm.DockerPort)
+}
+
+func (qp *qemuProvider) Routed() bool {
+	return true
+}
+
+func (qp *qemuProvider) Exists(vmName string) (bool, error) {
+	out, err := sh.NewCommand("virsh", "list", "--all", "--name").Query().Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to list VMs: %s", err)
+	}
+
+	for _, name := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(name) == vmName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// List returns the libvirt domains created by kindli, these are the
+// domains which have a directory in kindli's config directory
+func (qp *qemuProvider) List() ([]string, error) {
+	out, err := sh.NewCommand("virsh", "list", "--all", "--name").Query().Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VMs: %s", err)
+	}
+
+	vms := []string{}
+	for _, name := range strings.Split(string(out), "\n") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if _, err := os.Stat(qemuDir(name)); err == nil {
+			vms = append(vms, name)
+		}
+	}
+
+	return vms, nil
+}
+
+func (qp *qemuProvider) Adopt(vmName string) error {
+	ip, err := findIPv4(filepath.Join(qemuDir(vmName), "network-config"))
+	if err != nil {
+		return fmt.Errorf("failed to find IP of VM %s: %w", vmName, err)
+	}
+
+	return adopt(qp.Name(), vmName, filepath.Join(qemuDir(vmName), "user-data"), ip)
+}
+
+// forwardPorts forwards the docker port and the port of the registry of
+// the VM, if it has one, to localhost of the host
+func (qp *qemuProvider) forwardPorts(vmName string) error {
+	vm := models.NewVM(vmName, "", 0)
+	if err := vm.GetByName(); err != nil {
+		return fmt.Errorf("failed to get VM by name: %w", err)
+	}
+
+	if err := qp.Forward(vmName, vm.DockerPort); err != nil {
+		return fmt.Errorf("failed to forward docker port of VM %s: %w", vmName, err)
+	}
+
+	r := models.NewRegistry(vmName)
+	if err := r.GetByVM(); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to get registry of VM %s: %w", vmName, err)
+	}
+
+	if err := qp.Forward(vmName, r.Port); err != nil {
+		return fmt.Errorf("failed to forward registry port of VM %s: %w", vmName, err)
+	}
+
+	return nil
+}
+
+// Forward forwards the port of the VM to localhost of the host over SSH,
+// the forward is kept in the background until the VM goes down. An
+// existing forward is left as is.
+func (qp *qemuProvider) Forward(vmName string, port int) error {
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		conn.Close()
+		return nil
+	}
+
+	// The VM might still be booting, sshd is retried for a few minutes
+	args := []string{
+		"-f", "-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "ConnectTimeout=5",
+		"-o", "ConnectionAttempts=60",
+		"-o", "ServerAliveInterval=10",
+		"-o", "ServerAliveCountMax=3",
+		"-L", fmt.Sprintf("%s:%s", addr, addr),
+	}
+
+	return sh.NewCommand("ssh", append(args, qp.sshArgs(vmName)...)...).Run()
+}
+
+// setupNetwork creates and starts the kindli libvirt network if it
+// isn't already active
+func (qp *qemuProvider) setupNetwork() error {
+	out, err := sh.NewCommand("virsh", "net-info", qemuNetwork).Query().Output()
+	if err == nil {
+		if strings.Contains(string(out), "Active:         yes") {
+			return nil
+		}
+
+		return sh.NewCommand("virsh", "net-start", qemuNetwork).Run()
+	}
+
+	path := filepath.Join(config.Dir(), "qemu", "network.xml")
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return fmt.Errorf("failed to create network config: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(qemuNetworkXML), 0644); err != nil {
+		return fmt.Errorf("failed to create network config: %w", err)
+	}
+
+	for _, args := range [][]string{
+		{"net-define", path},
+		{"net-autostart", qemuNetwork},
+		{"net-start", qemuNetwork},
+	} {
+		if err := sh.NewCommand("virsh", args...).Run(); err != nil {
+			return fmt.Errorf("failed to setup libvirt network: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// createDisk creates the disk of the VM backed by the cached cloud image
+func (qp *qemuProvider) createDisk(vmName, arch, size string) (string, error) {
+	if arch == "" {
+		arch = "x86_64"
+		if runtime.GOARCH == "arm64" {
+			arch = "aarch64"
+		}
+	}
+
+	url, ok := qemuImages[arch]
+	if !ok {
+		return "", fmt.Errorf("unsupported VM architecture: %s", arch)
+	}
+
+	base := filepath.Join(config.Dir(), "qemu", filepath.Base(url))
+	if _, err := os.Stat(base); errors.Is(err, os.ErrNotExist) {
+		logrus.Info("Downloading VM image: ", url)
+		if err := sh.NewCommand("curl", "-fL", "-o", base, url).Run(); err != nil {
+			return "", fmt.Errorf("failed to download VM image: %w", err)
+		}
+	}
+
+	disk := filepath.Join(qemuDir(vmName), "disk.qcow2")
+	if err := sh.NewCommand("qemu-img", "create", "-f", "qcow2", "-F", "qcow2", "-b", base, disk, strings.TrimSuffix(size, "iB")).Run(); err != nil {
+		return "", fmt.Errorf("failed to create VM disk: %w", err)
+	}
+
+	return disk, nil
+}
+
+// sshPublicKey returns the public key used to access the VMs, the key
+// pair is generated if it doesn't exist
+func (qp *qemuProvider) sshPublicKey() (string, error) {
+	if _, err := os.Stat(qemuSSHKeyPath()); errors.Is(err, os.ErrNotExist) {
+		if err := sh.NewCommand("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", qemuSSHKeyPath()).RunSilent(); err != nil {
+			return "", fmt.Errorf("failed to generate ssh key: %w", err)
+		}
+	}
+
+	key, err := os.ReadFile(qemuSSHKeyPath() + ".pub")
+	if err != nil {
+		return "", fmt.Errorf("failed to read ssh key: %w", err)
+	}
+
+	return strings.TrimSpace(string(key)), nil
+}
+
+func (qp *qemuProvider) sshArgs(vmName string) []string {
+	vm := models.NewVM(vmName, "", 0)
+	if err := vm.GetByName(); err != nil {
+		logrus.Debug("failed to get VM by name: ", err)
+	}
+
+	username := ""
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+
+	return []string{
+		"-i", qemuSSHKeyPath(),
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+		"-o", "LogLevel=ERROR",
+		fmt.Sprintf("%s@%s", username, vm.GetVMIPv4()),
+	}
+}
+
+// toMiB converts memory in form of <N>GiB or <N>MiB to MiB
+func toMiB(mem string) (int, error) {
+	var size int
+	if _, err := fmt.Sscanf(mem, "%dGiB", &size); err == nil {
+		return size * 1024, nil
+	}
+
+	if _, err := fmt.Sscanf(mem, "%dMiB", &size); err == nil {
+		return size, nil
+	}
+
+	return 0, fmt.Errorf("invalid memory value: %s", mem)
+}
@@ -0,0 +1,101 @@
+package vm
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/utkarsh-pro/kindli/pkg/db"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "kindli-vm-test-*")
+	if err != nil {
+		panic(err)
+	}
+
+	models.RegisterMigrations()
+	db.Setup(filepath.Join(dir, "db.sqlite"))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// freePort returns a port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestQemuProviderStartRejectsMounts(t *testing.T) {
+	rec := sh.Record(t)
+
+	overrides, err := Spec{Mounts: []string{"/tmp:rw"}}.Overrides()
+	if err != nil {
+		t.Fatalf("Overrides() error = %v", err)
+	}
+
+	if err := (&qemuProvider{}).Start(overrides, true, "kindli"); err == nil {
+		t.Fatal("Start() error = nil, want an error")
+	}
+
+	if got := rec.Commands(); len(got) != 0 {
+		t.Errorf("commands = %q, want none", got)
+	}
+}
+
+func TestQemuProviderForwardPorts(t *testing.T) {
+	// The docker port is already forwarded
+	docker, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer docker.Close()
+
+	v := models.NewVM("qemu", "", docker.Addr().(*net.TCPAddr).Port)
+	v.Provider = "qemu"
+	if err := v.Save(); err != nil {
+		t.Fatalf("failed to save VM: %v", err)
+	}
+	t.Cleanup(func() { v.Delete() })
+
+	qp := &qemuProvider{}
+
+	rec := sh.Record(t)
+	if err := qp.forwardPorts("qemu"); err != nil {
+		t.Fatalf("forwardPorts() error = %v", err)
+	}
+
+	if got := rec.Commands(); len(got) != 0 {
+		t.Errorf("commands without registry = %q, want none", got)
+	}
+
+	registry := &models.Registry{VM: "qemu", Port: freePort(t), Image: "registry:2"}
+	if err := registry.Save(); err != nil {
+		t.Fatalf("failed to save registry: %v", err)
+	}
+
+	rec.Reset()
+	if err := qp.forwardPorts("qemu"); err != nil {
+		t.Fatalf("forwardPorts() error = %v", err)
+	}
+
+	got := rec.Commands()
+	forward := fmt.Sprintf("-L 127.0.0.1:%d:127.0.0.1:%d ", registry.Port, registry.Port)
+	if len(got) != 1 || !strings.HasPrefix(got[0], "ssh -f -N ") || !strings.Contains(got[0], forward) {
+		t.Errorf("commands = %q, want an ssh forward of the registry port", got)
+	}
+}
@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultCPU is the number of CPUs of a new VM
+	DefaultCPU = 4
+	// DefaultMemory is the memory of a new VM
+	DefaultMemory = "16GiB"
+	// DefaultDisk is the disk space of a new VM
+	DefaultDisk = "100GiB"
+)
+
+// Spec describes the resources of a new VM
+type Spec struct {
+	CPU    int
+	Memory string
+	Disk   string
+	// Arch is either "x86_64" or "aarch64", empty for the architecture of
+	// the host
+	Arch string
+	// Mounts are in form of <PATH>:rw or <PATH>:ro
+	Mounts []string
+	FIPS   bool
+}
+
+// Validate returns an error if the spec is invalid
+func (s Spec) Validate() error {
+	if s.Arch != "" && s.Arch != "x86_64" && s.Arch != "aarch64" {
+		return fmt.Errorf("invalid arch \"%s\", can be only \"x86_64\" or \"aarch64\"", s.Arch)
+	}
+
+	_, err := parseMounts(s.Mounts)
+	return err
+}
+
+// Overrides returns the overrides of the VM config for the spec
+func (s Spec) Overrides() (map[string]interface{}, error) {
+	mounts, err := parseMounts(s.Mounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"CPU":    s.CPU,
+		"Memory": s.Memory,
+		"Disk":   s.Disk,
+		"Arch":   s.Arch,
+		"FIPS":   s.FIPS,
+		"Mounts": mounts,
+	}, nil
+}
+
+func parseMounts(mounts []string) ([]map[string]interface{}, error) {
+	mapped := []map[string]interface{}{}
+
+	for _, mount := range mounts {
+		splitted := strings.Split(mount, ":")
+		if len(splitted) != 2 {
+			return nil, fmt.Errorf("failed to parse mount: %s", mount)
+		}
+
+		writable := false
+
+		if splitted[1] == "rw" {
+			writable = true
+		}
+
+		mapped = append(mapped, map[string]interface{}{
+			"location": splitted[0],
+			"writable": writable,
+		})
+	}
+
+	return mapped, nil
+}
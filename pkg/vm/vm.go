@@ -0,0 +1,382 @@
+package vm
+
+import (
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"text/template"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/config"
+	"github.com/utkarsh-pro/kindli/pkg/docker"
+	"github.com/utkarsh-pro/kindli/pkg/models"
+)
+
+// Provider is implemented by every VM driver that kindli supports.
+// The provider of a VM is chosen when the VM is created and is persisted
+// along with the VM.
+type Provider interface {
+	// Name returns the name with which the provider is registered
+	Name() string
+	// Start takes VM config overrides and creates (or starts) the VM
+	Start(overrides map[string]interface{}, skipIfExists bool, vmName string) error
+	// Stop stops the running VM
+	Stop(vmName string) error
+	// Delete deletes the stopped VM
+	Delete(vmName string) error
+	// Restart restarts the VM
+	Restart(vmName string) error
+	// Shell opens a shell in the VM or executes the given args in it
+	Shell(vmName string, args ...string) error
+	// Exec executes the given args in the VM and returns the output
+	Exec(vmName string, args ...string) ([]byte, error)
+	// Running returns true if the VM is running
+	Running(vmName string) (bool, error)
+	// Exists returns true if the VM exists
+	Exists(vmName string) (bool, error)
+	// DockerHost returns the endpoint of the docker daemon of the VM
+	DockerHost(vmName string) string
+	// Routed returns true if the host requires routes to reach the kind
+	// networks of the VM
+	Routed() bool
+}
+
+// Adopter is implemented by the providers which can find the VMs created
+// by kindli, including the ones missing from the kindli store, and can
+// add the missing ones back to the store
+type Adopter interface {
+	// List returns the names of all the VMs created by kindli
+	List() ([]string, error)
+	// Adopt adds the VM to the kindli store
+	Adopt(vmName string) error
+}
+
+// Forwarder is implemented by the providers which don't forward the ports
+// published in the VM to localhost of the host on their own
+type Forwarder interface {
+	// Forward forwards the port of the VM to the same port on localhost
+	// of the host
+	Forward(vmName string, port int) error
+}
+
+// HostProvider is the name of the provider which runs the clusters on the
+// docker daemon of the host, its VMs are never stopped or deleted
+const HostProvider = "host"
+
+var (
+	//go:embed vm.template
+	vmTemplate string
+
+	providers = map[string]Provider{}
+)
+
+func init() {
+	Register(newLimaProvider())
+	Register(&qemuProvider{})
+}
+
+// Register registers a VM provider, registering a provider with
+// the name of an already registered provider replaces it
+func Register(provider Provider) {
+	providers[provider.Name()] = provider
+}
+
+// Providers returns the names of all the registered providers
+func Providers() []string {
+	names := []string{}
+	for name := range providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// GetProvider returns the provider registered with the given name
+func GetProvider(name string) (Provider, error) {
+	provider, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown VM provider \"%s\" - available providers: %v", name, Providers())
+	}
+
+	return provider, nil
+}
+
+// ProviderFor returns the provider of the given VM, VMs that kindli
+// does not know about are assumed to be managed by the default provider
+func ProviderFor(vmName string) (Provider, error) {
+	vm := models.NewVM(vmName, "", 0)
+	if err := vm.GetByName(); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get VM by name: %w", err)
+		}
+	}
+
+	return GetProvider(vm.Provider)
+}
+
+// Start takes VM config overrides and creates a VM using the given provider.
+//
+// If the VM already exists then it is started using the provider it was
+// created with and providerName is ignored.
+func Start(providerName string, overrides map[string]interface{}, skipIfExists bool, vmName string) error {
+	vm := models.NewVM(vmName, "", 0)
+	exists, err := vm.Exists()
+	if err != nil {
+		return fmt.Errorf("failed to check if VM exists: %w", err)
+	}
+
+	var provider Provider
+	if exists || providerName == "" {
+		if provider, err = ProviderFor(vmName); err != nil {
+			return err
+		}
+
+		if providerName != "" && providerName != provider.Name() {
+			logrus.Warnf("VM %s is managed by provider \"%s\" - ignoring provider \"%s\"", vmName, provider.Name(), providerName)
+		}
+	} else if provider, err = GetProvider(providerName); err != nil {
+		return err
+	}
+
+	if err := provider.Start(overrides, skipIfExists, vmName); err != nil {
+		return err
+	}
+
+	// The kind network is created again before the first cluster if this fails
+	if err := EnsureKindNetwork(vmName); err != nil {
+		logrus.Warnf("failed to setup kind network of VM %s: %s", vmName, err)
+	}
+
+	return nil
+}
+
+// Stop stops the currently running VM
+func Stop(vmName string) error {
+	provider, err := ProviderFor(vmName)
+	if err != nil {
+		return err
+	}
+
+	return provider.Stop(vmName)
+}
+
+// Delete deletes the stopped VM
+func Delete(vmName string) error {
+	provider, err := ProviderFor(vmName)
+	if err != nil {
+		return err
+	}
+
+	return provider.Delete(vmName)
+}
+
+// Restart restarts the VM
+func Restart(vmName string) error {
+	provider, err := ProviderFor(vmName)
+	if err != nil {
+		return err
+	}
+
+	return provider.Restart(vmName)
+}
+
+// Shell opens the shell of the VM
+func Shell(vmName string, args ...string) error {
+	provider, err := ProviderFor(vmName)
+	if err != nil {
+		return err
+	}
+
+	return provider.Shell(vmName, args...)
+}
+
+// Exec executes the given command in the VM and returns its output
+func Exec(vmName string, args ...string) ([]byte, error) {
+	provider, err := ProviderFor(vmName)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.Exec(vmName, args...)
+}
+
+// Running checks if the VM is running
+func Running(vmName string) (bool, error) {
+	provider, err := ProviderFor(vmName)
+	if err != nil {
+		return false, err
+	}
+
+	return provider.Running(vmName)
+}
+
+// DockerHost returns the endpoint of the docker daemon of the VM
+func DockerHost(vmName string) (string, error) {
+	provider, err := ProviderFor(vmName)
+	if err != nil {
+		return "", err
+	}
+
+	return provider.DockerHost(vmName), nil
+}
+
+// Forward makes the port published in the VM reachable on localhost of
+// the host, nothing is done for the providers which forward the ports on
+// their own
+func Forward(vmName string, port int) error {
+	provider, err := ProviderFor(vmName)
+	if err != nil {
+		return err
+	}
+
+	if f, ok := provider.(Forwarder); ok {
+		return f.Forward(vmName, port)
+	}
+
+	return nil
+}
+
+// UseDockerContext creates the docker context of the VM if it is missing
+// and makes it the default docker context
+func UseDockerContext(vmName string) error {
+	if err := EnsureDockerContext(vmName); err != nil {
+		return err
+	}
+
+	return docker.UseContext(vmName)
+}
+
+// EnsureDockerContext creates the docker context of the VM if it is
+// missing, the default docker context is left as is
+func EnsureDockerContext(vmName string) error {
+	exists, err := docker.ExistsContext(vmName)
+	if err != nil || exists {
+		return err
+	}
+
+	dockerHost, err := DockerHost(vmName)
+	if err != nil {
+		return err
+	}
+
+	return docker.CreateContext(vmName, fmt.Sprintf("host=%s", dockerHost))
+}
+
+// List returns a list of all the VMs
+func List() ([]string, error) {
+	vms, err := models.ListVM()
+	if err != nil {
+		return nil, err
+	}
+
+	var vmNames []string
+	for _, vm := range vms {
+		vmNames = append(vmNames, vm.Name)
+	}
+
+	return vmNames, nil
+}
+
+// adopt saves the VM with the given IPv4 address in the store, the ID of
+// the VM is derived from the address so that the VM keeps its address
+func adopt(provider, vmName, configPath, ipv4 string) error {
+	var id uint
+	if _, err := fmt.Sscanf(ipv4, "192.168.105.%d", &id); err != nil || id < 10 {
+		return fmt.Errorf("failed to derive VM ID from IP %s", ipv4)
+	}
+
+	port, err := nextDockerPort()
+	if err != nil {
+		return err
+	}
+
+	vm := models.NewVM(vmName, configPath, port)
+	vm.ID = id - 10
+	vm.Provider = provider
+
+	if err := vm.Save(); err != nil {
+		return fmt.Errorf("failed to save vm instance: %w", err)
+	}
+
+	return nil
+}
+
+// findIPv4 returns the first 192.168.105.0/24 address in the given file
+func findIPv4(path string) (string, error) {
+	byt, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	match := regexp.MustCompile(`192\.168\.105\.\d+`).Find(byt)
+	if match == nil {
+		return "", fmt.Errorf("no VM IP found in %s", path)
+	}
+
+	return string(match), nil
+}
+
+func vmFilePath(vmName string) string {
+	return filepath.Join(config.Dir(), fmt.Sprintf("%s.yaml", vmName))
+}
+
+func defaultDockerPort() int {
+	return 2375
+}
+
+// nextDockerPort returns the docker port that should be assigned to a new VM
+func nextDockerPort() (int, error) {
+	port, err := models.GetMaxVMDockerPort()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get max docker port for the VM: %w", err)
+	}
+	if port == 0 {
+		return defaultDockerPort(), nil
+	}
+
+	return port + 1, nil
+}
+
+// renderVMConfig renders the given template into the config file of the VM
+// along with the overrides common to all the providers
+func renderVMConfig(tmpl string, overrides map[string]interface{}, vm *models.VM) error {
+	logrus.Debug("Creating VM config at:", vm.LimaConfigPath)
+	u, err := user.Current()
+	if err != nil {
+		return fmt.Errorf("failed to find username: %w", err)
+	}
+	overrides["user"] = u.Username
+	overrides["vmName"] = vm.Name
+	overrides["dockerPort"] = vm.DockerPort
+
+	vmID, err := models.GetNextVMID() // This ID will be assigned to the VM automatically
+	if err != nil {
+		return fmt.Errorf("failed to generate VM ID: %w", err)
+	}
+	overrides["VMIPv4"] = models.GetVMIPv4(vmID)
+	overrides["VMIPv6"] = models.GetVMIPv6(vmID)
+
+	file, err := os.Create(vm.LimaConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to create VM config file: %w", err)
+	}
+	defer file.Close()
+
+	parsed, err := template.New(vm.Name).Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("failed to parse VM config template: %w", err)
+	}
+
+	if err := parsed.Execute(file, overrides); err != nil {
+		return fmt.Errorf("failed to execute VM config template: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package vm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/utkarsh-pro/kindli/pkg/sh"
+)
+
+const (
+	// vdeSwitchDir is the directory where the VDE switch control socket lives
+	vdeSwitchDir = "/var/run/kindli"
+	// vdeTapIf is the name of the tap interface which connects the VDE switch
+	// to the host
+	vdeTapIf = "kindli0"
+	// vdeHostIPv4 is the address of the host on the VM network, this mirrors
+	// the gateway address lima uses on macOS
+	vdeHostIPv4 = "192.168.105.1/24"
+	vdeHostIPv6 = "fd00:105::1/64"
+)
+
+func init() {
+	Register(&hostProvider{})
+}
+
+// newLimaProvider returns the lima provider for Linux hosts
+//
+// Lima's shared network is macOS only and hence on Linux the VMs are
+// attached to a VDE switch which is connected to the host via a tap
+// interface. This gives the host L2 reachability to the VMs, the same
+// as on macOS.
+func newLimaProvider() Provider {
+	return &limaProvider{
+		prepareHost: func(overrides map[string]interface{}) error {
+			if err := setupVDESwitch(); err != nil {
+				return err
+			}
+
+			if overrides != nil {
+				overrides["VDESwitch"] = vdeSwitchPath()
+			}
+
+			return nil
+		},
+	}
+}
+
+func vdeSwitchPath() string {
+	return filepath.Join(vdeSwitchDir, "vde.ctl")
+}
+
+// setupVDESwitch starts the VDE switch and configures the tap interface on
+// the host if they are not already running
+func setupVDESwitch() error {
+	if _, err := os.Stat(filepath.Join(vdeSwitchPath(), "ctl")); err == nil {
+		logrus.Debug("VDE switch is already running")
+		return nil
+	}
+
+	logrus.Info("Starting VDE switch (requires root access)...")
+	if err := sh.NewCommand("sudo", "mkdir", "-p", vdeSwitchDir).Run(); err != nil {
+		return fmt.Errorf("failed to start VDE switch: %w", err)
+	}
+	if err := sh.NewCommand("sudo", "vde_switch", "--tap", vdeTapIf, "--sock", vdeSwitchPath(), "--daemon", "--mod", "777").Run(); err != nil {
+		return fmt.Errorf("failed to start VDE switch: %w", err)
+	}
+
+	for _, addr := range []string{vdeHostIPv4, vdeHostIPv6} {
+		if err := sh.NewCommand("sudo", "ip", "addr", "replace", addr, "dev", vdeTapIf).Run(); err != nil {
+			return fmt.Errorf("failed to configure %s interface: %w", vdeTapIf, err)
+		}
+	}
+	if err := sh.NewCommand("sudo", "ip", "link", "set", vdeTapIf, "up").Run(); err != nil {
+		return fmt.Errorf("failed to configure %s interface: %w", vdeTapIf, err)
+	}
+
+	return nil
+}